@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"strings"
+	"time"
+
+	dbmodels "gera-ai/internal/models/database"
+	"gera-ai/internal/models/requests"
+	"gera-ai/internal/models/responses"
+	"gera-ai/internal/utils/jwtUtils"
+	"gera-ai/internal/utils/token"
+	"gera-ai/internal/utils/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func accessTokenToDTO(accessToken dbmodels.AccessToken) responses.AccessTokenDTO {
+	return responses.AccessTokenDTO{
+		ID:         accessToken.ID,
+		Name:       accessToken.Name,
+		Hint:       accessToken.Hint,
+		Scopes:     strings.Fields(accessToken.Scopes),
+		CreatedAt:  accessToken.CreatedAt,
+		ExpiresAt:  accessToken.ExpiresAt,
+		LastUsedAt: accessToken.LastUsedAt,
+	}
+}
+
+// CreateAccessToken creates a personal access token
+// @Summary      Create a personal access token
+// @Description  Creates a token for scripts and integrations. Send it as "Authorization: Bearer gat_..."; it works only on routes that allow one of its scopes (tasks:read, tasks:write, generate, history:read, history:write). The token is shown only in this response
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      requests.CreateAccessToken  true  "Name, scopes and lifetime"
+// @Success      200      {object}  responses.CreateAccessTokenDTO
+// @Failure      400      {object}  responses.ErrorResponse
+// @Failure      422      {object}  responses.ValidationErrorResponse
+// @Failure      500      {object}  responses.ErrorResponse
+// @Router       /api/auth/tokens/new [post]
+func CreateAccessToken(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.CreateAccessToken{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		secret, err := token.Generate()
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to create token",
+				Error:  err.Error(),
+			})
+		}
+		raw := dbmodels.AccessTokenPrefix + secret
+
+		// Повторы областей не нужны, порядок сохраняется как в запросе
+		var scopes []string
+		seen := make(map[string]bool, len(data.Scopes))
+		for _, scope := range data.Scopes {
+			if !seen[scope] {
+				seen[scope] = true
+				scopes = append(scopes, scope)
+			}
+		}
+
+		accessToken := dbmodels.AccessToken{
+			UserID:    userID,
+			Name:      data.Name,
+			TokenHash: token.Hash(raw),
+			Hint:      raw[:len(dbmodels.AccessTokenPrefix)+6],
+			Scopes:    strings.Join(scopes, " "),
+		}
+		if data.ExpiresInDays > 0 {
+			expiresAt := time.Now().AddDate(0, 0, data.ExpiresInDays)
+			accessToken.ExpiresAt = &expiresAt
+		}
+		if err := db.Create(&accessToken).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to create token",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.CreateAccessTokenDTO{
+			Status:      "token created",
+			Token:       raw,
+			AccessToken: accessTokenToDTO(accessToken),
+		})
+	}
+}
+
+// GetAccessTokens lists personal access tokens of the user
+// @Summary      List personal access tokens
+// @Description  Returns the tokens of the user, newest first, including expired ones. Token values are not returned
+// @Tags         Auth
+// @Produce      json
+// @Param        cursor         query     string  false  "Cursor returned as page.next_cursor by the previous page"
+// @Param        limit          query     int     false  "Page size (default is 20, max is 100)"
+// @Param        include_total  query     bool    false  "Count all tokens and return page.total"
+// @Success      200      {object}  responses.GetAccessTokensDTO
+// @Failure      400      {object}  responses.ErrorResponse
+// @Failure      500      {object}  responses.ErrorResponse
+// @Router       /api/auth/tokens [get]
+func GetAccessTokens(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		page, err := parsePage(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid page",
+				Error:  err.Error(),
+			})
+		}
+		if validationErrors := validator.ValidateStruct(page); validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		query := db.Model(&dbmodels.AccessToken{}).Where("user_id = ?", userID)
+		total, err := countPage(query, page)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		order := pageOrder{IDColumn: "id", Desc: true}
+		query, err = paginate(query, order, page)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid cursor",
+				Error:  err.Error(),
+			})
+		}
+
+		var accessTokens []dbmodels.AccessToken
+		if err := query.Find(&accessTokens).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+
+		accessTokens, pageDTO, err := finishPage(accessTokens, page, total, func(accessToken dbmodels.AccessToken) pagePosition {
+			return pagePosition{ID: accessToken.ID}
+		})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to encode cursor",
+				Error:  err.Error(),
+			})
+		}
+
+		tokensDTO := make([]responses.AccessTokenDTO, len(accessTokens))
+		for i, accessToken := range accessTokens {
+			tokensDTO[i] = accessTokenToDTO(accessToken)
+		}
+
+		return c.Status(200).JSON(responses.GetAccessTokensDTO{
+			Tokens: tokensDTO,
+			Page:   pageDTO,
+		})
+	}
+}
+
+// RevokeAccessToken revokes a personal access token
+// @Summary      Revoke a personal access token
+// @Description  Deletes a token of the user. Requests with it are rejected immediately
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      requests.RevokeAccessToken  true  "Token"
+// @Success      200      {object}  responses.SessionStatusDTO
+// @Failure      400      {object}  responses.ErrorResponse
+// @Failure      404      {object}  responses.ErrorResponse
+// @Failure      422      {object}  responses.ValidationErrorResponse
+// @Failure      500      {object}  responses.ErrorResponse
+// @Router       /api/auth/tokens/revoke [delete]
+func RevokeAccessToken(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.RevokeAccessToken{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		result := db.Where("id = ? AND user_id = ?", data.ID, userID).Delete(&dbmodels.AccessToken{})
+		if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to revoke token",
+				Error:  result.Error.Error(),
+			})
+		}
+		if result.RowsAffected == 0 {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "token not found",
+			})
+		}
+
+		return c.Status(200).JSON(responses.SessionStatusDTO{
+			Status: "token revoked",
+		})
+	}
+}
@@ -0,0 +1,791 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	dbmodels "gera-ai/internal/models/database"
+	"gera-ai/internal/models/requests"
+	"gera-ai/internal/models/responses"
+	"gera-ai/internal/utils/jwtUtils"
+	"gera-ai/internal/utils/listQuery"
+	"gera-ai/internal/utils/taskGenerator"
+	"gera-ai/internal/utils/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// LoadPrompts применяет к генератору промпты, измененные администраторами
+func LoadPrompts(db *gorm.DB, tg *taskGenerator.TaskGenerator) error {
+	var prompts []dbmodels.Prompt
+	if err := db.Find(&prompts).Error; err != nil {
+		return err
+	}
+	for _, prompt := range prompts {
+		tg.SetPrompt(prompt.Key, taskGenerator.Prompt{Text: prompt.Text, Revision: prompt.Revision})
+	}
+	return nil
+}
+
+func adminUserToDTO(user dbmodels.User) responses.AdminUserDTO {
+	return responses.AdminUserDTO{
+		ID:          user.ID,
+		Login:       user.Login,
+		Username:    user.Username,
+		Role:        user.Role,
+		BlockedAt:   user.BlockedAt,
+		BlockReason: user.BlockReason,
+		CreatedAt:   user.CreatedAt,
+	}
+}
+
+func promptToDTO(tg *taskGenerator.TaskGenerator, key string, updatedAt *time.Time) responses.PromptDTO {
+	text, customized := tg.PromptText(key)
+	if !customized {
+		updatedAt = nil
+	}
+	return responses.PromptDTO{
+		Key:         key,
+		Text:        text,
+		DefaultText: taskGenerator.DefaultPrompts[key],
+		Version:     tg.PromptVersion(key),
+		Customized:  customized,
+		UpdatedAt:   updatedAt,
+	}
+}
+
+// findManagedUser загружает пользователя, которым управляет администратор.
+// Свою учетную запись администратор менять не может, чтобы не лишиться доступа
+func findManagedUser(db *gorm.DB, adminID, userID uint) (dbmodels.User, int, error) {
+	if adminID == userID {
+		return dbmodels.User{}, 422, errors.New("you cannot change your own account")
+	}
+
+	var user dbmodels.User
+	result := db.First(&user, userID)
+	if result.Error == gorm.ErrRecordNotFound {
+		return user, 404, errors.New("user not found")
+	} else if result.Error != nil {
+		return user, 500, result.Error
+	}
+	return user, 200, nil
+}
+
+// userListSpec - поля списка пользователей, по которым можно сортировать и фильтровать
+var userListSpec = listQuery.Spec{
+	Sort: map[string]listQuery.Field{
+		"login":      {Column: "login", Kind: listQuery.Text},
+		"created_at": {Column: "created_at", Kind: listQuery.Time},
+	},
+	Filters: map[string]listQuery.Field{
+		"login":    {Column: "login", Kind: listQuery.Text},
+		"username": {Column: "username", Kind: listQuery.Text},
+		"created":  {Column: "created_at", Kind: listQuery.Time},
+	},
+	Equal: map[string]listQuery.Field{
+		"role": {Column: "role", Kind: listQuery.Text},
+	},
+}
+
+// GetUsers lists users for administrators
+// @Summary List users
+// @Description Returns users ordered by registration by default, with sorting, field filters and cursor pagination. The total is always returned
+// @Tags Admin
+// @Produce json
+// @Param login query string false "Deprecated: same as login_prefix"
+// @Param login_prefix query string false "Only users whose login starts with this text"
+// @Param login_contains query string false "Only users whose login contains this text"
+// @Param username_contains query string false "Only users whose name contains this text"
+// @Param created_after query string false "Only users registered after this time (RFC3339)"
+// @Param created_before query string false "Only users registered before this time (RFC3339)"
+// @Param role query string false "Role" Enums(teacher, moderator, admin)
+// @Param sort query string false "Sort field, prefix with - for descending order (default is registration order)" Enums(login, -login, created_at, -created_at)
+// @Param cursor query string false "Cursor returned as page.next_cursor by the previous page"
+// @Param limit query int false "Page size (default is 20, max is 100)"
+// @Param offset query int false "Deprecated: page number, used only without a cursor"
+// @Success 200 {object} responses.GetUsersDTO "Users successfully retrieved"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token, cursor or filter"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/admin/users [get]
+func GetUsers(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		page, err := parsePage(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid page",
+				Error:  err.Error(),
+			})
+		}
+		// Администраторам общее число нужно всегда, как и до появления курсоров
+		page.IncludeTotal = true
+
+		data := requests.GetUsers{
+			Role: c.Query("role"),
+			Page: page,
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		// login остался от прежнего API и означает начало логина
+		params := c.Queries()
+		if login, ok := params["login"]; ok {
+			delete(params, "login")
+			if _, ok := params["login_prefix"]; !ok {
+				params["login_prefix"] = login
+			}
+		}
+		list, err := listQuery.Parse(params, userListSpec)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid query",
+				Error:  err.Error(),
+			})
+		}
+
+		query := list.Apply(db.Model(&dbmodels.User{}))
+
+		total, err := countPage(query, data.Page)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		order := sortedPageOrder(list.Sort, pageOrder{IDColumn: "id"})
+		query, err = paginate(query, order, data.Page)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid cursor",
+				Error:  err.Error(),
+			})
+		}
+
+		var users []dbmodels.User
+		if err := query.Find(&users).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+
+		users, pageDTO, err := finishPage(users, data.Page, total, func(user dbmodels.User) pagePosition {
+			position := pagePosition{Sort: order.Sort, ID: user.ID}
+			switch order.Field {
+			case "login":
+				position.Text = &user.Login
+			case "created_at":
+				position.Time = &user.CreatedAt
+			}
+			return position
+		})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to encode cursor",
+				Error:  err.Error(),
+			})
+		}
+
+		usersDTO := make([]responses.AdminUserDTO, len(users))
+		for i, user := range users {
+			usersDTO[i] = adminUserToDTO(user)
+		}
+
+		return c.Status(200).JSON(responses.GetUsersDTO{
+			Users: usersDTO,
+			Total: *total,
+			Page:  pageDTO,
+		})
+	}
+}
+
+// BlockUser blocks a user account
+// @Summary Block a user
+// @Description Blocks a user and revokes all of their sessions. A blocked user cannot log in
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param input body requests.BlockUser true "User and reason"
+// @Success 200 {object} responses.AdminUserStatusDTO "User blocked"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "User not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/admin/users/block [put]
+func BlockUser(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		adminID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.BlockUser{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		user, status, err := findManagedUser(db, adminID, data.ID)
+		if err != nil {
+			return c.Status(status).JSON(responses.ErrorResponse{
+				Status: "user unavailable",
+				Error:  err.Error(),
+			})
+		}
+
+		now := time.Now()
+		user.BlockedAt = &now
+		user.BlockReason = data.Reason
+		if err := db.Model(&user).Select("BlockedAt", "BlockReason").Updates(&user).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to block user",
+				Error:  err.Error(),
+			})
+		}
+		if _, err := revokeSessions(db, user.ID); err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to revoke sessions",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.AdminUserStatusDTO{
+			Status: "user blocked",
+			User:   adminUserToDTO(user),
+		})
+	}
+}
+
+// UnblockUser unblocks a user account
+// @Summary Unblock a user
+// @Description Restores access for a blocked user
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param input body requests.UnblockUser true "User"
+// @Success 200 {object} responses.AdminUserStatusDTO "User unblocked"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "User not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/admin/users/unblock [put]
+func UnblockUser(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		adminID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.UnblockUser{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		user, status, err := findManagedUser(db, adminID, data.ID)
+		if err != nil {
+			return c.Status(status).JSON(responses.ErrorResponse{
+				Status: "user unavailable",
+				Error:  err.Error(),
+			})
+		}
+
+		user.BlockedAt = nil
+		user.BlockReason = ""
+		if err := db.Model(&user).Select("BlockedAt", "BlockReason").Updates(&user).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to unblock user",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.AdminUserStatusDTO{
+			Status: "user unblocked",
+			User:   adminUserToDTO(user),
+		})
+	}
+}
+
+// ChangeUserRole assigns a role to a user
+// @Summary Change a user role
+// @Description Assigns teacher, moderator or admin role. The new role is applied to tokens issued after the change
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param input body requests.ChangeUserRole true "User and role"
+// @Success 200 {object} responses.AdminUserStatusDTO "Role changed"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "User not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/admin/users/role [put]
+func ChangeUserRole(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		adminID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.ChangeUserRole{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		user, status, err := findManagedUser(db, adminID, data.ID)
+		if err != nil {
+			return c.Status(status).JSON(responses.ErrorResponse{
+				Status: "user unavailable",
+				Error:  err.Error(),
+			})
+		}
+
+		user.Role = data.Role
+		if err := db.Model(&user).Update("role", data.Role).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to change role",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.AdminUserStatusDTO{
+			Status: "role changed",
+			User:   adminUserToDTO(user),
+		})
+	}
+}
+
+// auditListSpec - поля журнала аудита, по которым можно сортировать и фильтровать
+var auditListSpec = listQuery.Spec{
+	Sort: map[string]listQuery.Field{
+		"created_at": {Column: "created_at", Kind: listQuery.Time},
+	},
+	Filters: map[string]listQuery.Field{
+		"details": {Column: "details", Kind: listQuery.Text},
+		"created": {Column: "created_at", Kind: listQuery.Time},
+	},
+	Equal: map[string]listQuery.Field{
+		"type":    {Column: "type", Kind: listQuery.Text},
+		"user_id": {Column: "user_id", Kind: listQuery.Number},
+		"ip":      {Column: "ip", Kind: listQuery.Text},
+	},
+}
+
+// GetAuditEvents lists security audit events
+// @Summary List audit events
+// @Description Returns audit events (such as login lockouts), newest first by default, with sorting, field filters and cursor pagination. The total is always returned
+// @Tags Admin
+// @Produce json
+// @Param type query string false "Event type" Enums(login_lockout, account_deleted)
+// @Param user_id query int false "User ID"
+// @Param ip query string false "Client address"
+// @Param details_contains query string false "Only events whose details contain this text"
+// @Param created_after query string false "Only events after this time (RFC3339)"
+// @Param created_before query string false "Only events before this time (RFC3339)"
+// @Param sort query string false "Sort field, prefix with - for descending order (default is newest first)" Enums(created_at, -created_at)
+// @Param cursor query string false "Cursor returned as page.next_cursor by the previous page"
+// @Param limit query int false "Page size (default is 20, max is 100)"
+// @Param offset query int false "Deprecated: page number, used only without a cursor"
+// @Success 200 {object} responses.GetAuditEventsDTO "Events successfully retrieved"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token, cursor or filter"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/admin/audit [get]
+func GetAuditEvents(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		page, err := parsePage(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid page",
+				Error:  err.Error(),
+			})
+		}
+		page.IncludeTotal = true
+
+		data := requests.GetAuditEvents{
+			Type: c.Query("type"),
+			Page: page,
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		list, err := listQuery.Parse(c.Queries(), auditListSpec)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid query",
+				Error:  err.Error(),
+			})
+		}
+
+		query := list.Apply(db.Model(&dbmodels.AuditEvent{}))
+
+		total, err := countPage(query, data.Page)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		order := sortedPageOrder(list.Sort, pageOrder{IDColumn: "id", Desc: true})
+		query, err = paginate(query, order, data.Page)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid cursor",
+				Error:  err.Error(),
+			})
+		}
+
+		var events []dbmodels.AuditEvent
+		if err := query.Find(&events).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+
+		events, pageDTO, err := finishPage(events, data.Page, total, func(event dbmodels.AuditEvent) pagePosition {
+			position := pagePosition{Sort: order.Sort, ID: event.ID}
+			if order.Field == "created_at" {
+				position.Time = &event.CreatedAt
+			}
+			return position
+		})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to encode cursor",
+				Error:  err.Error(),
+			})
+		}
+
+		eventsDTO := make([]responses.AuditEventDTO, len(events))
+		for i, event := range events {
+			eventsDTO[i] = responses.AuditEventDTO{
+				ID:        event.ID,
+				Type:      event.Type,
+				UserID:    event.UserID,
+				IP:        event.IP,
+				Details:   event.Details,
+				CreatedAt: event.CreatedAt,
+			}
+		}
+
+		return c.Status(200).JSON(responses.GetAuditEventsDTO{
+			Events: eventsDTO,
+			Total:  *total,
+			Page:   pageDTO,
+		})
+	}
+}
+
+// GetUsage returns service usage statistics
+// @Summary Usage statistics
+// @Description Returns user, task and generation counts for the last days, generations by type and model, and the most active users. Deleted generations are counted too
+// @Tags Admin
+// @Produce json
+// @Param days query int false "Period in days, 30 by default"
+// @Success 200 {object} responses.UsageDTO "Usage statistics"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/admin/usage [get]
+func GetUsage(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		data := requests.GetUsage{Days: c.QueryInt("days", 30)}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		usage := responses.UsageDTO{
+			Since:              time.Now().AddDate(0, 0, -data.Days),
+			GenerationsByType:  map[string]int64{},
+			GenerationsByModel: map[string]int64{},
+			TopUsers:           []responses.UserUsageDTO{},
+		}
+		// Генерации считаются вместе с удаленными: запросы к модели уже были оплачены
+		generations := func() *gorm.DB {
+			return db.Unscoped().Model(&dbmodels.Generation{}).Where("generations.created_at >= ?", usage.Since)
+		}
+
+		var grouped []struct {
+			Key   string
+			Count int64
+		}
+		collect := func() error {
+			counters := []struct {
+				query *gorm.DB
+				value *int64
+			}{
+				{query: db.Model(&dbmodels.User{}), value: &usage.Users},
+				{query: db.Model(&dbmodels.User{}).Where("created_at >= ?", usage.Since), value: &usage.NewUsers},
+				{query: db.Model(&dbmodels.User{}).Where("blocked_at IS NOT NULL"), value: &usage.BlockedUsers},
+				{query: db.Model(&dbmodels.Task{}).Where("created_at >= ?", usage.Since), value: &usage.Tasks},
+				{query: generations(), value: &usage.Generations},
+			}
+			for _, counter := range counters {
+				if err := counter.query.Count(counter.value).Error; err != nil {
+					return err
+				}
+			}
+
+			for column, target := range map[string]map[string]int64{
+				"type":     usage.GenerationsByType,
+				"ai_model": usage.GenerationsByModel,
+			} {
+				grouped = grouped[:0]
+				err := generations().Select(column + " AS key, COUNT(*) AS count").Group(column).Scan(&grouped).Error
+				if err != nil {
+					return err
+				}
+				for _, row := range grouped {
+					target[row.Key] = row.Count
+				}
+			}
+
+			return generations().
+				Select("generations.user_id, users.login, COUNT(*) AS generations").
+				Joins("JOIN users ON users.id = generations.user_id").
+				Group("generations.user_id, users.login").
+				Order("generations DESC").
+				Limit(10).
+				Scan(&usage.TopUsers).Error
+		}
+		if err := collect(); err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(usage)
+	}
+}
+
+// GetPrompts lists generator prompts
+// @Summary List prompts
+// @Description Returns the current text, default text and version of every generator prompt
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} responses.GetPromptsDTO "Prompts successfully retrieved"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/admin/prompts [get]
+func GetPrompts(db *gorm.DB, tg *taskGenerator.TaskGenerator) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var prompts []dbmodels.Prompt
+		if err := db.Find(&prompts).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		updated := make(map[string]time.Time, len(prompts))
+		for _, prompt := range prompts {
+			updated[prompt.Key] = prompt.UpdatedAt
+		}
+
+		promptsDTO := make([]responses.PromptDTO, len(taskGenerator.PromptKeys))
+		for i, key := range taskGenerator.PromptKeys {
+			var updatedAt *time.Time
+			if t, ok := updated[key]; ok {
+				updatedAt = &t
+			}
+			promptsDTO[i] = promptToDTO(tg, key, updatedAt)
+		}
+
+		return c.Status(200).JSON(responses.GetPromptsDTO{
+			Prompts: promptsDTO,
+		})
+	}
+}
+
+// EditPrompt replaces the text of a generator prompt
+// @Summary Edit a prompt
+// @Description Replaces a generator prompt. The text must keep the placeholders of the default prompt, e.g. {condition}. Each edit gets a new version which is saved with generations
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param input body requests.EditPrompt true "Prompt key and text"
+// @Success 200 {object} responses.EditPromptDTO "Prompt updated"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error or missing placeholder"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/admin/prompts [put]
+func EditPrompt(db *gorm.DB, tg *taskGenerator.TaskGenerator) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		adminID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.EditPrompt{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		if err := taskGenerator.ValidatePrompt(data.Key, data.Text); err != nil {
+			return c.Status(422).JSON(responses.ErrorResponse{
+				Status: "invalid prompt",
+				Error:  err.Error(),
+			})
+		}
+
+		// Сброшенный промпт остается в базе удаленным, чтобы номер правки не повторялся
+		var prompt dbmodels.Prompt
+		err = db.Transaction(func(tx *gorm.DB) error {
+			result := tx.Unscoped().Where("key = ?", data.Key).First(&prompt)
+			if result.Error != nil && result.Error != gorm.ErrRecordNotFound {
+				return result.Error
+			}
+
+			prompt.Key = data.Key
+			prompt.Text = data.Text
+			prompt.Revision++
+			prompt.UpdatedByID = adminID
+			prompt.DeletedAt = gorm.DeletedAt{}
+			prompt.UpdatedAt = time.Now()
+			if prompt.ID == 0 {
+				prompt.CreatedAt = time.Now()
+			}
+			return tx.Unscoped().Save(&prompt).Error
+		})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to update prompt",
+				Error:  err.Error(),
+			})
+		}
+
+		tg.SetPrompt(prompt.Key, taskGenerator.Prompt{Text: prompt.Text, Revision: prompt.Revision})
+
+		return c.Status(200).JSON(responses.EditPromptDTO{
+			Status: "prompt updated",
+			Prompt: promptToDTO(tg, prompt.Key, &prompt.UpdatedAt),
+		})
+	}
+}
+
+// ResetPrompt restores the default text of a generator prompt
+// @Summary Reset a prompt
+// @Description Restores the default prompt text and version
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param input body requests.ResetPrompt true "Prompt key"
+// @Success 200 {object} responses.EditPromptDTO "Prompt reset"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/admin/prompts/reset [delete]
+func ResetPrompt(db *gorm.DB, tg *taskGenerator.TaskGenerator) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		data := requests.ResetPrompt{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		if err := db.Where("key = ?", data.Key).Delete(&dbmodels.Prompt{}).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to reset prompt",
+				Error:  err.Error(),
+			})
+		}
+
+		tg.ResetPrompt(data.Key)
+
+		return c.Status(200).JSON(responses.EditPromptDTO{
+			Status: "prompt reset",
+			Prompt: promptToDTO(tg, data.Key, nil),
+		})
+	}
+}
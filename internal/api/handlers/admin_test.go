@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"io"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"gera-ai/internal/utils/cursor"
+	"gera-ai/internal/utils/jwtUtils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// getList выполняет GET к списку от имени пользователя 5 и возвращает статус и тело ответа
+func getList(t *testing.T, handler fiber.Handler, target string) (int, string) {
+	t.Helper()
+	app := fiber.New()
+	app.Get("/list", func(c *fiber.Ctx) error {
+		jwtUtils.SetAccessToken(c, 5, "admin", 0, "")
+		return c.Next()
+	}, handler)
+
+	response, err := app.Test(httptest.NewRequest("GET", target, nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return response.StatusCode, string(body)
+}
+
+func TestListsRejectQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(db *gorm.DB) fiber.Handler
+		query   string
+		wantErr string
+	}{
+		{"users: unknown sort field", GetUsers, "sort=password_hash", `sorting by \"password_hash\" is not supported`},
+		{"users: unknown filter", GetUsers, "password_hash_contains=%24", `filter \"password_hash_contains\" is not supported`},
+		{"users: bad date", GetUsers, "created_after=yesterday", "invalid created_after"},
+		{"audit: unknown sort field", GetAuditEvents, "sort=-ip", `sorting by \"ip\" is not supported`},
+		{"audit: unknown filter", GetAuditEvents, "ip_contains=10.", `filter \"ip_contains\" is not supported`},
+		{"audit: bad date", GetAuditEvents, "created_before=2024-03-01", "invalid created_before"},
+		{"audit: bad user", GetAuditEvents, "user_id=five", "invalid user_id"},
+		{"library: unknown sort field", BrowseLibrary, "sort=report_count", `sorting by \"report_count\" is not supported`},
+		{"library: unknown filter", BrowseLibrary, "moderation_note_contains=spam", `filter \"moderation_note_contains\" is not supported`},
+		{"library: bad date", BrowseLibrary, "created_after=1709251200", "invalid created_after"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Запрос отклоняется до обращения к базе: ни одного ожидания у мока нет
+			db, _ := newMockDB(t)
+			status, body := getList(t, tt.handler(db), "/list?"+tt.query)
+			if status != 400 || !strings.Contains(body, `"status":"invalid query"`) || !strings.Contains(body, tt.wantErr) {
+				t.Fatalf("GET = %d %s, want 400 with %q", status, body, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetUsersEscapesLogin(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	// Прежний параметр login ищет начало логина буквально: % и _ не работают как шаблон
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "users" WHERE login ILIKE $1 AND role = $2 AND "users"."deleted_at" IS NULL`)).
+		WithArgs(`50\%\_`+"%", "teacher").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE login ILIKE $1 AND role = $2 AND "users"."deleted_at" IS NULL ORDER BY login DESC,id DESC LIMIT $3`)).
+		WithArgs(`50\%\_`+"%", "teacher", 21).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	status, body := getList(t, GetUsers(db), "/list?login=50%25_&role=teacher&sort=-login")
+	if status != 200 {
+		t.Fatalf("GET = %d %s, want 200", status, body)
+	}
+}
+
+func TestGetAuditEventsFilters(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	createdAfter := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "audit_events" WHERE created_at > $1 AND type = $2 AND user_id = $3`)).
+		WithArgs(createdAfter, "login_lockout", 7).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "audit_events" WHERE created_at > $1 AND type = $2 AND user_id = $3 ORDER BY id DESC LIMIT $4`)).
+		WithArgs(createdAfter, "login_lockout", 7, 21).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "type", "user_id", "created_at"}).
+			AddRow(3, "login_lockout", 7, createdAfter.Add(time.Hour)))
+
+	status, body := getList(t, GetAuditEvents(db), "/list?type=login_lockout&user_id=7&created_after=2024-03-01T00:00:00Z")
+	if status != 200 || !strings.Contains(body, `"total":1`) {
+		t.Fatalf("GET = %d %s, want 200 with one event", status, body)
+	}
+}
+
+func TestBrowseLibraryCursorBoundToSort(t *testing.T) {
+	// Курсор сортировки по умолчанию (популярные) не подходит к sort=new
+	cloneCount := 4
+	createdAt := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	value, err := cursor.Encode(pagePosition{Count: &cloneCount, Time: &createdAt, ID: 9})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, _ := newMockDB(t)
+	status, body := getList(t, BrowseLibrary(db), "/list?sort=new&cursor="+value)
+	if status != 400 || !strings.Contains(body, `"status":"invalid cursor"`) {
+		t.Fatalf("GET = %d %s, want 400 invalid cursor", status, body)
+	}
+}
@@ -1,22 +1,21 @@
 package handlers
 
 import (
-	"fmt"
-	"gera-ai/internal/config"
 	dbmodels "gera-ai/internal/models/database"
 	"gera-ai/internal/models/requests"
 	"gera-ai/internal/models/responses"
 	"gera-ai/internal/utils/password"
 	"gera-ai/internal/utils/validator"
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
 	"gorm.io/gorm"
+	"math"
+	"strconv"
 	"time"
 )
 
 // Login handles user authentication
 // @Summary      User Login
-// @Description  Authenticate user and return JWT token
+// @Description  Authenticate user and start a session. Returns a short-lived JWT access token and a refresh token. Unknown logins and wrong passwords get the same 401; repeated failures slow down and then temporarily lock the login and the client address (429 with Retry-After)
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
@@ -24,8 +23,9 @@ import (
 // @Success      200      {object}  responses.AuthDTO
 // @Failure      400      {object}  responses.ErrorResponse
 // @Failure      401      {object}  responses.ErrorResponse
-// @Failure      404      {object}  responses.ErrorResponse
+// @Failure      403      {object}  responses.ErrorResponse
 // @Failure      422      {object}  responses.ValidationErrorResponse
+// @Failure      429      {object}  responses.ErrorResponse
 // @Failure      500      {object}  responses.ErrorResponse
 // @Router       /api/auth/login [post]
 func Login(db *gorm.DB) fiber.Handler {
@@ -46,46 +46,84 @@ func Login(db *gorm.DB) fiber.Handler {
 			})
 		}
 
+		accountKey, ipKey := loginFailureKeys(data.Login, c.IP())
+		retryAfter, err := loginRetryAfter(db, accountKey, ipKey)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "error",
+				Error:  "Internal server error",
+			})
+		}
+		if retryAfter > 0 {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			return c.Status(429).JSON(responses.ErrorResponse{
+				Status: "error",
+				Error:  "Too many login attempts, try again later",
+			})
+		}
+
+		// Неизвестный логин и неверный пароль дают одинаковый ответ,
+		// чтобы по нему нельзя было узнать, существует ли учетная запись
 		var user dbmodels.User
 		result := db.First(&user, "login = ?", data.Login)
-		if result.Error != nil {
-			return c.Status(404).JSON(responses.ErrorResponse{
+		if result.Error != nil && result.Error != gorm.ErrRecordNotFound {
+			return c.Status(500).JSON(responses.ErrorResponse{
 				Status: "error",
-				Error:  "User not found",
+				Error:  "Internal server error",
 			})
 		}
 
-		if !password.CheckPasswordHash(data.Password, user.PasswordHash) {
+		var valid bool
+		var userID *uint
+		if result.Error == gorm.ErrRecordNotFound {
+			checkDummyPassword(data.Password)
+		} else {
+			valid = password.CheckPasswordHash(data.Password, user.PasswordHash)
+			userID = &user.ID
+		}
+		if !valid {
+			if err := registerFailedLogin(db, data.Login, c.IP(), userID); err != nil {
+				return c.Status(500).JSON(responses.ErrorResponse{
+					Status: "error",
+					Error:  "Internal server error",
+				})
+			}
 			return c.Status(401).JSON(responses.ErrorResponse{
 				Status: "error",
-				Error:  "Wrong password",
+				Error:  "Invalid login or password",
 			})
 		}
 
-		claims := jwt.MapClaims{
-			"id":  fmt.Sprint(user.ID),
-			"exp": time.Now().Add(config.Config.JWTExpiration).Unix(),
-			"iat": time.Now().Unix(),
+		if err := resetLoginFailures(db, data.Login); err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "error",
+				Error:  "Internal server error",
+			})
 		}
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-		signedToken, tokenErr := token.SignedString([]byte(config.Config.JWTSecret))
+
+		if user.BlockedAt != nil {
+			return c.Status(403).JSON(responses.ErrorResponse{
+				Status: "error",
+				Error:  "Account is blocked",
+			})
+		}
+
+		auth, tokenErr := startSession(db, c, user)
 		if tokenErr != nil {
 			return c.Status(500).JSON(responses.ErrorResponse{
 				Status: "error",
-				Error:  "Failed to sign token",
+				Error:  "Failed to start session",
 			})
 		}
 
-		return c.Status(200).JSON(responses.AuthDTO{
-			Status: "ok",
-			Token:  signedToken,
-		})
+		auth.Status = "ok"
+		return c.Status(200).JSON(auth)
 	}
 }
 
 // Register handles user registration
 // @Summary      User Registration
-// @Description  Register a new user and return JWT token
+// @Description  Register a new user and start a session. Returns a short-lived JWT access token and a refresh token
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
@@ -132,6 +170,25 @@ func Register(db *gorm.DB) fiber.Handler {
 			})
 		}
 
+		// Email нужен только для сброса пароля, но тоже должен быть уникальным
+		var email *string
+		if data.Email != "" {
+			email = &data.Email
+			var count int64
+			if err := db.Unscoped().Model(&dbmodels.User{}).Where("email = ?", data.Email).Count(&count).Error; err != nil {
+				return c.Status(500).JSON(responses.ErrorResponse{
+					Status: "error",
+					Error:  "Internal server error during user lookup",
+				})
+			}
+			if count > 0 {
+				return c.Status(409).JSON(responses.ErrorResponse{
+					Status: "error",
+					Error:  "User with this email already exists",
+				})
+			}
+		}
+
 		// 4. Хэширование пароля
 		hashedPassword, hashErr := password.HashPassword(data.Password)
 		if hashErr != nil {
@@ -146,6 +203,8 @@ func Register(db *gorm.DB) fiber.Handler {
 			Login:        data.Login,
 			Username:     data.Username,
 			PasswordHash: hashedPassword,
+			Email:        email,
+			Role:         dbmodels.UserRoleTeacher,
 			CreatedAt:    time.Now(),
 		}
 		result := db.Create(&user)
@@ -156,25 +215,17 @@ func Register(db *gorm.DB) fiber.Handler {
 			})
 		}
 
-		// 6. Генерация токена
-		claims := jwt.MapClaims{
-			"id":  fmt.Sprint(user.ID),
-			"exp": time.Now().Add(config.Config.JWTExpiration).Unix(),
-			"iat": time.Now().Unix(),
-		}
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-		signedToken, tokenErr := token.SignedString([]byte(config.Config.JWTSecret))
+		// 6. Создание сессии и выпуск токенов
+		auth, tokenErr := startSession(db, c, *user)
 		if tokenErr != nil {
 			return c.Status(500).JSON(responses.ErrorResponse{
 				Status: "error",
-				Error:  "Failed to sign token",
+				Error:  "Failed to start session",
 			})
 		}
 
 		// 7. Успешный ответ
-		return c.Status(200).JSON(responses.AuthDTO{
-			Status: "success",
-			Token:  signedToken,
-		})
+		auth.Status = "success"
+		return c.Status(200).JSON(auth)
 	}
 }
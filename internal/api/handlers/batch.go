@@ -0,0 +1,366 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gera-ai/internal/config"
+	dbmodels "gera-ai/internal/models/database"
+	"gera-ai/internal/models/requests"
+	"gera-ai/internal/models/responses"
+	"gera-ai/internal/utils/jwtUtils"
+	"gera-ai/internal/utils/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// batchResource - создание и изменение записей одного типа в пакете.
+// Удаление и перенос в организацию одинаковы для всех типов
+type batchResource struct {
+	create func(tx *gorm.DB, userID uint, organizationID *uint, item requests.BatchItem) (uint, int, error)
+	update func(tx *gorm.DB, userID uint, item requests.BatchItem) (int, error)
+}
+
+var batchResources = map[string]batchResource{
+	"task":               {create: createTaskItem, update: updateTaskItem},
+	"condition_template": {create: createConditionTemplateItem, update: updateConditionTemplateItem},
+	"interests_template": {create: createInterestsTemplateItem, update: updateInterestsTemplateItem},
+}
+
+// itemValidationError собирает ошибки валидации операции в одну строку вида "Title: required"
+func itemValidationError(validationErrors map[string]string) error {
+	fields := make([]string, 0, len(validationErrors))
+	for field, tag := range validationErrors {
+		fields = append(fields, field+": "+tag)
+	}
+	sort.Strings(fields)
+	return errors.New("validation failed: " + strings.Join(fields, ", "))
+}
+
+func createTaskItem(tx *gorm.DB, userID uint, organizationID *uint, item requests.BatchItem) (uint, int, error) {
+	data := requests.CreateTask{Title: item.Title, Condition: item.Condition, Answer: item.Answer}
+	if validationErrors := validator.ValidateStruct(data); validationErrors != nil {
+		return 0, 422, itemValidationError(validationErrors)
+	}
+	task, err := createTask(tx, userID, organizationID, data)
+	if err != nil {
+		return 0, 500, err
+	}
+	return task.ID, 200, nil
+}
+
+func updateTaskItem(tx *gorm.DB, userID uint, item requests.BatchItem) (int, error) {
+	data := requests.EditTask{ID: item.ID, Title: item.Title, Condition: item.Condition, Answer: item.Answer}
+	if validationErrors := validator.ValidateStruct(data); validationErrors != nil {
+		return 422, itemValidationError(validationErrors)
+	}
+	if status, err := checkResourceEditor(tx, userID, "task", data.ID); err != nil {
+		return status, err
+	}
+
+	var task dbmodels.Task
+	if err := tx.First(&task, data.ID).Error; err != nil {
+		return 500, err
+	}
+	err := updateTask(tx, userID, &task, requests.PatchTask{
+		Title:     &data.Title,
+		Condition: &data.Condition,
+		Answer:    &data.Answer,
+	})
+	if err != nil {
+		return 500, err
+	}
+	return 200, nil
+}
+
+func createConditionTemplateItem(tx *gorm.DB, userID uint, organizationID *uint, item requests.BatchItem) (uint, int, error) {
+	data := requests.CreateConditionTemplate{Title: item.Title, Condition: item.Condition}
+	if validationErrors := validator.ValidateStruct(data); validationErrors != nil {
+		return 0, 422, itemValidationError(validationErrors)
+	}
+	conditionTemplate, err := createConditionTemplate(tx, userID, organizationID, data)
+	if err != nil {
+		return 0, 500, err
+	}
+	return conditionTemplate.ID, 200, nil
+}
+
+func updateConditionTemplateItem(tx *gorm.DB, userID uint, item requests.BatchItem) (int, error) {
+	data := requests.EditConditionTemplate{ID: item.ID, Title: item.Title, Condition: item.Condition}
+	if validationErrors := validator.ValidateStruct(data); validationErrors != nil {
+		return 422, itemValidationError(validationErrors)
+	}
+	if status, err := checkResourceEditor(tx, userID, "condition_template", data.ID); err != nil {
+		return status, err
+	}
+
+	var conditionTemplate dbmodels.ConditionTemplate
+	if err := tx.First(&conditionTemplate, data.ID).Error; err != nil {
+		return 500, err
+	}
+	err := updateConditionTemplate(tx, userID, &conditionTemplate, requests.PatchConditionTemplate{
+		Title:     &data.Title,
+		Condition: &data.Condition,
+	})
+	if err != nil {
+		return 500, err
+	}
+	return 200, nil
+}
+
+func createInterestsTemplateItem(tx *gorm.DB, userID uint, organizationID *uint, item requests.BatchItem) (uint, int, error) {
+	data := requests.CreateInterestsTemplate{Title: item.Title, Interests: item.Interests}
+	if validationErrors := validator.ValidateStruct(data); validationErrors != nil {
+		return 0, 422, itemValidationError(validationErrors)
+	}
+	interestsTemplate, err := createInterestsTemplate(tx, userID, organizationID, data)
+	if err != nil {
+		return 0, 500, err
+	}
+	return interestsTemplate.ID, 200, nil
+}
+
+func updateInterestsTemplateItem(tx *gorm.DB, userID uint, item requests.BatchItem) (int, error) {
+	data := requests.EditInterestsTemplate{ID: item.ID, Title: item.Title, Interests: item.Interests}
+	if validationErrors := validator.ValidateStruct(data); validationErrors != nil {
+		return 422, itemValidationError(validationErrors)
+	}
+	if status, err := checkResourceEditor(tx, userID, "interests_template", data.ID); err != nil {
+		return status, err
+	}
+
+	var interestsTemplate dbmodels.InterestsTemplate
+	if err := tx.First(&interestsTemplate, data.ID).Error; err != nil {
+		return 500, err
+	}
+	err := updateInterestsTemplate(tx, &interestsTemplate, requests.PatchInterestsTemplate{
+		Title:     &data.Title,
+		Interests: &data.Interests,
+	})
+	if err != nil {
+		return 500, err
+	}
+	return 200, nil
+}
+
+// deleteResource удаляет запись, если у пользователя есть права редактора.
+// Удаленное, как и при обычном удалении, попадает в корзину автора
+func deleteResource(tx *gorm.DB, userID uint, resourceType string, id uint) (int, error) {
+	if status, err := checkResourceEditor(tx, userID, resourceType, id); err != nil {
+		return status, err
+	}
+	if err := tx.Delete(taggedResources[resourceType].model(id)).Error; err != nil {
+		return 500, err
+	}
+	return 200, nil
+}
+
+// applyBatchItem выполняет одну операцию пакета и возвращает id записи, статус и ошибку
+func applyBatchItem(tx *gorm.DB, resourceType string, userID uint, organizationID *uint, item requests.BatchItem) (uint, int, error) {
+	resource := batchResources[resourceType]
+	switch item.Op {
+	case "create":
+		return resource.create(tx, userID, organizationID, item)
+	case "update":
+		status, err := resource.update(tx, userID, item)
+		return item.ID, status, err
+	case "delete":
+		status, err := deleteResource(tx, userID, resourceType, item.ID)
+		return item.ID, status, err
+	case "move":
+		status, err := moveResource(tx, userID, resourceType, item.ID, item.OrganizationID)
+		return item.ID, status, err
+	}
+	return item.ID, 422, fmt.Errorf("unknown operation %q", item.Op)
+}
+
+// runBatch выполняет операции пакета. В режиме atomic все операции идут в одной транзакции:
+// первая ошибка откатывает уже выполненные, а остальные не выполняются.
+// В режиме best_effort каждая операция выполняется в своей транзакции независимо от других
+func runBatch(db *gorm.DB, atomic bool, items []requests.BatchItem, apply func(tx *gorm.DB, item requests.BatchItem) (uint, int, error)) []responses.BatchItemResultDTO {
+	results := make([]responses.BatchItemResultDTO, len(items))
+	setResult := func(i int, id uint, status int, err error) {
+		results[i] = responses.BatchItemResultDTO{
+			Index:  i,
+			Op:     items[i].Op,
+			ID:     id,
+			Status: status,
+		}
+		if err != nil {
+			results[i].Error = err.Error()
+		}
+	}
+
+	if !atomic {
+		for i, item := range items {
+			var id uint
+			var status int
+			err := db.Transaction(func(tx *gorm.DB) error {
+				var err error
+				id, status, err = apply(tx, item)
+				return err
+			})
+			if err != nil && status == 200 {
+				status = 500
+			}
+			setResult(i, id, status, err)
+		}
+		return results
+	}
+
+	failed := -1
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for i, item := range items {
+			id, status, err := apply(tx, item)
+			setResult(i, id, status, err)
+			if err != nil {
+				failed = i
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		return results
+	}
+
+	// Откат коснулся всех операций: созданные записи больше не существуют
+	for i, item := range items {
+		switch {
+		case failed == -1:
+			setResult(i, item.ID, 500, fmt.Errorf("failed to commit the batch: %w", err))
+		case i != failed:
+			setResult(i, item.ID, 424, fmt.Errorf("not applied because item %d failed", failed))
+		}
+	}
+	return results
+}
+
+// batchHandler обрабатывает пакет операций над записями resourceType
+func batchHandler(db *gorm.DB, resourceType string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.Batch{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors == nil && len(data.Items) > config.Config.BatchMaxItems {
+			validationErrors = map[string]string{"Items": "max"}
+		}
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		// Новые записи создаются в активной организации, как и при обычном создании
+		organizationID := activeOrganizationID(c)
+		results := runBatch(db, data.Mode == requests.BatchModeAtomic, data.Items, func(tx *gorm.DB, item requests.BatchItem) (uint, int, error) {
+			return applyBatchItem(tx, resourceType, userID, organizationID, item)
+		})
+
+		response := responses.BatchDTO{
+			Mode:    data.Mode,
+			Results: results,
+		}
+		status := 200
+		for _, result := range results {
+			if result.Error == "" {
+				response.Succeeded++
+				continue
+			}
+			response.Failed++
+			// В режиме atomic ответ получает статус операции, из-за которой пакет откатился
+			if data.Mode == requests.BatchModeAtomic && result.Status != 424 {
+				status = result.Status
+			}
+		}
+
+		switch {
+		case response.Failed == 0:
+			response.Status = "batch completed"
+		case data.Mode == requests.BatchModeAtomic:
+			response.Status = "batch rolled back"
+		default:
+			response.Status = "batch partially completed"
+			status = 207
+		}
+		return c.Status(status).JSON(response)
+	}
+}
+
+// BatchTasks creates, updates, deletes or moves several tasks in one request
+// @Summary Batch task operations
+// @Description Applies up to BATCH_MAX_ITEMS operations (create, update, delete, move) to tasks. In atomic mode either all operations are applied or none: the failed item gets its own status and the others get 424. In best_effort mode every operation is applied independently. Each item gets the status the single-item endpoint would return
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Param input body requests.Batch true "Mode and operations"
+// @Success 200 {object} responses.BatchDTO "All operations applied"
+// @Success 207 {object} responses.BatchDTO "Some operations failed (best_effort)"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.BatchDTO "Batch rolled back (atomic)"
+// @Failure 404 {object} responses.BatchDTO "Batch rolled back (atomic)"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/task/batch [post]
+// @Router /api/v2/tasks/batch [post]
+func BatchTasks(db *gorm.DB) fiber.Handler {
+	return batchHandler(db, "task")
+}
+
+// BatchConditionTemplates creates, updates, deletes or moves several condition templates in one request
+// @Summary Batch condition template operations
+// @Description Applies up to BATCH_MAX_ITEMS operations (create, update, delete, move) to condition templates. In atomic mode either all operations are applied or none: the failed item gets its own status and the others get 424. In best_effort mode every operation is applied independently
+// @Tags ConditionTemplate
+// @Accept json
+// @Produce json
+// @Param input body requests.Batch true "Mode and operations"
+// @Success 200 {object} responses.BatchDTO "All operations applied"
+// @Success 207 {object} responses.BatchDTO "Some operations failed (best_effort)"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.BatchDTO "Batch rolled back (atomic)"
+// @Failure 404 {object} responses.BatchDTO "Batch rolled back (atomic)"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/template/condition/batch [post]
+// @Router /api/v2/condition-templates/batch [post]
+func BatchConditionTemplates(db *gorm.DB) fiber.Handler {
+	return batchHandler(db, "condition_template")
+}
+
+// BatchInterestsTemplates creates, updates, deletes or moves several interests templates in one request
+// @Summary Batch interests template operations
+// @Description Applies up to BATCH_MAX_ITEMS operations (create, update, delete, move) to interests templates. In atomic mode either all operations are applied or none: the failed item gets its own status and the others get 424. In best_effort mode every operation is applied independently
+// @Tags InterestsTemplates
+// @Accept json
+// @Produce json
+// @Param input body requests.Batch true "Mode and operations"
+// @Success 200 {object} responses.BatchDTO "All operations applied"
+// @Success 207 {object} responses.BatchDTO "Some operations failed (best_effort)"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.BatchDTO "Batch rolled back (atomic)"
+// @Failure 404 {object} responses.BatchDTO "Batch rolled back (atomic)"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/template/interests/batch [post]
+// @Router /api/v2/interests-templates/batch [post]
+func BatchInterestsTemplates(db *gorm.DB) fiber.Handler {
+	return batchHandler(db, "interests_template")
+}
@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	dbmodels "gera-ai/internal/models/database"
+	"gera-ai/internal/models/requests"
+	"gera-ai/internal/models/responses"
+	"gera-ai/internal/utils/jwtUtils"
+	"gera-ai/internal/utils/rbac"
+	"gera-ai/internal/utils/taskGenerator"
+	"gera-ai/internal/utils/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// checkGenerateScope проверяет, что персональный токен может обращаться к языковой модели.
+// Классификация при создании и переносе задачи вызывает модель, поэтому кроме областей маршрута
+// ей нужна область generate. Вход по паролю областями не ограничен
+func checkGenerateScope(c *fiber.Ctx) error {
+	if scopes, ok := jwtUtils.ExtractScopes(c); ok && !rbac.HasScope(scopes, rbac.ScopeGenerate) {
+		return fmt.Errorf("the access token does not have the %s scope", rbac.ScopeGenerate)
+	}
+	return nil
+}
+
+// classifyTask классифицирует задачу через модель и сохраняет тему, формулы и сложность.
+// Предмет добавляется к предметам задачи, если он есть в справочнике.
+// Обращение к модели расходует месячный лимит активной организации и записывается
+// в историю генераций пользователя userID. Возвращает HTTP статус, с которым нужно ответить при ошибке
+func classifyTask(c *fiber.Ctx, db *gorm.DB, tg *taskGenerator.TaskGenerator, userID uint, task *dbmodels.Task) (int, error) {
+	if status, err := checkGenerationLimit(c, db); err != nil {
+		return status, err
+	}
+
+	var subjects []dbmodels.Subject
+	if err := db.Order("id").Find(&subjects).Error; err != nil {
+		return 500, err
+	}
+	codes := make([]string, len(subjects))
+	for i, subject := range subjects {
+		codes[i] = subject.Code
+	}
+
+	classification, err := tg.ClassifyTask(task.Condition, codes)
+	if err != nil {
+		return 500, err
+	}
+
+	formulas, err := json.Marshal(classification.Formulas)
+	if err != nil {
+		return 500, err
+	}
+	result, err := json.Marshal(classification)
+	if err != nil {
+		return 500, err
+	}
+	generation, err := newGeneration(tg, userID, activeOrganizationID(c), dbmodels.GenerationTypeClassification, task.Condition, string(result))
+	if err != nil {
+		return 500, err
+	}
+	generation.TaskID = &task.ID
+
+	task.Topic = truncateRunes(classification.Topic, 100)
+	task.Formulas = formulas
+	task.Difficulty = &classification.Difficulty
+	task.ClassificationSource = dbmodels.ClassificationSourceAI
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&generation).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(task).Select("Topic", "Formulas", "Difficulty", "ClassificationSource").Updates(task).Error; err != nil {
+			return err
+		}
+		for _, subject := range subjects {
+			if subject.Code == classification.Subject {
+				return tx.Model(task).Association("Subjects").Append(&subject)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 500, err
+	}
+	return 200, nil
+}
+
+// taskClassificationToDTO возвращает nil, если задача еще не классифицирована
+func taskClassificationToDTO(task dbmodels.Task) *responses.TaskClassificationDTO {
+	if task.ClassificationSource == "" {
+		return nil
+	}
+
+	formulas := []string{}
+	if len(task.Formulas) > 0 {
+		_ = json.Unmarshal(task.Formulas, &formulas)
+	}
+
+	return &responses.TaskClassificationDTO{
+		Topic:      task.Topic,
+		Formulas:   formulas,
+		Difficulty: task.Difficulty,
+		Source:     task.ClassificationSource,
+	}
+}
+
+// ClassifyTask runs the LLM classification for an existing task
+// @Summary Classify a task
+// @Description Detects the subject, topic, required formulas and difficulty of a task with the language model. Overwrites previous classification
+// @Tags Tasks
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {object} responses.ClassifyTaskResponseDTO "Task successfully classified"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or ID"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "Task not found"
+// @Failure 429 {object} responses.ErrorResponse "Organization generation limit exceeded"
+// @Failure 500 {object} responses.ErrorResponse "Classification failed"
+// @Router /api/task/classify/{id} [post]
+func ClassifyTask(db *gorm.DB, tg *taskGenerator.TaskGenerator) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		authorID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		taskID, err := strconv.Atoi(c.Params("id"))
+		if err != nil || taskID <= 0 {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid task ID",
+				Error:  "task ID must be a positive integer",
+			})
+		}
+
+		data := requests.ClassifyTask{ID: uint(taskID)}
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		var task dbmodels.Task
+		result := db.First(&task, data.ID)
+		if result.Error == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "task not found",
+			})
+		} else if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		// Менять классификацию могут автор и редакторы задачи
+		access, err := resourceAccess(db, authorID, "task", task.ID, task.AuthorID, task.OrganizationID)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		if access < accessEditor {
+			return c.Status(403).JSON(responses.ErrorResponse{
+				Status: "forbidden",
+				Error:  "you cannot edit this task",
+			})
+		}
+
+		if status, err := classifyTask(c, db, tg, authorID, &task); err != nil {
+			return c.Status(status).JSON(responses.ErrorResponse{
+				Status: "failed to classify task",
+				Error:  err.Error(),
+			})
+		}
+
+		return respondWithTaskClassification(c, db, task.ID, "task classified")
+	}
+}
+
+// EditTaskClassification lets a teacher correct the classification of a task
+// @Summary Correct task classification
+// @Description Updates the topic, formulas and difficulty of a task. A non-empty subject replaces the subjects of the task
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Param input body requests.EditTaskClassification true "Corrected classification"
+// @Success 200 {object} responses.ClassifyTaskResponseDTO "Classification successfully updated"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "Task or subject not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Database error"
+// @Router /api/task/classification [put]
+func EditTaskClassification(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		authorID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.EditTaskClassification{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		var task dbmodels.Task
+		result := db.First(&task, data.ID)
+		if result.Error == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "task not found",
+			})
+		} else if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		// Менять классификацию могут автор и редакторы задачи
+		access, err := resourceAccess(db, authorID, "task", task.ID, task.AuthorID, task.OrganizationID)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		if access < accessEditor {
+			return c.Status(403).JSON(responses.ErrorResponse{
+				Status: "forbidden",
+				Error:  "you cannot edit this task",
+			})
+		}
+
+		var subject *dbmodels.Subject
+		if data.Subject != "" {
+			subject = &dbmodels.Subject{}
+			result := db.Where("code = ?", data.Subject).First(subject)
+			if result.Error == gorm.ErrRecordNotFound {
+				return c.Status(404).JSON(responses.ErrorResponse{
+					Status: "subject not found",
+					Error:  "unknown subject " + data.Subject,
+				})
+			} else if result.Error != nil {
+				return c.Status(500).JSON(responses.ErrorResponse{
+					Status: "internal server error",
+					Error:  result.Error.Error(),
+				})
+			}
+		}
+
+		formulas := data.Formulas
+		if formulas == nil {
+			formulas = []string{}
+		}
+		encodedFormulas, err := json.Marshal(formulas)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to process formulas",
+				Error:  err.Error(),
+			})
+		}
+
+		task.Topic = data.Topic
+		task.Formulas = encodedFormulas
+		task.Difficulty = data.Difficulty
+		task.ClassificationSource = dbmodels.ClassificationSourceTeacher
+		task.UpdatedAt = time.Now()
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&task).Select("Topic", "Formulas", "Difficulty", "ClassificationSource", "UpdatedAt").Updates(&task).Error; err != nil {
+				return err
+			}
+			if subject != nil {
+				return tx.Model(&task).Association("Subjects").Replace(subject)
+			}
+			return nil
+		})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to update classification",
+				Error:  err.Error(),
+			})
+		}
+
+		return respondWithTaskClassification(c, db, task.ID, "classification updated")
+	}
+}
+
+// respondWithTaskClassification перечитывает задачу вместе со связями и отдает ее клиенту
+func respondWithTaskClassification(c *fiber.Ctx, db *gorm.DB, taskID uint, status string) error {
+	var task dbmodels.Task
+	if err := preloadClassification(db).First(&task, taskID).Error; err != nil {
+		return c.Status(500).JSON(responses.ErrorResponse{
+			Status: "internal server error",
+			Error:  err.Error(),
+		})
+	}
+
+	return c.Status(200).JSON(responses.ClassifyTaskResponseDTO{
+		Status: status,
+		Task: responses.TaskDTO{
+			ID:                 task.ID,
+			AuthorID:           task.AuthorID,
+			Title:              task.Title,
+			Condition:          task.Condition,
+			Answer:             task.Answer,
+			SourceGenerationID: task.SourceGenerationID,
+			Attribution:        task.Attribution,
+			ClassificationDTO:  classificationToDTO(task.Tags, task.Subjects, task.Grades),
+			Classification:     taskClassificationToDTO(task),
+		},
+	})
+}
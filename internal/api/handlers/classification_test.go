@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	dbmodels "gera-ai/internal/models/database"
+	"gera-ai/internal/utils/jwtUtils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestClassifyTaskRespectsGenerationLimit(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	app := fiber.New()
+	app.Post("/task/classify/:id", func(c *fiber.Ctx) error {
+		jwtUtils.SetAccessToken(c, 5, "teacher", 0, "")
+		jwtUtils.SetOrganization(c, 3, dbmodels.OrganizationRoleTeacher)
+		return c.Next()
+	}, ClassifyTask(db, nil))
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "tasks" WHERE "tasks"."id" = $1`)).
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "author_id", "condition"}).AddRow(7, 5, "1/2 + 1/4"))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "organizations" WHERE "organizations"."id" = $1`)).
+		WithArgs(3, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "monthly_generation_limit"}).AddRow(3, 10))
+	// Классификации записываются в generations, поэтому считаются вместе с генерациями
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "generations" WHERE (organization_id = $1 AND created_at >= $2)`)).
+		WithArgs(3, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(10))
+
+	// Генератор не передан: обращение к модели после исчерпания лимита уронило бы тест
+	response, err := app.Test(httptest.NewRequest("POST", "/task/classify/7", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != 429 {
+		t.Fatalf("classify = %d, want 429", response.StatusCode)
+	}
+}
+
+func TestPromoteRejectsClassification(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	app := fiber.New()
+	app.Post("/history/promote", func(c *fiber.Ctx) error {
+		jwtUtils.SetAccessToken(c, 5, "teacher", 0, "")
+		return c.Next()
+	}, PromoteGeneration(db, nil))
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "generations" WHERE "generations"."id" = $1`)).
+		WithArgs(4, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "type", "result", "created_at"}).
+			AddRow(4, 5, dbmodels.GenerationTypeClassification, `{"topic":"Дроби"}`, time.Now()))
+
+	request := httptest.NewRequest("POST", "/history/promote", strings.NewReader(`{"id": 4}`))
+	request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	response, err := app.Test(request, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != 422 {
+		t.Fatalf("promote = %d, want 422", response.StatusCode)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"gera-ai/internal/models/requests"
 	"gera-ai/internal/models/responses"
 	"gera-ai/internal/utils/jwtUtils"
+	"gera-ai/internal/utils/listQuery"
 	"gera-ai/internal/utils/validator"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -12,6 +13,44 @@ import (
 	"time"
 )
 
+// createConditionTemplate сохраняет шаблон условия вместе с первой ревизией. Вызывается внутри транзакции
+func createConditionTemplate(tx *gorm.DB, authorID uint, organizationID *uint, data requests.CreateConditionTemplate) (database.ConditionTemplate, error) {
+	conditionTemplate := database.ConditionTemplate{
+		AuthorID:       authorID,
+		OrganizationID: organizationID,
+		Title:          data.Title,
+		Condition:      data.Condition,
+		CreatedAt:      time.Now(),
+	}
+	if err := tx.Create(&conditionTemplate).Error; err != nil {
+		return conditionTemplate, err
+	}
+	revision := conditionTemplateRevision(conditionTemplate, authorID)
+	return conditionTemplate, recordRevision(tx, &revision)
+}
+
+// updateConditionTemplate меняет переданные поля шаблона и записывает ревизию от имени userID.
+// Вызывается внутри транзакции
+func updateConditionTemplate(tx *gorm.DB, userID uint, conditionTemplate *database.ConditionTemplate, data requests.PatchConditionTemplate) error {
+	if err := ensureBaseRevision(tx, conditionTemplateRevision(*conditionTemplate, conditionTemplate.AuthorID)); err != nil {
+		return err
+	}
+
+	if data.Title != nil {
+		conditionTemplate.Title = *data.Title
+	}
+	if data.Condition != nil {
+		conditionTemplate.Condition = *data.Condition
+	}
+	conditionTemplate.UpdatedAt = time.Now()
+
+	if err := tx.Save(conditionTemplate).Error; err != nil {
+		return err
+	}
+	revision := conditionTemplateRevision(*conditionTemplate, userID)
+	return recordRevision(tx, &revision)
+}
+
 // CreateConditionTemplate godoc
 // @Summary Create a new condition template
 // @Description Creates a condition template for the current user
@@ -50,18 +89,15 @@ func CreateConditionTemplate(db *gorm.DB) fiber.Handler {
 			})
 		}
 
-		conditionTemplate := database.ConditionTemplate{
-			AuthorID:  authorID,
-			Title:     data.Title,
-			Condition: data.Condition,
-			CreatedAt: time.Now(),
-		}
-
-		result := db.Create(&conditionTemplate)
-		if result.Error != nil {
+		var conditionTemplate database.ConditionTemplate
+		err = db.Transaction(func(tx *gorm.DB) error {
+			conditionTemplate, err = createConditionTemplate(tx, authorID, activeOrganizationID(c), data)
+			return err
+		})
+		if err != nil {
 			return c.Status(500).JSON(responses.ErrorResponse{
 				Status: "failed to create condition template",
-				Error:  result.Error.Error(),
+				Error:  err.Error(),
 			})
 		}
 
@@ -87,6 +123,7 @@ func CreateConditionTemplate(db *gorm.DB) fiber.Handler {
 // @Failure 404 {object} responses.ErrorResponse "Not found"
 // @Failure 500 {object} responses.ErrorResponse "Internal server error"
 // @Router /api/template/condition/get/{id} [get]
+// @Router /api/v2/condition-templates/{id} [get]
 func GetConditionTemplate(db *gorm.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authorID, err := jwtUtils.ExtractUserID(c)
@@ -106,7 +143,7 @@ func GetConditionTemplate(db *gorm.DB) fiber.Handler {
 		}
 
 		var conditionTemplate database.ConditionTemplate
-		result := db.First(&conditionTemplate, conditionTemplateID)
+		result := preloadClassification(db).First(&conditionTemplate, conditionTemplateID)
 		if result.Error == gorm.ErrRecordNotFound {
 			return c.Status(404).JSON(responses.ErrorResponse{
 				Status: "condition template not found",
@@ -118,18 +155,28 @@ func GetConditionTemplate(db *gorm.DB) fiber.Handler {
 			})
 		}
 
-		if conditionTemplate.AuthorID != authorID {
+		// Доступ есть у автора и у пользователей, с которыми поделились
+		access, err := resourceAccess(db, authorID, "condition_template", conditionTemplate.ID, conditionTemplate.AuthorID, conditionTemplate.OrganizationID)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		if access < accessViewer {
 			return c.Status(403).JSON(responses.ErrorResponse{
 				Status: "forbidden",
-				Error:  "you are not the author of this condition template",
+				Error:  "you do not have access to this condition template",
 			})
 		}
 
 		return c.Status(200).JSON(responses.GetConditionTemplateDTO{
 			TaskTemplate: responses.ConditionTemplateDTO{
-				ID:        conditionTemplate.ID,
-				Title:     conditionTemplate.Title,
-				Condition: conditionTemplate.Condition,
+				ID:                conditionTemplate.ID,
+				Title:             conditionTemplate.Title,
+				Condition:         conditionTemplate.Condition,
+				Attribution:       conditionTemplate.Attribution,
+				ClassificationDTO: classificationToDTO(conditionTemplate.Tags, conditionTemplate.Subjects, conditionTemplate.Grades),
 			},
 		})
 	}
@@ -188,22 +235,31 @@ func EditConditionTemplate(db *gorm.DB) fiber.Handler {
 			})
 		}
 
-		if conditionTemplate.AuthorID != authorID {
+		// Редактировать могут автор и пользователи с ролью editor
+		access, err := resourceAccess(db, authorID, "condition_template", conditionTemplate.ID, conditionTemplate.AuthorID, conditionTemplate.OrganizationID)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		if access < accessEditor {
 			return c.Status(403).JSON(responses.ErrorResponse{
 				Status: "forbidden",
-				Error:  "you are not the author of this condition template",
+				Error:  "you cannot edit this condition template",
 			})
 		}
 
-		conditionTemplate.Title = data.Title
-		conditionTemplate.Condition = data.Condition
-		conditionTemplate.UpdatedAt = time.Now()
-
-		saveResult := db.Save(&conditionTemplate)
-		if saveResult.Error != nil {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			return updateConditionTemplate(tx, authorID, &conditionTemplate, requests.PatchConditionTemplate{
+				Title:     &data.Title,
+				Condition: &data.Condition,
+			})
+		})
+		if err != nil {
 			return c.Status(500).JSON(responses.ErrorResponse{
 				Status: "failed to update condition template",
-				Error:  saveResult.Error.Error(),
+				Error:  err.Error(),
 			})
 		}
 
@@ -270,10 +326,18 @@ func DeleteConditionTemplate(db *gorm.DB) fiber.Handler {
 			})
 		}
 
-		if conditionTemplate.AuthorID != authorID {
+		// Удалять могут автор и пользователи с ролью editor, удаленное попадает в корзину автора
+		access, err := resourceAccess(db, authorID, "condition_template", conditionTemplate.ID, conditionTemplate.AuthorID, conditionTemplate.OrganizationID)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		if access < accessEditor {
 			return c.Status(403).JSON(responses.ErrorResponse{
 				Status: "forbidden",
-				Error:  "you are not the author of this condition template",
+				Error:  "you cannot delete this condition template",
 			})
 		}
 
@@ -291,15 +355,45 @@ func DeleteConditionTemplate(db *gorm.DB) fiber.Handler {
 	}
 }
 
+// conditionTemplateListSpec - поля списка шаблонов условий, по которым можно сортировать и фильтровать
+var conditionTemplateListSpec = listQuery.Spec{
+	Sort: map[string]listQuery.Field{
+		"title":      {Column: "title", Kind: listQuery.Text},
+		"created_at": {Column: "created_at", Kind: listQuery.Time},
+		"updated_at": {Column: "updated_at", Kind: listQuery.Time},
+	},
+	Filters: map[string]listQuery.Field{
+		"title":     {Column: "title", Kind: listQuery.Text},
+		"condition": {Column: "condition", Kind: listQuery.Text},
+		"created":   {Column: "created_at", Kind: listQuery.Time},
+		"updated":   {Column: "updated_at", Kind: listQuery.Time},
+	},
+}
+
 // GetAllConditionTemplates godoc
 // @Summary Get all condition templates for the user
-// @Description Retrieves all condition templates created by the current user with pagination
+// @Description Retrieves condition templates of the current user or the active organization, newest first by default, with sorting, field filters and cursor pagination
 // @Tags ConditionTemplate
-// @Param offset query int false "Pagination offset (default: 0)"
+// @Param cursor query string false "Cursor returned as page.next_cursor by the previous page"
+// @Param limit query int false "Page size (default is 20, max is 100)"
+// @Param include_total query bool false "Count all matching templates and return page.total"
+// @Param sort query string false "Sort field, prefix with - for descending order (default is newest first)" Enums(title, -title, created_at, -created_at, updated_at, -updated_at)
+// @Param created_after query string false "Only templates created after this time (RFC3339)"
+// @Param created_before query string false "Only templates created before this time (RFC3339)"
+// @Param updated_after query string false "Only templates updated after this time (RFC3339)"
+// @Param updated_before query string false "Only templates updated before this time (RFC3339)"
+// @Param title_contains query string false "Only templates whose title contains this text"
+// @Param condition_contains query string false "Only templates whose condition contains this text"
+// @Param offset query int false "Deprecated: page number, used only without a cursor"
+// @Param tag query string false "Comma separated tags, all of them must match"
+// @Param subject query string false "Comma separated subject codes, any of them must match"
+// @Param grade query string false "Comma separated grades, any of them must match"
 // @Success 200 {object} responses.GetAllConditionTemplatesDTO
-// @Failure 400 {object} responses.ErrorResponse "Invalid token or request"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token, cursor or filter"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
 // @Failure 500 {object} responses.ErrorResponse "Internal server error"
 // @Router /api/template/condition/all [get]
+// @Router /api/v2/condition-templates [get]
 func GetAllConditionTemplates(db *gorm.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authorID, err := jwtUtils.ExtractUserID(c)
@@ -310,40 +404,91 @@ func GetAllConditionTemplates(db *gorm.DB) fiber.Handler {
 			})
 		}
 
-		offset, err := strconv.Atoi(c.Query("offset", "0"))
-		if err != nil || offset < 0 {
+		page, err := parsePage(c)
+		if err != nil {
 			return c.Status(400).JSON(responses.ErrorResponse{
-				Status: "invalid offset",
-				Error:  "offset must be a non-negative integer",
+				Status: "invalid page",
+				Error:  err.Error(),
+			})
+		}
+		if validationErrors := validator.ValidateStruct(page); validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
 			})
 		}
 
-		limit := 10
-		var conditions []database.ConditionTemplate
-		result := db.Where("author_id = ?", authorID).
-			Order("id DESC").
-			Offset(offset * limit).
-			Limit(limit).
-			Find(&conditions)
+		filter, err := parseClassificationFilter(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid filter",
+				Error:  err.Error(),
+			})
+		}
+		if validationErrors := validator.ValidateStruct(filter); validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
 
-		if result.Error != nil {
+		list, err := listQuery.Parse(c.Queries(), conditionTemplateListSpec)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid query",
+				Error:  err.Error(),
+			})
+		}
+
+		query := applyClassificationFilter(scopeToWorkspace(c, db.Model(&database.ConditionTemplate{}), authorID), "condition_template", filter)
+		query = list.Apply(query)
+		total, err := countPage(query, page)
+		if err != nil {
 			return c.Status(500).JSON(responses.ErrorResponse{
 				Status: "internal server error",
-				Error:  result.Error.Error(),
+				Error:  err.Error(),
+			})
+		}
+		order := sortedPageOrder(list.Sort, pageOrder{IDColumn: "id", Desc: true})
+		query, err = paginate(query, order, page)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid cursor",
+				Error:  err.Error(),
+			})
+		}
+
+		var conditions []database.ConditionTemplate
+		if err := preloadClassification(query).Find(&conditions).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+
+		conditions, pageDTO, err := finishPage(conditions, page, total, func(condition database.ConditionTemplate) pagePosition {
+			return order.recordPosition(condition.ID, condition.Title, condition.CreatedAt, condition.UpdatedAt)
+		})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to encode cursor",
+				Error:  err.Error(),
 			})
 		}
 
-		var responseConditions []responses.ConditionTemplateDTO
+		responseConditions := make([]responses.ConditionTemplateDTO, 0, len(conditions))
 		for _, condition := range conditions {
 			responseConditions = append(responseConditions, responses.ConditionTemplateDTO{
-				ID:        condition.ID,
-				Title:     condition.Title,
-				Condition: condition.Condition,
+				ID:                condition.ID,
+				Title:             condition.Title,
+				Condition:         condition.Condition,
+				ClassificationDTO: classificationToDTO(condition.Tags, condition.Subjects, condition.Grades),
 			})
 		}
 
 		return c.Status(200).JSON(responses.GetAllConditionTemplatesDTO{
 			TaskTemplates: responseConditions,
+			Page:          pageDTO,
 		})
 	}
 }
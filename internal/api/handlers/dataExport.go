@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"gera-ai/internal/config"
+	"gera-ai/internal/dataexport"
+	dbmodels "gera-ai/internal/models/database"
+	"gera-ai/internal/models/responses"
+	"gera-ai/internal/utils/jwtUtils"
+	"gera-ai/internal/utils/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func dataExportToDTO(export dbmodels.DataExport) responses.DataExportDTO {
+	dto := responses.DataExportDTO{
+		ID:          export.ID,
+		Status:      export.Status,
+		Error:       export.Error,
+		Size:        export.Size,
+		CreatedAt:   export.CreatedAt,
+		CompletedAt: export.CompletedAt,
+		ExpiresAt:   export.ExpiresAt,
+	}
+
+	if export.Status == dbmodels.DataExportReady && export.ExpiresAt != nil && export.ExpiresAt.After(time.Now()) {
+		// Ссылка не переживает сам архив
+		linkExpiresAt := time.Now().Add(config.Config.DataExport.LinkTTL)
+		if linkExpiresAt.After(*export.ExpiresAt) {
+			linkExpiresAt = *export.ExpiresAt
+		}
+		dto.DownloadURL = dataexport.SignedURL(config.Config.DataExport.SigningKey, export.ID, linkExpiresAt)
+		dto.LinkExpiresAt = &linkExpiresAt
+	}
+	return dto
+}
+
+// RequestDataExport starts an export of all personal data
+// @Summary Export personal data
+// @Description Queues a ZIP archive with the account, tasks, condition and interests templates and generation history, each as JSON and CSV. Poll GET /api/me/exports for the signed download link
+// @Tags Profile
+// @Produce json
+// @Success 202 {object} responses.RequestDataExportDTO "Export queued"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token"
+// @Failure 409 {object} responses.ErrorResponse "Export already in progress"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/me/export [post]
+func RequestDataExport(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		var running int64
+		err = db.Model(&dbmodels.DataExport{}).
+			Where("user_id = ? AND status IN ?", userID, []string{dbmodels.DataExportPending, dbmodels.DataExportProcessing}).
+			Count(&running).Error
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		if running > 0 {
+			return c.Status(409).JSON(responses.ErrorResponse{
+				Status: "export in progress",
+				Error:  "wait for the current export to finish",
+			})
+		}
+
+		export := dbmodels.DataExport{
+			UserID:    userID,
+			Status:    dbmodels.DataExportPending,
+			CreatedAt: time.Now(),
+		}
+		if err := db.Create(&export).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to start export",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(202).JSON(responses.RequestDataExportDTO{
+			Status: "export queued",
+			Export: dataExportToDTO(export),
+		})
+	}
+}
+
+// GetDataExports lists personal data exports of the user
+// @Summary List personal data exports
+// @Description Returns exports of the user, newest first. Ready exports include a signed download link that works without logging in until link_expires_at
+// @Tags Profile
+// @Produce json
+// @Param cursor query string false "Cursor returned as page.next_cursor by the previous page"
+// @Param limit query int false "Page size (default is 20, max is 100)"
+// @Param include_total query bool false "Count all items and return page.total"
+// @Success 200 {object} responses.GetDataExportsDTO "Exports successfully retrieved"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/me/exports [get]
+func GetDataExports(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		page, err := parsePage(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid page",
+				Error:  err.Error(),
+			})
+		}
+		if validationErrors := validator.ValidateStruct(page); validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		query := db.Model(&dbmodels.DataExport{}).Where("user_id = ?", userID)
+		total, err := countPage(query, page)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		order := pageOrder{IDColumn: "id", Desc: true}
+		query, err = paginate(query, order, page)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid cursor",
+				Error:  err.Error(),
+			})
+		}
+
+		var exports []dbmodels.DataExport
+		if err := query.Find(&exports).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+
+		exports, pageDTO, err := finishPage(exports, page, total, func(export dbmodels.DataExport) pagePosition {
+			return pagePosition{ID: export.ID}
+		})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to encode cursor",
+				Error:  err.Error(),
+			})
+		}
+
+		exportsDTO := make([]responses.DataExportDTO, len(exports))
+		for i, export := range exports {
+			exportsDTO[i] = dataExportToDTO(export)
+		}
+
+		return c.Status(200).JSON(responses.GetDataExportsDTO{
+			Exports: exportsDTO,
+			Page:    pageDTO,
+		})
+	}
+}
+
+// DownloadDataExport downloads a personal data archive by a signed link
+// @Summary Download personal data
+// @Description Downloads the ZIP archive. Works without a token, but only with a valid, unexpired signature from GET /api/me/exports
+// @Tags Profile
+// @Produce application/zip
+// @Param id path int true "Export ID"
+// @Param expires query int true "Link expiry, Unix time"
+// @Param signature query string true "Link signature"
+// @Success 200 {file} file "ZIP archive"
+// @Failure 400 {object} responses.ErrorResponse "Invalid ID"
+// @Failure 403 {object} responses.ErrorResponse "Invalid or expired link"
+// @Failure 404 {object} responses.ErrorResponse "Archive not available"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/me/export/download/{id} [get]
+func DownloadDataExport(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.Atoi(c.Params("id"))
+		if err != nil || id <= 0 {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid id",
+				Error:  "ID must be a positive integer",
+			})
+		}
+
+		if !dataexport.VerifySignature(config.Config.DataExport.SigningKey, uint(id), c.Query("expires"), c.Query("signature")) {
+			return c.Status(403).JSON(responses.ErrorResponse{
+				Status: "invalid link",
+				Error:  "the download link is invalid or expired",
+			})
+		}
+
+		var export dbmodels.DataExport
+		result := db.First(&export, id)
+		if result.Error != nil && result.Error != gorm.ErrRecordNotFound {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+		if result.Error == gorm.ErrRecordNotFound || export.Status != dbmodels.DataExportReady ||
+			export.ExpiresAt == nil || time.Now().After(*export.ExpiresAt) {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "export not available",
+				Error:  "the archive was deleted, request a new export",
+			})
+		}
+
+		c.Set(fiber.HeaderCacheControl, "no-store")
+		name := fmt.Sprintf("gera-ai-data-%s.zip", export.CompletedAt.Format("2006-01-02"))
+		return c.Download(filepath.Join(config.Config.DataExport.Dir, export.FileName), name)
+	}
+}
@@ -0,0 +1,347 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+
+	dbmodels "gera-ai/internal/models/database"
+	"gera-ai/internal/models/requests"
+	"gera-ai/internal/models/responses"
+	"gera-ai/internal/utils/exporter"
+	"gera-ai/internal/utils/jwtUtils"
+	"gera-ai/internal/utils/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// ExportTask exports a single task to an LMS format
+// @Summary Export a task
+// @Description Exports a task the user can view to Moodle XML, GIFT or an IMS QTI 2.1 package. Numeric answers become numerical questions, others short-answer
+// @Tags Export
+// @Produce application/xml,text/plain,application/zip
+// @Param id path int true "Task ID"
+// @Param format query string true "Export format" Enums(moodle, gift, qti)
+// @Success 200 {file} file "Exported file"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or ID"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "Task not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/export/task/{id} [get]
+func ExportTask(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		authorID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		taskID, err := strconv.Atoi(c.Params("id"))
+		if err != nil || taskID <= 0 {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid task ID",
+				Error:  "task ID must be a positive integer",
+			})
+		}
+
+		data := requests.ExportTask{ID: uint(taskID), Format: c.Query("format")}
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		var task dbmodels.Task
+		result := db.First(&task, data.ID)
+		if result.Error == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "task not found",
+			})
+		} else if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		access, err := resourceAccess(db, authorID, "task", task.ID, task.AuthorID, task.OrganizationID)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		if access < accessViewer {
+			return c.Status(403).JSON(responses.ErrorResponse{
+				Status: "forbidden",
+				Error:  "you do not have access to this task",
+			})
+		}
+
+		file, err := exporter.Export(data.Format, fmt.Sprintf("task-%d", task.ID), []exporter.Question{{
+			ID:     task.ID,
+			Title:  task.Title,
+			Text:   task.Condition,
+			Answer: task.Answer,
+		}})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to export task",
+				Error:  err.Error(),
+			})
+		}
+
+		return sendExportFile(c, file)
+	}
+}
+
+// ExportTasks exports a selection of tasks to an LMS format
+// @Summary Export selected tasks
+// @Description Exports the selected tasks the user can view into a single Moodle XML, GIFT or IMS QTI 2.1 file
+// @Tags Export
+// @Accept json
+// @Produce application/xml,text/plain,application/zip
+// @Param input body requests.ExportTasks true "Tasks to export"
+// @Success 200 {file} file "Exported file"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "Task not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/export/tasks [post]
+func ExportTasks(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		authorID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.ExportTasks{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		var tasks []dbmodels.Task
+		result := db.Where("id IN ?", data.IDs).Order("id").Find(&tasks)
+		if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		// Выгружать можно все задачи, которые пользователь может просматривать, как и в GetTask
+		found := make(map[uint]dbmodels.Task, len(tasks))
+		for _, task := range tasks {
+			access, err := resourceAccess(db, authorID, "task", task.ID, task.AuthorID, task.OrganizationID)
+			if err != nil {
+				return c.Status(500).JSON(responses.ErrorResponse{
+					Status: "internal server error",
+					Error:  err.Error(),
+				})
+			}
+			if access < accessViewer {
+				return c.Status(403).JSON(responses.ErrorResponse{
+					Status: "forbidden",
+					Error:  fmt.Sprintf("you do not have access to task %d", task.ID),
+				})
+			}
+			found[task.ID] = task
+		}
+
+		// Сохраняем порядок, в котором задачи выбрал пользователь
+		questions := make([]exporter.Question, 0, len(data.IDs))
+		for _, id := range data.IDs {
+			task, ok := found[id]
+			if !ok {
+				return c.Status(404).JSON(responses.ErrorResponse{
+					Status: "task not found",
+					Error:  fmt.Sprintf("task %d not found", id),
+				})
+			}
+			questions = append(questions, exporter.Question{
+				ID:     task.ID,
+				Title:  task.Title,
+				Text:   task.Condition,
+				Answer: task.Answer,
+			})
+		}
+
+		file, err := exporter.Export(data.Format, "tasks", questions)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to export tasks",
+				Error:  err.Error(),
+			})
+		}
+
+		return sendExportFile(c, file)
+	}
+}
+
+// ExportGenerations exports a batch of generated variants to an LMS format
+// @Summary Export generated variants
+// @Description Exports a batch of generated variants. The answer is taken from task_id when given, then from the task the variant was generated from, otherwise from answer
+// @Tags Export
+// @Accept json
+// @Produce application/xml,text/plain,application/zip
+// @Param input body requests.ExportGenerations true "Generated variants to export"
+// @Success 200 {file} file "Exported file"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "Task or generation not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/export/generations [post]
+func ExportGenerations(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		authorID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.ExportGenerations{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		// Ответ берем из указанной задачи, иначе из задачи, по которой сделана генерация
+		var task *dbmodels.Task
+		if data.TaskID != 0 {
+			task = &dbmodels.Task{}
+			result := db.First(task, data.TaskID)
+			if result.Error == gorm.ErrRecordNotFound {
+				return c.Status(404).JSON(responses.ErrorResponse{
+					Status: "task not found",
+				})
+			} else if result.Error != nil {
+				return c.Status(500).JSON(responses.ErrorResponse{
+					Status: "internal server error",
+					Error:  result.Error.Error(),
+				})
+			}
+			access, err := resourceAccess(db, authorID, "task", task.ID, task.AuthorID, task.OrganizationID)
+			if err != nil {
+				return c.Status(500).JSON(responses.ErrorResponse{
+					Status: "internal server error",
+					Error:  err.Error(),
+				})
+			}
+			if access < accessViewer {
+				return c.Status(403).JSON(responses.ErrorResponse{
+					Status: "forbidden",
+					Error:  "you do not have access to this task",
+				})
+			}
+		}
+
+		var generations []dbmodels.Generation
+		result := db.Preload("Task").Where("id IN ?", data.GenerationIDs).Find(&generations)
+		if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		found := make(map[uint]dbmodels.Generation, len(generations))
+		for _, generation := range generations {
+			if generation.UserID != authorID {
+				return c.Status(403).JSON(responses.ErrorResponse{
+					Status: "forbidden",
+					Error:  fmt.Sprintf("you are not the author of generation %d", generation.ID),
+				})
+			}
+			if generation.Type == dbmodels.GenerationTypeAnswer || generation.Type == dbmodels.GenerationTypeClassification {
+				return c.Status(422).JSON(responses.ErrorResponse{
+					Status: "invalid generation type",
+					Error:  fmt.Sprintf("generation %d has type %s and is not a task", generation.ID, generation.Type),
+				})
+			}
+			found[generation.ID] = generation
+		}
+
+		questions := make([]exporter.Question, 0, len(data.GenerationIDs))
+		for i, id := range data.GenerationIDs {
+			generation, ok := found[id]
+			if !ok {
+				return c.Status(404).JSON(responses.ErrorResponse{
+					Status: "generation not found",
+					Error:  fmt.Sprintf("generation %d not found", id),
+				})
+			}
+
+			title, answer := data.Title, data.Answer
+			source := task
+			if source == nil {
+				source = generation.Task
+			}
+			if source != nil {
+				answer = source.Answer
+				if title == "" {
+					title = source.Title
+				}
+			}
+			if title == "" {
+				title = "variant"
+			}
+
+			questions = append(questions, exporter.Question{
+				ID:     generation.ID,
+				Title:  fmt.Sprintf("%s (%d)", title, i+1),
+				Text:   generation.Result,
+				Answer: answer,
+			})
+		}
+
+		file, err := exporter.Export(data.Format, "generations", questions)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to export generations",
+				Error:  err.Error(),
+			})
+		}
+
+		return sendExportFile(c, file)
+	}
+}
+
+// sendExportFile отдает файл экспорта как вложение
+func sendExportFile(c *fiber.Ctx, file exporter.File) error {
+	c.Set(fiber.HeaderContentType, file.ContentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, file.Name))
+	return c.Status(200).Send(file.Data)
+}
@@ -0,0 +1,441 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	dbmodels "gera-ai/internal/models/database"
+	"gera-ai/internal/models/requests"
+	"gera-ai/internal/models/responses"
+	"gera-ai/internal/utils/jsonUtils"
+	"gera-ai/internal/utils/jwtUtils"
+	"gera-ai/internal/utils/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// feedbackGroupColumns - колонки генераций, по которым можно группировать сводку оценок
+var feedbackGroupColumns = map[string]string{
+	"prompt_version": "g.prompt_version",
+	"model":          "g.ai_model",
+}
+
+func feedbackToDTO(feedback dbmodels.GenerationFeedback) (responses.FeedbackDTO, error) {
+	dto := responses.FeedbackDTO{
+		ID:           feedback.ID,
+		GenerationID: feedback.GenerationID,
+		UserID:       feedback.UserID,
+		Rating:       feedback.Rating,
+		Comment:      feedback.Comment,
+		Tags:         []string{},
+		CreatedAt:    feedback.CreatedAt,
+	}
+	if feedback.ThumbsUp != nil {
+		if *feedback.ThumbsUp {
+			dto.Thumb = "up"
+		} else {
+			dto.Thumb = "down"
+		}
+	}
+	if len(feedback.Tags) > 0 {
+		tags, err := jsonUtils.ConvertInterestsToList(feedback.Tags)
+		if err != nil {
+			return dto, err
+		}
+		dto.Tags = tags
+	}
+	return dto, nil
+}
+
+// CreateFeedback rates a generation
+// @Summary Rate a generation
+// @Description Saves a rating, a thumb, a comment and issue tags for a generation. Rating the same generation again replaces the previous feedback
+// @Tags Feedback
+// @Accept json
+// @Produce json
+// @Param input body requests.CreateFeedback true "Feedback data"
+// @Success 200 {object} responses.CreateFeedbackDTO "Feedback successfully saved"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "Generation not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/feedback/new [post]
+func CreateFeedback(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.CreateFeedback{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+		if data.Rating == nil && data.Thumb == "" && data.Comment == "" && len(data.Tags) == 0 {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: map[string]string{"Rating": "required_without_all"},
+			})
+		}
+
+		var generation dbmodels.Generation
+		result := db.First(&generation, data.GenerationID)
+		if result.Error == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "generation not found",
+			})
+		} else if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		if generation.UserID != userID {
+			return c.Status(403).JSON(responses.ErrorResponse{
+				Status: "forbidden",
+				Error:  "you are not the author of this generation",
+			})
+		}
+
+		if data.Tags == nil {
+			data.Tags = []string{}
+		}
+		tagsJSON, err := json.Marshal(data.Tags)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to process tags",
+				Error:  err.Error(),
+			})
+		}
+
+		// Повторная оценка заменяет предыдущую, в том числе удаленную
+		var feedback dbmodels.GenerationFeedback
+		result = db.Unscoped().
+			Where("generation_id = ? AND user_id = ?", generation.ID, userID).
+			Take(&feedback)
+		if result.Error != nil && result.Error != gorm.ErrRecordNotFound {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+		if result.Error == gorm.ErrRecordNotFound {
+			feedback = dbmodels.GenerationFeedback{
+				GenerationID: generation.ID,
+				UserID:       userID,
+				CreatedAt:    time.Now(),
+			}
+		}
+
+		feedback.Rating = data.Rating
+		feedback.ThumbsUp = nil
+		if data.Thumb != "" {
+			thumbsUp := data.Thumb == "up"
+			feedback.ThumbsUp = &thumbsUp
+		}
+		feedback.Comment = data.Comment
+		feedback.Tags = tagsJSON
+		feedback.DeletedAt = gorm.DeletedAt{}
+		feedback.UpdatedAt = time.Now()
+
+		if err := db.Unscoped().Save(&feedback).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to save feedback",
+				Error:  err.Error(),
+			})
+		}
+
+		dto, err := feedbackToDTO(feedback)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to decode tags",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.CreateFeedbackDTO{
+			Status:   "feedback saved",
+			Feedback: dto,
+		})
+	}
+}
+
+// GetGenerationFeedback retrieves the feedback left for a generation
+// @Summary Retrieve feedback of a generation
+// @Description Fetches all feedback left for a generation owned by the user
+// @Tags Feedback
+// @Produce json
+// @Param id path int true "Generation ID"
+// @Success 200 {object} responses.GetGenerationFeedbackDTO "Feedback successfully retrieved"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or ID"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "Generation not found"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/feedback/generation/{id} [get]
+func GetGenerationFeedback(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		generationID, err := strconv.Atoi(c.Params("id"))
+		if err != nil || generationID <= 0 {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid generation ID",
+				Error:  "generation ID must be a positive integer",
+			})
+		}
+
+		var generation dbmodels.Generation
+		result := db.First(&generation, generationID)
+		if result.Error == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "generation not found",
+			})
+		} else if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		if generation.UserID != userID {
+			return c.Status(403).JSON(responses.ErrorResponse{
+				Status: "forbidden",
+				Error:  "you are not the author of this generation",
+			})
+		}
+
+		var feedback []dbmodels.GenerationFeedback
+		result = db.Where("generation_id = ?", generation.ID).Order("id DESC").Find(&feedback)
+		if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		feedbackDTO := make([]responses.FeedbackDTO, 0, len(feedback))
+		for _, item := range feedback {
+			dto, err := feedbackToDTO(item)
+			if err != nil {
+				return c.Status(500).JSON(responses.ErrorResponse{
+					Status: "failed to decode tags",
+					Error:  err.Error(),
+				})
+			}
+			feedbackDTO = append(feedbackDTO, dto)
+		}
+
+		return c.Status(200).JSON(responses.GetGenerationFeedbackDTO{
+			Feedback: feedbackDTO,
+		})
+	}
+}
+
+// DeleteFeedback deletes feedback by ID
+// @Summary Delete feedback
+// @Description Deletes feedback left by the user
+// @Tags Feedback
+// @Accept json
+// @Produce json
+// @Param input body requests.DeleteFeedback true "Feedback deletion data"
+// @Success 200 {object} responses.DeleteFeedbackDTO "Feedback successfully deleted"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "Feedback not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/feedback/delete [delete]
+func DeleteFeedback(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.DeleteFeedback{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		var feedback dbmodels.GenerationFeedback
+		result := db.First(&feedback, data.ID)
+		if result.Error == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "feedback not found",
+			})
+		} else if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		if feedback.UserID != userID {
+			return c.Status(403).JSON(responses.ErrorResponse{
+				Status: "forbidden",
+				Error:  "you are not the author of this feedback",
+			})
+		}
+
+		if err := db.Delete(&feedback).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to delete feedback",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.DeleteFeedbackDTO{
+			Status: "feedback deleted",
+		})
+	}
+}
+
+// GetFeedbackStats aggregates feedback per prompt version or model
+// @Summary Aggregated feedback
+// @Description Aggregates ratings, thumbs and issue tags of all feedback per generation type and prompt version or model. Generations in the trash are not counted
+// @Tags Feedback
+// @Produce json
+// @Param group_by query string false "Grouping (default is prompt_version)" Enums(prompt_version, model)
+// @Success 200 {object} responses.GetFeedbackStatsDTO "Stats successfully retrieved"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/feedback/stats [get]
+func GetFeedbackStats(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if _, err := jwtUtils.ExtractUserID(c); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.GetFeedbackStats{GroupBy: c.Query("group_by", "prompt_version")}
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+		groupColumn := feedbackGroupColumns[data.GroupBy]
+
+		// Тип генерации совпадает с ключом промпта, а версия промпта нумеруется для каждого ключа
+		groupColumns := "g.type, " + groupColumn
+
+		var rows []struct {
+			Type          string
+			Group         string
+			Generations   int64
+			Feedback      int64
+			Ratings       int64
+			AverageRating *float64
+			ThumbsUp      int64
+			ThumbsDown    int64
+		}
+		result := db.Table("generation_feedbacks AS f").
+			Select(`g.type AS type, ` + groupColumn + ` AS "group",
+				COUNT(DISTINCT f.generation_id) AS generations,
+				COUNT(f.id) AS feedback,
+				COUNT(f.rating) AS ratings,
+				AVG(f.rating) AS average_rating,
+				COUNT(*) FILTER (WHERE f.thumbs_up) AS thumbs_up,
+				COUNT(*) FILTER (WHERE NOT f.thumbs_up) AS thumbs_down`).
+			Joins("JOIN generations AS g ON g.id = f.generation_id").
+			Where("f.deleted_at IS NULL AND g.deleted_at IS NULL").
+			Group(groupColumns).
+			Order(groupColumns).
+			Scan(&rows)
+		if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		var tagCounts []struct {
+			Type  string
+			Group string
+			Tag   string
+			Count int64
+		}
+		result = db.Table("generation_feedbacks AS f").
+			Select(`g.type AS type, ` + groupColumn + ` AS "group", tag.value AS tag, COUNT(*) AS count`).
+			Joins("JOIN generations AS g ON g.id = f.generation_id").
+			Joins("CROSS JOIN LATERAL json_array_elements_text(f.tags) AS tag(value)").
+			Where("f.deleted_at IS NULL AND g.deleted_at IS NULL").
+			Group(groupColumns + ", tag.value").
+			Scan(&tagCounts)
+		if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		stats := make([]responses.FeedbackStatsDTO, len(rows))
+		byGroup := make(map[[2]string]*responses.FeedbackStatsDTO, len(rows))
+		for i, row := range rows {
+			stats[i] = responses.FeedbackStatsDTO{
+				Type:          row.Type,
+				Group:         row.Group,
+				Generations:   row.Generations,
+				Feedback:      row.Feedback,
+				Ratings:       row.Ratings,
+				AverageRating: row.AverageRating,
+				ThumbsUp:      row.ThumbsUp,
+				ThumbsDown:    row.ThumbsDown,
+				Tags:          map[string]int64{},
+			}
+			byGroup[[2]string{row.Type, row.Group}] = &stats[i]
+		}
+		for _, tagCount := range tagCounts {
+			if group, ok := byGroup[[2]string{tagCount.Type, tagCount.Group}]; ok {
+				group.Tags[tagCount.Tag] = tagCount.Count
+			}
+		}
+
+		return c.Status(200).JSON(responses.GetFeedbackStatsDTO{
+			GroupBy: data.GroupBy,
+			Stats:   stats,
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"gera-ai/internal/models/requests"
+	"gera-ai/internal/models/responses"
+	"gera-ai/internal/utils/jwtUtils"
+	"gera-ai/internal/utils/validator"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestGetFeedbackStatsGroupsByType(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	// Одинаковая версия у разных промптов - это разные версии, они не складываются
+	mock.ExpectQuery(`SELECT g\.type AS type, g\.prompt_version AS "group",(.|\n)+` +
+		regexp.QuoteMeta(`WHERE f.deleted_at IS NULL AND g.deleted_at IS NULL GROUP BY g.type, g.prompt_version ORDER BY g.type, g.prompt_version`)).
+		WillReturnRows(sqlmock.NewRows([]string{"type", "group", "generations", "feedback", "ratings", "average_rating", "thumbs_up", "thumbs_down"}).
+			AddRow("answer", "v1-r2", 1, 1, 1, 5.0, 1, 0).
+			AddRow("interests", "v1-r2", 2, 2, 2, 2.5, 0, 2))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT g.type AS type, g.prompt_version AS "group", tag.value AS tag, COUNT(*) AS count`) + `(.|\n)+` +
+		regexp.QuoteMeta(`WHERE f.deleted_at IS NULL AND g.deleted_at IS NULL GROUP BY g.type, g.prompt_version, tag.value`)).
+		WillReturnRows(sqlmock.NewRows([]string{"type", "group", "tag", "count"}).
+			AddRow("interests", "v1-r2", "boring", 2))
+
+	app := fiber.New()
+	app.Get("/feedback/stats", func(c *fiber.Ctx) error {
+		jwtUtils.SetAccessToken(c, 5, "admin", 0, "")
+		return c.Next()
+	}, GetFeedbackStats(db))
+
+	response, err := app.Test(httptest.NewRequest("GET", "/feedback/stats", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != 200 {
+		t.Fatalf("GET = %d %s, want 200", response.StatusCode, body)
+	}
+
+	var stats responses.GetFeedbackStatsDTO
+	if err := json.Unmarshal(body, &stats); err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Stats) != 2 || stats.Stats[0].Type != "answer" || stats.Stats[1].Type != "interests" {
+		t.Fatalf("stats = %+v, want answer and interests rows", stats.Stats)
+	}
+	// Метки относятся только к своему типу
+	if len(stats.Stats[0].Tags) != 0 || !reflect.DeepEqual(stats.Stats[1].Tags, map[string]int64{"boring": 2}) {
+		t.Fatalf("tags = %v and %v", stats.Stats[0].Tags, stats.Stats[1].Tags)
+	}
+}
+
+func TestCreateFeedbackRejectsDuplicateTags(t *testing.T) {
+	data := requests.CreateFeedback{GenerationID: 1, Tags: []string{"boring", "too_long", "boring"}}
+	if validator.ValidateStruct(data) == nil {
+		t.Fatal("duplicate tags passed validation")
+	}
+
+	data.Tags = []string{"boring", "too_long"}
+	if errs := validator.ValidateStruct(data); errs != nil {
+		t.Fatalf("unique tags failed validation: %v", errs)
+	}
+}
@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	dbmodels "gera-ai/internal/models/database"
 	"gera-ai/internal/models/requests"
 	"gera-ai/internal/models/responses"
@@ -23,6 +25,7 @@ import (
 // @Success 200 {object} responses.GeneratedTaskResponse "Successfully generated task"
 // @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
 // @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 429 {object} responses.ErrorResponse "Organization generation limit exceeded"
 // @Failure 500 {object} responses.ErrorResponse "Error saving the generated task"
 // @Router /api/generate/interests [post]
 func GenerateTaskByInterest(db *gorm.DB, tg *taskGenerator.TaskGenerator) fiber.Handler {
@@ -54,6 +57,22 @@ func GenerateTaskByInterest(db *gorm.DB, tg *taskGenerator.TaskGenerator) fiber.
 			})
 		}
 
+		// Проверка источников генерации
+		if status, err := checkGenerationSources(db, authorID, data.TaskID, data.ConditionTemplateID, data.InterestsTemplateID, nil); err != nil {
+			return c.Status(status).JSON(responses.ErrorResponse{
+				Status: "invalid generation source",
+				Error:  err.Error(),
+			})
+		}
+
+		// Проверка месячного лимита генераций организации
+		if status, err := checkGenerationLimit(c, db); err != nil {
+			return c.Status(status).JSON(responses.ErrorResponse{
+				Status: "generation limit exceeded",
+				Error:  err.Error(),
+			})
+		}
+
 		// Генерация задания
 		taskText, err := tg.GenerateTaskWithInterests(data.Condition, data.Interests)
 		if err != nil {
@@ -73,13 +92,17 @@ func GenerateTaskByInterest(db *gorm.DB, tg *taskGenerator.TaskGenerator) fiber.
 		}
 
 		// Сохранение в истории генераций
-		generatedTask := dbmodels.GenerationByInterestsHistory{
-			UserID:    authorID,
-			Condition: data.Condition,
-			Interests: interestsJSON,
-			TaskText:  taskText,
-			CreatedAt: time.Now(),
+		generatedTask, err := newGeneration(tg, authorID, activeOrganizationID(c), dbmodels.GenerationTypeInterests, data.Condition, taskText)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to process generation parameters",
+				Error:  err.Error(),
+			})
 		}
+		generatedTask.Interests = interestsJSON
+		generatedTask.TaskID = data.TaskID
+		generatedTask.ConditionTemplateID = data.ConditionTemplateID
+		generatedTask.InterestsTemplateID = data.InterestsTemplateID
 
 		if err := db.Create(&generatedTask).Error; err != nil {
 			return c.Status(500).JSON(responses.ErrorResponse{
@@ -91,6 +114,7 @@ func GenerateTaskByInterest(db *gorm.DB, tg *taskGenerator.TaskGenerator) fiber.
 		// Ответ с успешным результатом
 		return c.Status(200).JSON(responses.GeneratedTaskResponse{
 			Status:        "generated successfully",
+			GenerationID:  generatedTask.ID,
 			GeneratedText: taskText,
 		})
 	}
@@ -106,6 +130,7 @@ func GenerateTaskByInterest(db *gorm.DB, tg *taskGenerator.TaskGenerator) fiber.
 // @Success 200 {object} responses.GeneratedTaskResponse "Successfully generated task"
 // @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
 // @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 429 {object} responses.ErrorResponse "Organization generation limit exceeded"
 // @Failure 500 {object} responses.ErrorResponse "Error saving the generated task"
 // @Router /api/generate/nointerests [post]
 func GenerateTaskByNoInterest(db *gorm.DB, tg *taskGenerator.TaskGenerator) fiber.Handler {
@@ -137,6 +162,22 @@ func GenerateTaskByNoInterest(db *gorm.DB, tg *taskGenerator.TaskGenerator) fibe
 			})
 		}
 
+		// Проверка источников генерации
+		if status, err := checkGenerationSources(db, authorID, data.TaskID, data.ConditionTemplateID, nil, nil); err != nil {
+			return c.Status(status).JSON(responses.ErrorResponse{
+				Status: "invalid generation source",
+				Error:  err.Error(),
+			})
+		}
+
+		// Проверка месячного лимита генераций организации
+		if status, err := checkGenerationLimit(c, db); err != nil {
+			return c.Status(status).JSON(responses.ErrorResponse{
+				Status: "generation limit exceeded",
+				Error:  err.Error(),
+			})
+		}
+
 		// Генерация задания
 		taskText, err := tg.GenerateTaskWithNoInterests(data.Condition)
 		if err != nil {
@@ -146,12 +187,15 @@ func GenerateTaskByNoInterest(db *gorm.DB, tg *taskGenerator.TaskGenerator) fibe
 			})
 		}
 		// Сохранение в истории генераций
-		generatedTask := dbmodels.GenerationByNoInterestsHistory{
-			UserID:    authorID,
-			Condition: data.Condition,
-			TaskText:  taskText,
-			CreatedAt: time.Now(),
+		generatedTask, err := newGeneration(tg, authorID, activeOrganizationID(c), dbmodels.GenerationTypeNoInterests, data.Condition, taskText)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to process generation parameters",
+				Error:  err.Error(),
+			})
 		}
+		generatedTask.TaskID = data.TaskID
+		generatedTask.ConditionTemplateID = data.ConditionTemplateID
 
 		if err := db.Create(&generatedTask).Error; err != nil {
 			return c.Status(500).JSON(responses.ErrorResponse{
@@ -163,6 +207,7 @@ func GenerateTaskByNoInterest(db *gorm.DB, tg *taskGenerator.TaskGenerator) fibe
 		// Ответ с успешным результатом
 		return c.Status(200).JSON(responses.GeneratedTaskResponse{
 			Status:        "generated successfully",
+			GenerationID:  generatedTask.ID,
 			GeneratedText: taskText,
 		})
 	}
@@ -178,6 +223,7 @@ func GenerateTaskByNoInterest(db *gorm.DB, tg *taskGenerator.TaskGenerator) fibe
 // @Success 200 {object} responses.GeneratedAnswerResponse "Successfully generated answer"
 // @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
 // @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 429 {object} responses.ErrorResponse "Organization generation limit exceeded"
 // @Failure 500 {object} responses.ErrorResponse "Error saving the generated answer"
 // @Router /api/generate/answer [post]
 func GenerateAnswer(db *gorm.DB, tg *taskGenerator.TaskGenerator) fiber.Handler {
@@ -209,6 +255,22 @@ func GenerateAnswer(db *gorm.DB, tg *taskGenerator.TaskGenerator) fiber.Handler
 			})
 		}
 
+		// Проверка источников генерации
+		if status, err := checkGenerationSources(db, authorID, data.TaskID, nil, nil, data.GenerationID); err != nil {
+			return c.Status(status).JSON(responses.ErrorResponse{
+				Status: "invalid generation source",
+				Error:  err.Error(),
+			})
+		}
+
+		// Проверка месячного лимита генераций организации
+		if status, err := checkGenerationLimit(c, db); err != nil {
+			return c.Status(status).JSON(responses.ErrorResponse{
+				Status: "generation limit exceeded",
+				Error:  err.Error(),
+			})
+		}
+
 		// Генерация задания
 		answer, err := tg.GenerateAnswer(data.Condition)
 		if err != nil {
@@ -218,12 +280,15 @@ func GenerateAnswer(db *gorm.DB, tg *taskGenerator.TaskGenerator) fiber.Handler
 			})
 		}
 		// Сохранение в истории генераций
-		generatedAnswer := dbmodels.GenerationAnswersHistory{
-			UserID:    authorID,
-			Condition: data.Condition,
-			Answer:    answer,
-			CreatedAt: time.Now(),
+		generatedAnswer, err := newGeneration(tg, authorID, activeOrganizationID(c), dbmodels.GenerationTypeAnswer, data.Condition, answer)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to process generation parameters",
+				Error:  err.Error(),
+			})
 		}
+		generatedAnswer.TaskID = data.TaskID
+		generatedAnswer.ParentGenerationID = data.GenerationID
 
 		if err := db.Create(&generatedAnswer).Error; err != nil {
 			return c.Status(500).JSON(responses.ErrorResponse{
@@ -235,7 +300,63 @@ func GenerateAnswer(db *gorm.DB, tg *taskGenerator.TaskGenerator) fiber.Handler
 		// Ответ с успешным результатом
 		return c.Status(200).JSON(responses.GeneratedAnswerResponse{
 			Status:        "generated successfully",
+			GenerationID:  generatedAnswer.ID,
 			GeneratedText: answer,
 		})
 	}
 }
+
+// newGeneration создает запись истории с моделью, версией промпта и параметрами генератора.
+// Тип генерации совпадает с ключом промпта
+func newGeneration(tg *taskGenerator.TaskGenerator, userID uint, organizationID *uint, generationType, condition, result string) (dbmodels.Generation, error) {
+	parameters, err := json.Marshal(tg.Parameters())
+	if err != nil {
+		return dbmodels.Generation{}, err
+	}
+
+	return dbmodels.Generation{
+		UserID:         userID,
+		OrganizationID: organizationID,
+		Type:           generationType,
+		Condition:      condition,
+		Result:         result,
+		AIModel:        tg.Model(),
+		PromptVersion:  tg.PromptVersion(generationType),
+		Parameters:     parameters,
+		CreatedAt:      time.Now(),
+	}, nil
+}
+
+// checkGenerationSources проверяет, что задача и шаблоны, из которых сделана генерация, принадлежат пользователю.
+// Возвращает HTTP статус, с которым нужно ответить при ошибке
+func checkGenerationSources(db *gorm.DB, userID uint, taskID, conditionTemplateID, interestsTemplateID, parentGenerationID *uint) (int, error) {
+	sources := []struct {
+		name   string
+		model  interface{}
+		column string
+		id     *uint
+	}{
+		{name: "task", model: &dbmodels.Task{}, column: "author_id", id: taskID},
+		{name: "condition template", model: &dbmodels.ConditionTemplate{}, column: "author_id", id: conditionTemplateID},
+		{name: "interests template", model: &dbmodels.InterestsTemplate{}, column: "author_id", id: interestsTemplateID},
+		{name: "generation", model: &dbmodels.Generation{}, column: "user_id", id: parentGenerationID},
+	}
+
+	for _, source := range sources {
+		if source.id == nil {
+			continue
+		}
+
+		var authorIDs []uint
+		if err := db.Model(source.model).Where("id = ?", *source.id).Pluck(source.column, &authorIDs).Error; err != nil {
+			return 500, err
+		}
+		if len(authorIDs) == 0 {
+			return 404, fmt.Errorf("%s %d not found", source.name, *source.id)
+		}
+		if authorIDs[0] != userID {
+			return 403, errors.New("you are not the author of this " + source.name)
+		}
+	}
+	return 0, nil
+}
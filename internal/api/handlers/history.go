@@ -0,0 +1,548 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	dbmodels "gera-ai/internal/models/database"
+	"gera-ai/internal/models/requests"
+	"gera-ai/internal/models/responses"
+	"gera-ai/internal/utils/jsonUtils"
+	"gera-ai/internal/utils/jwtUtils"
+	"gera-ai/internal/utils/listQuery"
+	"gera-ai/internal/utils/taskGenerator"
+	"gera-ai/internal/utils/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func generationToDTO(generation dbmodels.Generation) (responses.GenerationHistoryDTO, error) {
+	dto := responses.GenerationHistoryDTO{
+		ID:                  generation.ID,
+		Type:                generation.Type,
+		TaskID:              generation.TaskID,
+		ConditionTemplateID: generation.ConditionTemplateID,
+		InterestsTemplateID: generation.InterestsTemplateID,
+		Condition:           generation.Condition,
+		Text:                generation.Result,
+		Model:               generation.AIModel,
+		PromptVersion:       generation.PromptVersion,
+		CreatedAt:           generation.CreatedAt,
+		LegacyID:            generation.LegacyID,
+	}
+	if len(generation.Interests) > 0 {
+		interests, err := jsonUtils.ConvertInterestsToList(generation.Interests)
+		if err != nil {
+			return dto, err
+		}
+		dto.Interests = interests
+	}
+	return dto, nil
+}
+
+// historyListSpec - поля истории генераций, по которым можно сортировать и фильтровать
+var historyListSpec = listQuery.Spec{
+	Sort: map[string]listQuery.Field{
+		"created_at": {Column: "created_at", Kind: listQuery.Time},
+	},
+	Filters: map[string]listQuery.Field{
+		"condition": {Column: "condition", Kind: listQuery.Text},
+		"text":      {Column: "result", Kind: listQuery.Text},
+		"created":   {Column: "created_at", Kind: listQuery.Time},
+	},
+}
+
+// GetAllGenerationHistory retrieves the generation history of the user
+// @Summary Retrieve generation history
+// @Description Fetches generations of the authenticated user, newest first by default, with filtering, sorting and cursor pagination
+// @Tags History
+// @Produce json
+// @Param type query string false "Comma separated generation types" Enums(interests, nointerests, answer)
+// @Param from query string false "Only generations created at or after this time (RFC3339)"
+// @Param to query string false "Only generations created at or before this time (RFC3339)"
+// @Param q query string false "Text to search in the condition and the generated text"
+// @Param cursor query string false "Cursor returned as page.next_cursor by the previous page"
+// @Param limit query int false "Page size (default is 20, max is 100)"
+// @Param include_total query bool false "Count all matching generations and return page.total"
+// @Param sort query string false "Sort order (default is newest first)" Enums(created_at, -created_at)
+// @Param created_after query string false "Only generations created after this time (RFC3339)"
+// @Param created_before query string false "Only generations created before this time (RFC3339)"
+// @Param condition_contains query string false "Only generations whose condition contains this text"
+// @Param text_contains query string false "Only generations whose generated text contains this text"
+// @Success 200 {object} responses.GetAllGenerationHistoryDTO "History successfully retrieved"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or query"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/history/all [get]
+func GetAllGenerationHistory(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		page, err := parsePage(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid page",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.GetAllGenerationHistory{
+			Search: c.Query("q"),
+			Page:   page,
+		}
+		if types := c.Query("type"); types != "" {
+			data.Types = strings.Split(types, ",")
+		}
+		for param, target := range map[string]**time.Time{"from": &data.From, "to": &data.To} {
+			if value := c.Query(param); value != "" {
+				parsed, err := time.Parse(time.RFC3339, value)
+				if err != nil {
+					return c.Status(400).JSON(responses.ErrorResponse{
+						Status: "invalid " + param,
+						Error:  err.Error(),
+					})
+				}
+				*target = &parsed
+			}
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		list, err := listQuery.Parse(c.Queries(), historyListSpec)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid query",
+				Error:  err.Error(),
+			})
+		}
+
+		query := list.Apply(db.Model(&dbmodels.Generation{}).Where("user_id = ?", userID))
+		if len(data.Types) > 0 {
+			query = query.Where("type IN ?", data.Types)
+		}
+		if data.From != nil {
+			query = query.Where("created_at >= ?", *data.From)
+		}
+		if data.To != nil {
+			query = query.Where("created_at <= ?", *data.To)
+		}
+		if data.Search != "" {
+			pattern := "%" + listQuery.EscapeLike(data.Search) + "%"
+			query = query.Where("(condition ILIKE ? OR result ILIKE ?)", pattern, pattern)
+		}
+
+		total, err := countPage(query, data.Page)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		order := sortedPageOrder(list.Sort, pageOrder{Field: "created_at", TimeColumn: "created_at", IDColumn: "id", Desc: true})
+		query, err = paginate(query, order, data.Page)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid cursor",
+				Error:  err.Error(),
+			})
+		}
+
+		var generations []dbmodels.Generation
+		if err := query.Find(&generations).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+
+		generations, pageDTO, err := finishPage(generations, data.Page, total, func(generation dbmodels.Generation) pagePosition {
+			return order.recordPosition(generation.ID, "", generation.CreatedAt, generation.UpdatedAt)
+		})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to encode cursor",
+				Error:  err.Error(),
+			})
+		}
+
+		// next_cursor на верхнем уровне оставлен для клиентов, написанных до появления page
+		response := responses.GetAllGenerationHistoryDTO{
+			History:    make([]responses.GenerationHistoryDTO, 0, len(generations)),
+			NextCursor: pageDTO.NextCursor,
+			Page:       pageDTO,
+		}
+		for _, generation := range generations {
+			dto, err := generationToDTO(generation)
+			if err != nil {
+				return c.Status(500).JSON(responses.ErrorResponse{
+					Status: "failed to decode interests",
+					Error:  err.Error(),
+				})
+			}
+			response.History = append(response.History, dto)
+		}
+
+		return c.Status(200).JSON(response)
+	}
+}
+
+// GetGenerationHistory retrieves a single generation from the history
+// @Summary Retrieve a generation
+// @Description Fetches a generation by its ID. With legacy_type the ID is looked up among the IDs the generation had before the history tables were merged
+// @Tags History
+// @Produce json
+// @Param id path int true "Generation ID"
+// @Param legacy_type query string false "Type of the legacy history record (interests, nointerests, answer)"
+// @Success 200 {object} responses.GetGenerationHistoryDTO "Generation successfully retrieved"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or ID"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "Generation not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/history/get/{id} [get]
+func GetGenerationHistory(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		generationID, err := strconv.Atoi(c.Params("id"))
+		if err != nil || generationID <= 0 {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid generation ID",
+				Error:  "generation ID must be a positive integer",
+			})
+		}
+
+		data := requests.GetGenerationHistory{ID: uint(generationID), LegacyType: c.Query("legacy_type")}
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		var generation dbmodels.Generation
+		query := db.Where("id = ?", data.ID)
+		if data.LegacyType != "" {
+			query = db.Where("type = ? AND legacy_id = ?", data.LegacyType, data.ID)
+		}
+		result := query.First(&generation)
+		if result.Error == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "generation not found",
+			})
+		} else if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		if generation.UserID != userID {
+			return c.Status(403).JSON(responses.ErrorResponse{
+				Status: "forbidden",
+				Error:  "you are not the author of this generation",
+			})
+		}
+
+		dto, err := generationToDTO(generation)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to decode interests",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.GetGenerationHistoryDTO{
+			History: dto,
+		})
+	}
+}
+
+// DeleteGenerationHistory deletes a generation from the history
+// @Summary Delete a generation
+// @Description Deletes a generation by its ID
+// @Tags History
+// @Accept json
+// @Produce json
+// @Param input body requests.DeleteGenerationHistory true "Generation deletion data"
+// @Success 200 {object} responses.DeleteGenerationHistoryDTO "Generation successfully deleted"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "Generation not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/history/delete [delete]
+func DeleteGenerationHistory(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.DeleteGenerationHistory{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		var generation dbmodels.Generation
+		result := db.First(&generation, data.ID)
+		if result.Error == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "generation not found",
+			})
+		} else if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		if generation.UserID != userID {
+			return c.Status(403).JSON(responses.ErrorResponse{
+				Status: "forbidden",
+				Error:  "you are not the author of this generation",
+			})
+		}
+
+		deleteResult := db.Delete(&generation)
+		if deleteResult.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to delete generation",
+				Error:  deleteResult.Error.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.DeleteGenerationHistoryDTO{
+			Status: "generation deleted",
+		})
+	}
+}
+
+// PromoteGeneration saves a generated variant as a new task
+// @Summary Save a generation as a task
+// @Description Creates a task from a generated variant. The answer is taken from the request, then from the task the variant was generated from, then from the latest answer generated for the variant. With classify the new task is classified by the language model, which with an access token also needs the generate scope
+// @Tags History
+// @Accept json
+// @Produce json
+// @Param input body requests.PromoteGeneration true "Generation to save"
+// @Success 200 {object} responses.CreateTaskResponseDTO "Task successfully created"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "Generation not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error or unknown answer"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/history/promote [post]
+func PromoteGeneration(db *gorm.DB, tg *taskGenerator.TaskGenerator) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.PromoteGeneration{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		// Классификация обращается к языковой модели, как и /task/classify
+		if data.Classify {
+			if err := checkGenerateScope(c); err != nil {
+				return c.Status(403).JSON(responses.ErrorResponse{
+					Status: "insufficient scope",
+					Error:  err.Error(),
+				})
+			}
+		}
+
+		var generation dbmodels.Generation
+		result := db.Preload("Task").First(&generation, data.ID)
+		if result.Error == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "generation not found",
+			})
+		} else if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		if generation.UserID != userID {
+			return c.Status(403).JSON(responses.ErrorResponse{
+				Status: "forbidden",
+				Error:  "you are not the author of this generation",
+			})
+		}
+
+		if generation.Type == dbmodels.GenerationTypeAnswer || generation.Type == dbmodels.GenerationTypeClassification {
+			return c.Status(422).JSON(responses.ErrorResponse{
+				Status: "invalid generation type",
+				Error:  generation.Type + " generations cannot be saved as tasks",
+			})
+		}
+		if utf8.RuneCountInString(generation.Result) > 2000 {
+			return c.Status(422).JSON(responses.ErrorResponse{
+				Status: "generation too long",
+				Error:  "generated text is longer than 2000 characters",
+			})
+		}
+
+		// Ответ: из запроса, из исходной задачи или из разбора, сделанного для этого варианта
+		answer := data.Answer
+		if answer == "" && generation.Task != nil {
+			answer = generation.Task.Answer
+		}
+		if answer == "" {
+			var answerGeneration dbmodels.Generation
+			result := db.Where("parent_generation_id = ? AND type = ? AND user_id = ?",
+				generation.ID, dbmodels.GenerationTypeAnswer, userID).
+				Order("created_at DESC").
+				Take(&answerGeneration)
+			if result.Error != nil && result.Error != gorm.ErrRecordNotFound {
+				return c.Status(500).JSON(responses.ErrorResponse{
+					Status: "internal server error",
+					Error:  result.Error.Error(),
+				})
+			}
+			answer = truncateRunes(answerGeneration.Result, 100)
+		}
+		if answer == "" {
+			return c.Status(422).JSON(responses.ErrorResponse{
+				Status: "answer unknown",
+				Error:  "generation is not linked to a task or an answer, pass the answer explicitly",
+			})
+		}
+
+		title := data.Title
+		if title == "" && generation.Task != nil {
+			title = generation.Task.Title
+		}
+		if title == "" {
+			title = fmt.Sprintf("Generated task %d", generation.ID)
+		}
+
+		parameters, err := json.Marshal(map[string]interface{}{
+			"type":                  generation.Type,
+			"model":                 generation.AIModel,
+			"prompt_version":        generation.PromptVersion,
+			"parameters":            generation.Parameters,
+			"interests":             generation.Interests,
+			"task_id":               generation.TaskID,
+			"condition_template_id": generation.ConditionTemplateID,
+			"interests_template_id": generation.InterestsTemplateID,
+		})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to process generation parameters",
+				Error:  err.Error(),
+			})
+		}
+
+		task := dbmodels.Task{
+			AuthorID:             userID,
+			OrganizationID:       activeOrganizationID(c),
+			Title:                title,
+			Condition:            generation.Result,
+			Answer:               answer,
+			SourceGenerationID:   &generation.ID,
+			GenerationParameters: parameters,
+			CreatedAt:            time.Now(),
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&task).Error; err != nil {
+				return err
+			}
+			revision := taskRevision(task, userID)
+			return recordRevision(tx, &revision)
+		})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to create task",
+				Error:  err.Error(),
+			})
+		}
+
+		var classificationError string
+		if data.Classify {
+			if _, err := classifyTask(c, db, tg, userID, &task); err != nil {
+				classificationError = err.Error()
+			}
+		}
+
+		return c.Status(200).JSON(responses.CreateTaskResponseDTO{
+			Status: "task created",
+			Task: responses.TaskDTO{
+				ID:                 task.ID,
+				AuthorID:           task.AuthorID,
+				Title:              task.Title,
+				Condition:          task.Condition,
+				Answer:             task.Answer,
+				SourceGenerationID: task.SourceGenerationID,
+				Classification:     taskClassificationToDTO(task),
+			},
+			ClassificationError: classificationError,
+		})
+	}
+}
+
+// truncateRunes обрезает строку до limit символов
+func truncateRunes(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit])
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"gera-ai/internal/utils/jwtUtils"
+	"gera-ai/internal/utils/taskGenerator"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func TestClassifyNeedsGenerateScope(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(db *gorm.DB, tg *taskGenerator.TaskGenerator) fiber.Handler
+		body    string
+	}{
+		{"promote", PromoteGeneration, `{"id": 1, "classify": true}`},
+		{"create", CreateTask, `{"title": "Дроби", "condition": "1/2 + 1/4", "answer": "3/4", "classify": true}`},
+		{"create v2", CreateTaskV2, `{"title": "Дроби", "condition": "1/2 + 1/4", "answer": "3/4", "classify": true}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := newMockDB(t)
+
+			app := fiber.New()
+			app.Post("/", func(c *fiber.Ctx) error {
+				jwtUtils.SetAccessToken(c, 5, "teacher", 9, "history:read tasks:write")
+				return c.Next()
+			}, tt.handler(db, nil))
+
+			// Без classify токену хватает областей маршрута, с classify нужен еще generate.
+			// Запрос отклоняется до обращения к базе: ни одного ожидания у мока нет
+			request := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			response, err := app.Test(request, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if response.StatusCode != 403 {
+				t.Fatalf("%s = %d, want 403", tt.name, response.StatusCode)
+			}
+		})
+	}
+}
+
+func TestHistorySearchMatchesLiterally(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	app := fiber.New()
+	app.Get("/history/all", func(c *fiber.Ctx) error {
+		jwtUtils.SetAccessToken(c, 5, "teacher", 0, "")
+		return c.Next()
+	}, GetAllGenerationHistory(db))
+
+	// % и _ в поиске - обычные символы, а не шаблоны LIKE
+	pattern := `%100\%\_%`
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "generations" WHERE user_id = $1 AND ((condition ILIKE $2 OR result ILIKE $3))`)).
+		WithArgs(5, pattern, pattern, 21).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	response, err := app.Test(httptest.NewRequest("GET", "/history/all?q="+url.QueryEscape("100%_"), nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != 200 {
+		t.Fatalf("search = %d, want 200", response.StatusCode)
+	}
+}
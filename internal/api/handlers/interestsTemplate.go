@@ -7,6 +7,7 @@ import (
 	"gera-ai/internal/models/responses"
 	"gera-ai/internal/utils/jsonUtils"
 	"gera-ai/internal/utils/jwtUtils"
+	"gera-ai/internal/utils/listQuery"
 	"gera-ai/internal/utils/validator"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -14,6 +15,38 @@ import (
 	"time"
 )
 
+// createInterestsTemplate сохраняет шаблон интересов
+func createInterestsTemplate(db *gorm.DB, authorID uint, organizationID *uint, data requests.CreateInterestsTemplate) (dbmodels.InterestsTemplate, error) {
+	interestsJSON, err := json.Marshal(data.Interests)
+	if err != nil {
+		return dbmodels.InterestsTemplate{}, err
+	}
+	taskTemplate := dbmodels.InterestsTemplate{
+		AuthorID:       authorID,
+		OrganizationID: organizationID,
+		Title:          data.Title,
+		Interests:      interestsJSON,
+		CreatedAt:      time.Now(),
+	}
+	return taskTemplate, db.Create(&taskTemplate).Error
+}
+
+// updateInterestsTemplate меняет переданные поля шаблона интересов
+func updateInterestsTemplate(db *gorm.DB, taskTemplate *dbmodels.InterestsTemplate, data requests.PatchInterestsTemplate) error {
+	if data.Title != nil {
+		taskTemplate.Title = *data.Title
+	}
+	if data.Interests != nil {
+		interestsJSON, err := json.Marshal(*data.Interests)
+		if err != nil {
+			return err
+		}
+		taskTemplate.Interests = interestsJSON
+	}
+	taskTemplate.UpdatedAt = time.Now()
+	return db.Save(taskTemplate).Error
+}
+
 // CreateInterestsTemplate creates a new interests template
 // @Summary Create Interests Template
 // @Description Creates a new template based on the provided title and list of interests.
@@ -52,26 +85,11 @@ func CreateInterestsTemplate(db *gorm.DB) fiber.Handler {
 			})
 		}
 
-		interestsJSON, err := json.Marshal(data.Interests)
+		taskTemplate, err := createInterestsTemplate(db, authorID, activeOrganizationID(c), data)
 		if err != nil {
-			return c.Status(500).JSON(responses.ErrorResponse{
-				Status: "failed to serialize interests",
-				Error:  err.Error(),
-			})
-		}
-
-		taskTemplate := dbmodels.InterestsTemplate{
-			AuthorID:  authorID,
-			Title:     data.Title,
-			Interests: interestsJSON,
-			CreatedAt: time.Now(),
-		}
-
-		result := db.Create(&taskTemplate)
-		if result.Error != nil {
 			return c.Status(500).JSON(responses.ErrorResponse{
 				Status: "failed to create task template",
-				Error:  result.Error.Error(),
+				Error:  err.Error(),
 			})
 		}
 
@@ -106,6 +124,7 @@ func CreateInterestsTemplate(db *gorm.DB) fiber.Handler {
 // @Failure 404 {object} responses.ErrorResponse "Template not found"
 // @Failure 500 {object} responses.ErrorResponse "Internal server error"
 // @Router /api/template/interests/get/{id} [get]
+// @Router /api/v2/interests-templates/{id} [get]
 func GetInterestsTemplate(db *gorm.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authorID, err := jwtUtils.ExtractUserID(c)
@@ -125,7 +144,7 @@ func GetInterestsTemplate(db *gorm.DB) fiber.Handler {
 		}
 
 		var taskTemplate dbmodels.InterestsTemplate
-		result := db.First(&taskTemplate, taskTemplateID)
+		result := preloadClassification(db).First(&taskTemplate, taskTemplateID)
 		if result.Error == gorm.ErrRecordNotFound {
 			return c.Status(404).JSON(responses.ErrorResponse{
 				Status: "task template not found",
@@ -137,10 +156,18 @@ func GetInterestsTemplate(db *gorm.DB) fiber.Handler {
 			})
 		}
 
-		if taskTemplate.AuthorID != authorID {
+		// Доступ есть у автора и у пользователей, с которыми поделились
+		access, err := resourceAccess(db, authorID, "interests_template", taskTemplate.ID, taskTemplate.AuthorID, taskTemplate.OrganizationID)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		if access < accessViewer {
 			return c.Status(403).JSON(responses.ErrorResponse{
 				Status: "forbidden",
-				Error:  "you are not the author of this task template",
+				Error:  "you do not have access to this task template",
 			})
 		}
 
@@ -154,9 +181,10 @@ func GetInterestsTemplate(db *gorm.DB) fiber.Handler {
 
 		return c.Status(200).JSON(responses.GetInterestsTemplateDTO{
 			TaskTemplate: responses.InterestsTemplateDTO{
-				ID:        taskTemplate.ID,
-				Title:     taskTemplate.Title,
-				Interests: interests,
+				ID:                taskTemplate.ID,
+				Title:             taskTemplate.Title,
+				Interests:         interests,
+				ClassificationDTO: classificationToDTO(taskTemplate.Tags, taskTemplate.Subjects, taskTemplate.Grades),
 			},
 		})
 	}
@@ -215,31 +243,29 @@ func EditInterestsTemplate(db *gorm.DB) fiber.Handler {
 			})
 		}
 
-		if taskTemplate.AuthorID != authorID {
+		// Редактировать могут автор и пользователи с ролью editor
+		access, err := resourceAccess(db, authorID, "interests_template", taskTemplate.ID, taskTemplate.AuthorID, taskTemplate.OrganizationID)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		if access < accessEditor {
 			return c.Status(403).JSON(responses.ErrorResponse{
 				Status: "forbidden",
-				Error:  "you are not the author of this task template",
+				Error:  "you cannot edit this task template",
 			})
 		}
 
-		taskTemplate.Title = data.Title
+		patch := requests.PatchInterestsTemplate{Title: &data.Title}
 		if data.Interests != nil {
-			interestsJSON, err := json.Marshal(data.Interests)
-			if err != nil {
-				return c.Status(500).JSON(responses.ErrorResponse{
-					Status: "failed to process interests",
-					Error:  err.Error(),
-				})
-			}
-			taskTemplate.Interests = interestsJSON
+			patch.Interests = &data.Interests
 		}
-		taskTemplate.UpdatedAt = time.Now()
-
-		saveResult := db.Save(&taskTemplate)
-		if saveResult.Error != nil {
+		if err := updateInterestsTemplate(db, &taskTemplate, patch); err != nil {
 			return c.Status(500).JSON(responses.ErrorResponse{
 				Status: "failed to update task template",
-				Error:  saveResult.Error.Error(),
+				Error:  err.Error(),
 			})
 		}
 
@@ -315,10 +341,18 @@ func DeleteInterestsTemplate(db *gorm.DB) fiber.Handler {
 			})
 		}
 
-		if taskTemplate.AuthorID != authorID {
+		// Удалять могут автор и пользователи с ролью editor, удаленное попадает в корзину автора
+		access, err := resourceAccess(db, authorID, "interests_template", taskTemplate.ID, taskTemplate.AuthorID, taskTemplate.OrganizationID)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		if access < accessEditor {
 			return c.Status(403).JSON(responses.ErrorResponse{
 				Status: "forbidden",
-				Error:  "you are not the author of this task template",
+				Error:  "you cannot delete this task template",
 			})
 		}
 
@@ -336,19 +370,46 @@ func DeleteInterestsTemplate(db *gorm.DB) fiber.Handler {
 	}
 }
 
+// interestsTemplateListSpec - поля списка шаблонов интересов, по которым можно сортировать и фильтровать
+var interestsTemplateListSpec = listQuery.Spec{
+	Sort: map[string]listQuery.Field{
+		"title":      {Column: "title", Kind: listQuery.Text},
+		"created_at": {Column: "created_at", Kind: listQuery.Time},
+		"updated_at": {Column: "updated_at", Kind: listQuery.Time},
+	},
+	Filters: map[string]listQuery.Field{
+		"title":   {Column: "title", Kind: listQuery.Text},
+		"created": {Column: "created_at", Kind: listQuery.Time},
+		"updated": {Column: "updated_at", Kind: listQuery.Time},
+	},
+}
+
 // GetAllInterestsTemplates retrieves all interest templates by user ID.
 // @Summary Get all interest templates by user ID
-// @Description Retrieves all interest templates for a specific user, with pagination support.
+// @Description Retrieves interest templates of the current user or the active organization, newest first by default, with sorting, field filters and cursor pagination.
 // @Tags InterestsTemplates
 // @Accept json
 // @Produce json
-// @Param offset query int false "The page offset for pagination. Default is 0." minimum(0)
+// @Param cursor query string false "Cursor returned as page.next_cursor by the previous page"
+// @Param limit query int false "Page size (default is 20, max is 100)"
+// @Param include_total query bool false "Count all matching templates and return page.total"
+// @Param sort query string false "Sort field, prefix with - for descending order (default is newest first)" Enums(title, -title, created_at, -created_at, updated_at, -updated_at)
+// @Param created_after query string false "Only templates created after this time (RFC3339)"
+// @Param created_before query string false "Only templates created before this time (RFC3339)"
+// @Param updated_after query string false "Only templates updated after this time (RFC3339)"
+// @Param updated_before query string false "Only templates updated before this time (RFC3339)"
+// @Param title_contains query string false "Only templates whose title contains this text"
+// @Param offset query int false "Deprecated: page number, used only without a cursor"
+// @Param tag query string false "Comma separated tags, all of them must match"
+// @Param subject query string false "Comma separated subject codes, any of them must match"
+// @Param grade query string false "Comma separated grades, any of them must match"
 // @Param Authorization header string true "Bearer token for authentication"
 // @Success 200 {object} responses.GetAllInterestsTemplatesDTO "Successfully retrieved interest templates"
-// @Failure 400 {object} responses.ErrorResponse "Invalid token or offset"
-// @Failure 404 {object} responses.ErrorResponse "No task templates found"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token, cursor or filter"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
 // @Failure 500 {object} responses.ErrorResponse "Internal server error"
 // @Router /api/template/interests/all [get]
+// @Router /api/v2/interests-templates [get]
 func GetAllInterestsTemplates(db *gorm.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authorID, err := jwtUtils.ExtractUserID(c)
@@ -359,35 +420,79 @@ func GetAllInterestsTemplates(db *gorm.DB) fiber.Handler {
 			})
 		}
 
-		offset, err := strconv.Atoi(c.Query("offset", "0"))
-		if err != nil || offset < 0 {
+		page, err := parsePage(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid page",
+				Error:  err.Error(),
+			})
+		}
+		if validationErrors := validator.ValidateStruct(page); validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		filter, err := parseClassificationFilter(c)
+		if err != nil {
 			return c.Status(400).JSON(responses.ErrorResponse{
-				Status: "invalid offset",
-				Error:  "offset must be a non-negative integer",
+				Status: "invalid filter",
+				Error:  err.Error(),
+			})
+		}
+		if validationErrors := validator.ValidateStruct(filter); validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		list, err := listQuery.Parse(c.Queries(), interestsTemplateListSpec)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid query",
+				Error:  err.Error(),
+			})
+		}
+
+		query := applyClassificationFilter(scopeToWorkspace(c, db.Model(&dbmodels.InterestsTemplate{}), authorID), "interests_template", filter)
+		query = list.Apply(query)
+		total, err := countPage(query, page)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		order := sortedPageOrder(list.Sort, pageOrder{IDColumn: "id", Desc: true})
+		query, err = paginate(query, order, page)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid cursor",
+				Error:  err.Error(),
 			})
 		}
 
-		limit := 10
 		var templates []dbmodels.InterestsTemplate
-		result := db.Where("author_id = ?", authorID).
-			Order("id DESC").
-			Offset(offset * limit).
-			Limit(limit).
-			Find(&templates)
-
-		if result.Error != nil {
-			if result.Error == gorm.ErrRecordNotFound {
-				return c.Status(404).JSON(responses.ErrorResponse{
-					Status: "no task templates found",
-				})
-			}
+		if err := preloadClassification(query).Find(&templates).Error; err != nil {
 			return c.Status(500).JSON(responses.ErrorResponse{
 				Status: "internal server error",
-				Error:  result.Error.Error(),
+				Error:  err.Error(),
+			})
+		}
+
+		templates, pageDTO, err := finishPage(templates, page, total, func(template dbmodels.InterestsTemplate) pagePosition {
+			return order.recordPosition(template.ID, template.Title, template.CreatedAt, template.UpdatedAt)
+		})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to encode cursor",
+				Error:  err.Error(),
 			})
 		}
 
-		var taskTemplates []responses.InterestsTemplateDTO
+		taskTemplates := make([]responses.InterestsTemplateDTO, 0, len(templates))
 		for _, template := range templates {
 			interests, err := jsonUtils.ConvertInterestsToList(template.Interests)
 			if err != nil {
@@ -397,14 +502,16 @@ func GetAllInterestsTemplates(db *gorm.DB) fiber.Handler {
 				})
 			}
 			taskTemplates = append(taskTemplates, responses.InterestsTemplateDTO{
-				ID:        template.ID,
-				Title:     template.Title,
-				Interests: interests,
+				ID:                template.ID,
+				Title:             template.Title,
+				Interests:         interests,
+				ClassificationDTO: classificationToDTO(template.Tags, template.Subjects, template.Grades),
 			})
 		}
 
 		return c.Status(200).JSON(responses.GetAllInterestsTemplatesDTO{
 			TaskTemplates: taskTemplates,
+			Page:          pageDTO,
 		})
 	}
 }
@@ -0,0 +1,866 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	dbmodels "gera-ai/internal/models/database"
+	"gera-ai/internal/models/requests"
+	"gera-ai/internal/models/responses"
+	"gera-ai/internal/utils/jwtUtils"
+	"gera-ai/internal/utils/listQuery"
+	"gera-ai/internal/utils/rbac"
+	"gera-ai/internal/utils/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// licenseNames - названия лицензий для строки авторства
+var licenseNames = map[string]string{
+	dbmodels.LicenseCCBY:   "CC BY 4.0",
+	dbmodels.LicenseCCBYSA: "CC BY-SA 4.0",
+	dbmodels.LicenseCCBYNC: "CC BY-NC 4.0",
+	dbmodels.LicenseCC0:    "CC0 1.0",
+}
+
+func publicationToDTO(publication dbmodels.Publication) responses.PublicationDTO {
+	classification := classificationToDTO(nil, publication.Subjects, publication.Grades)
+	return responses.PublicationDTO{
+		ID:           publication.ID,
+		ResourceType: publication.ResourceType,
+		AuthorID:     publication.AuthorID,
+		AuthorName:   publication.Author.Username,
+		Title:        publication.Title,
+		Condition:    publication.Condition,
+		Answer:       publication.Answer,
+		Description:  publication.Description,
+		License:      publication.License,
+		Subjects:     classification.Subjects,
+		Grades:       classification.Grades,
+		Status:       publication.Status,
+		CloneCount:   publication.CloneCount,
+		PublishedAt:  publication.CreatedAt,
+	}
+}
+
+// preloadPublication подгружает автора, предметы и классы публикации
+func preloadPublication(query *gorm.DB) *gorm.DB {
+	return query.Preload("Author").Preload("Subjects").Preload("Grades")
+}
+
+// PublishToLibrary publishes a task or condition template to the public library
+// @Summary Publish to the library
+// @Description Publishes a copy of a task or condition template with a license and a description. Later edits of the original do not change the publication
+// @Tags Library
+// @Accept json
+// @Produce json
+// @Param input body requests.PublishToLibrary true "Publication data"
+// @Success 200 {object} responses.PublishToLibraryDTO "Successfully published"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "Resource not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/library/publish [post]
+func PublishToLibrary(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.PublishToLibrary{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		if status, err := checkResourceOwner(db, userID, data.ResourceType, data.ID); err != nil {
+			return c.Status(status).JSON(responses.ErrorResponse{
+				Status: "resource unavailable",
+				Error:  err.Error(),
+			})
+		}
+
+		publication := dbmodels.Publication{
+			AuthorID:     userID,
+			ResourceType: data.ResourceType,
+			SourceID:     data.ID,
+			Description:  data.Description,
+			License:      data.License,
+			Status:       dbmodels.PublicationStatusPublished,
+			CreatedAt:    time.Now(),
+		}
+
+		switch data.ResourceType {
+		case dbmodels.RevisionResourceTask:
+			var task dbmodels.Task
+			err = db.Preload("Subjects").Preload("Grades").First(&task, data.ID).Error
+			publication.Title, publication.Condition, publication.Answer = task.Title, task.Condition, task.Answer
+			publication.Subjects, publication.Grades = task.Subjects, task.Grades
+		case dbmodels.RevisionResourceConditionTemplate:
+			var template dbmodels.ConditionTemplate
+			err = db.Preload("Subjects").Preload("Grades").First(&template, data.ID).Error
+			publication.Title, publication.Condition = template.Title, template.Condition
+			publication.Subjects, publication.Grades = template.Subjects, template.Grades
+		}
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+
+		// Справочники уже существуют, создаются только связи
+		if err := db.Omit("Subjects.*", "Grades.*").Create(&publication).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to publish",
+				Error:  err.Error(),
+			})
+		}
+
+		if err := preloadPublication(db).First(&publication, publication.ID).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.PublishToLibraryDTO{
+			Status:      "published",
+			Publication: publicationToDTO(publication),
+		})
+	}
+}
+
+// WithdrawPublication removes the user's own publication from the library
+// @Summary Withdraw a publication
+// @Description The author removes their publication from the library. Copies already cloned by others are kept
+// @Tags Library
+// @Accept json
+// @Produce json
+// @Param input body requests.WithdrawPublication true "Publication to withdraw"
+// @Success 200 {object} responses.WithdrawPublicationDTO "Publication withdrawn"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "Publication not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/library/withdraw [put]
+func WithdrawPublication(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.WithdrawPublication{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		var publication dbmodels.Publication
+		result := db.First(&publication, data.ID)
+		if result.Error == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "publication not found",
+			})
+		} else if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		if publication.AuthorID != userID {
+			return c.Status(403).JSON(responses.ErrorResponse{
+				Status: "forbidden",
+				Error:  "you are not the author of this publication",
+			})
+		}
+
+		if err := db.Model(&publication).Update("status", dbmodels.PublicationStatusWithdrawn).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to withdraw publication",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.WithdrawPublicationDTO{
+			Status: "publication withdrawn",
+		})
+	}
+}
+
+// libraryListSpec - поля библиотеки, по которым можно сортировать и фильтровать
+var libraryListSpec = listQuery.Spec{
+	Sort: map[string]listQuery.Field{
+		"title":       {Column: "publications.title", Kind: listQuery.Text},
+		"clone_count": {Column: "publications.clone_count", Kind: listQuery.Number},
+		"created_at":  {Column: "publications.created_at", Kind: listQuery.Time},
+	},
+	Filters: map[string]listQuery.Field{
+		"title":       {Column: "publications.title", Kind: listQuery.Text},
+		"description": {Column: "publications.description", Kind: listQuery.Text},
+		"created":     {Column: "publications.created_at", Kind: listQuery.Time},
+	},
+	Equal: map[string]listQuery.Field{
+		"author_id": {Column: "publications.author_id", Kind: listQuery.Number},
+	},
+}
+
+// BrowseLibrary lists published tasks and condition templates
+// @Summary Browse the library
+// @Description Lists published items filtered by subject, grade and fields, most cloned first by default
+// @Tags Library
+// @Produce json
+// @Param type query string false "Comma separated resource types" Enums(task, condition_template)
+// @Param subject query string false "Comma separated subject codes, any of them must match"
+// @Param grade query string false "Comma separated grades, any of them must match"
+// @Param sort query string false "Sort field, prefix with - for descending order. popular (the default) and new are kept for old clients" Enums(popular, new, title, -title, clone_count, -clone_count, created_at, -created_at)
+// @Param created_after query string false "Only items published after this time (RFC3339)"
+// @Param created_before query string false "Only items published before this time (RFC3339)"
+// @Param title_contains query string false "Only items whose title contains this text"
+// @Param title_prefix query string false "Only items whose title starts with this text"
+// @Param description_contains query string false "Only items whose description contains this text"
+// @Param author_id query int false "Only items of this author"
+// @Param cursor query string false "Cursor returned as page.next_cursor by the previous page with the same sort"
+// @Param limit query int false "Page size (default is 20, max is 100)"
+// @Param include_total query bool false "Count all matching publications and return page.total"
+// @Param offset query int false "Deprecated: page number, used only without a cursor"
+// @Success 200 {object} responses.BrowseLibraryDTO "Publications successfully retrieved"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token, cursor or filter"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/library/all [get]
+func BrowseLibrary(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if _, err := jwtUtils.ExtractUserID(c); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		filter, err := parseClassificationFilter(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid filter",
+				Error:  err.Error(),
+			})
+		}
+		// Метки личные, в библиотеку не копируются
+		filter.Tags = nil
+
+		page, err := parsePage(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid page",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.BrowseLibrary{
+			Filter: filter,
+			Page:   page,
+		}
+		if types := c.Query("type"); types != "" {
+			data.Types = uniqueStrings(strings.Split(types, ","))
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		// sort=popular и sort=new остались от прежнего API: popular - порядок по умолчанию
+		params := c.Queries()
+		switch params["sort"] {
+		case "popular":
+			delete(params, "sort")
+		case "new":
+			params["sort"] = "-created_at"
+		}
+		list, err := listQuery.Parse(params, libraryListSpec)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid query",
+				Error:  err.Error(),
+			})
+		}
+
+		query := db.Model(&dbmodels.Publication{}).Where("publications.status = ?", dbmodels.PublicationStatusPublished)
+		if len(data.Types) > 0 {
+			query = query.Where("publications.resource_type IN ?", data.Types)
+		}
+		query = list.Apply(applyClassificationFilter(query, "publication", data.Filter))
+
+		total, err := countPage(query, data.Page)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		// Курсор хранит сортировку, поэтому курсор одной сортировки не подходит к другой
+		order := sortedPageOrder(list.Sort, pageOrder{
+			CountColumn: "publications.clone_count",
+			TimeColumn:  "publications.created_at",
+			IDColumn:    "publications.id",
+			Desc:        true,
+		})
+		query, err = paginate(query, order, data.Page)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid cursor",
+				Error:  err.Error(),
+			})
+		}
+
+		var publications []dbmodels.Publication
+		if err := preloadPublication(query).Find(&publications).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+
+		publications, pageDTO, err := finishPage(publications, data.Page, total, func(publication dbmodels.Publication) pagePosition {
+			position := order.recordPosition(publication.ID, publication.Title, publication.CreatedAt, publication.UpdatedAt)
+			if order.CountColumn != "" {
+				position.Count = &publication.CloneCount
+			}
+			if order.Field == "" {
+				// По умолчанию при равном числе клонов сначала идут новые
+				position.Time = &publication.CreatedAt
+			}
+			return position
+		})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to encode cursor",
+				Error:  err.Error(),
+			})
+		}
+
+		publicationsDTO := make([]responses.PublicationDTO, len(publications))
+		for i, publication := range publications {
+			publicationsDTO[i] = publicationToDTO(publication)
+		}
+
+		return c.Status(200).JSON(responses.BrowseLibraryDTO{
+			Publications: publicationsDTO,
+			Page:         pageDTO,
+		})
+	}
+}
+
+// GetPublication retrieves a library item by ID
+// @Summary Get a publication
+// @Description Returns a published item. Withdrawn and unpublished items are visible only to their author and moderators
+// @Tags Library
+// @Produce json
+// @Param id path int true "Publication ID"
+// @Success 200 {object} responses.GetPublicationDTO "Publication successfully retrieved"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or ID"
+// @Failure 404 {object} responses.ErrorResponse "Publication not found"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/library/get/{id} [get]
+func GetPublication(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		publicationID, err := strconv.Atoi(c.Params("id"))
+		if err != nil || publicationID <= 0 {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid publication ID",
+				Error:  "publication ID must be a positive integer",
+			})
+		}
+
+		data := requests.GetPublication{ID: uint(publicationID)}
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		var publication dbmodels.Publication
+		result := preloadPublication(db).First(&publication, data.ID)
+		if result.Error == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "publication not found",
+			})
+		} else if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		// Снятые публикации не раскрываем посторонним
+		if publication.Status != dbmodels.PublicationStatusPublished && publication.AuthorID != userID {
+			role, err := jwtUtils.ExtractRole(c)
+			if err != nil {
+				return c.Status(400).JSON(responses.ErrorResponse{
+					Status: "invalid token",
+					Error:  err.Error(),
+				})
+			}
+			if !rbac.Has(role, rbac.PermissionModerateLibrary) {
+				return c.Status(404).JSON(responses.ErrorResponse{
+					Status: "publication not found",
+				})
+			}
+		}
+
+		return c.Status(200).JSON(responses.GetPublicationDTO{
+			Publication: publicationToDTO(publication),
+		})
+	}
+}
+
+// ClonePublication copies a library item into the user's account
+// @Summary Clone a publication
+// @Description Copies a published task or condition template into the current account with attribution to the original author and license
+// @Tags Library
+// @Accept json
+// @Produce json
+// @Param input body requests.ClonePublication true "Publication to clone"
+// @Success 200 {object} responses.ClonePublicationDTO "Publication cloned"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 404 {object} responses.ErrorResponse "Publication not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/library/clone [post]
+func ClonePublication(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.ClonePublication{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		var publication dbmodels.Publication
+		result := preloadPublication(db).
+			Where("status = ?", dbmodels.PublicationStatusPublished).
+			First(&publication, data.ID)
+		if result.Error == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "publication not found",
+			})
+		} else if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		authorName := publication.Author.Username
+		if authorName == "" {
+			authorName = publication.Author.Login
+		}
+		attribution := fmt.Sprintf("«%s», автор: %s, лицензия %s", publication.Title, authorName, licenseNames[publication.License])
+
+		var clonedID uint
+		err = db.Transaction(func(tx *gorm.DB) error {
+			var revision dbmodels.Revision
+			switch publication.ResourceType {
+			case dbmodels.RevisionResourceTask:
+				task := dbmodels.Task{
+					AuthorID:        userID,
+					OrganizationID:  activeOrganizationID(c),
+					Title:           publication.Title,
+					Condition:       publication.Condition,
+					Answer:          publication.Answer,
+					LibrarySourceID: &publication.ID,
+					Attribution:     attribution,
+					Subjects:        publication.Subjects,
+					Grades:          publication.Grades,
+					CreatedAt:       time.Now(),
+				}
+				if err := tx.Omit("Subjects.*", "Grades.*").Create(&task).Error; err != nil {
+					return err
+				}
+				clonedID, revision = task.ID, taskRevision(task, userID)
+			case dbmodels.RevisionResourceConditionTemplate:
+				template := dbmodels.ConditionTemplate{
+					AuthorID:        userID,
+					OrganizationID:  activeOrganizationID(c),
+					Title:           publication.Title,
+					Condition:       publication.Condition,
+					LibrarySourceID: &publication.ID,
+					Attribution:     attribution,
+					Subjects:        publication.Subjects,
+					Grades:          publication.Grades,
+					CreatedAt:       time.Now(),
+				}
+				if err := tx.Omit("Subjects.*", "Grades.*").Create(&template).Error; err != nil {
+					return err
+				}
+				clonedID, revision = template.ID, conditionTemplateRevision(template, userID)
+			}
+			if err := recordRevision(tx, &revision); err != nil {
+				return err
+			}
+
+			return tx.Model(&publication).UpdateColumn("clone_count", gorm.Expr("clone_count + 1")).Error
+		})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to clone publication",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.ClonePublicationDTO{
+			Status:       "publication cloned",
+			ResourceType: publication.ResourceType,
+			ID:           clonedID,
+			Attribution:  attribution,
+		})
+	}
+}
+
+// ReportPublication reports abusive or wrong content
+// @Summary Report a publication
+// @Description Sends a publication to the moderation queue. A user can report a publication once
+// @Tags Library
+// @Accept json
+// @Produce json
+// @Param input body requests.ReportPublication true "Report data"
+// @Success 200 {object} responses.ReportPublicationDTO "Report sent"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 404 {object} responses.ErrorResponse "Publication not found"
+// @Failure 409 {object} responses.ErrorResponse "Already reported"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/library/report [post]
+func ReportPublication(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.ReportPublication{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		var publication dbmodels.Publication
+		result := db.Where("status = ?", dbmodels.PublicationStatusPublished).First(&publication, data.ID)
+		if result.Error == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "publication not found",
+			})
+		} else if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		var count int64
+		err = db.Unscoped().Model(&dbmodels.PublicationReport{}).
+			Where("publication_id = ? AND user_id = ?", publication.ID, userID).
+			Count(&count).Error
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		if count > 0 {
+			return c.Status(409).JSON(responses.ErrorResponse{
+				Status: "already reported",
+			})
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			report := dbmodels.PublicationReport{
+				PublicationID: publication.ID,
+				UserID:        userID,
+				Reason:        data.Reason,
+				Comment:       data.Comment,
+				CreatedAt:     time.Now(),
+			}
+			if err := tx.Create(&report).Error; err != nil {
+				return err
+			}
+			return tx.Model(&publication).UpdateColumn("report_count", gorm.Expr("report_count + 1")).Error
+		})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to report publication",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.ReportPublicationDTO{
+			Status: "report sent",
+		})
+	}
+}
+
+// GetModerationQueue lists publications with open reports
+// @Summary Moderation queue
+// @Description Returns publications with unresolved reports, most reported first, and the open reports of these publications. Only for moderators
+// @Tags Library
+// @Produce json
+// @Param cursor query string false "Cursor returned as page.next_cursor by the previous page"
+// @Param limit query int false "Page size (default is 20, max is 100)"
+// @Param include_total query bool false "Count all reported publications and return page.total"
+// @Success 200 {object} responses.GetModerationQueueDTO "Moderation queue"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/library/moderation [get]
+func GetModerationQueue(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		page, err := parsePage(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid page",
+				Error:  err.Error(),
+			})
+		}
+		if validationErrors := validator.ValidateStruct(page); validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		// Листаются публикации, жалобы возвращаются только для публикаций страницы
+		openReports := db.Model(&dbmodels.PublicationReport{}).Select("publication_id").Where("resolved = ?", false)
+		query := db.Model(&dbmodels.Publication{}).Where("id IN (?)", openReports)
+		total, err := countPage(query, page)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		order := pageOrder{CountColumn: "report_count", IDColumn: "id", Desc: true}
+		query, err = paginate(query, order, page)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid cursor",
+				Error:  err.Error(),
+			})
+		}
+
+		var publications []dbmodels.Publication
+		if err := preloadPublication(query).Find(&publications).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+
+		publications, pageDTO, err := finishPage(publications, page, total, func(publication dbmodels.Publication) pagePosition {
+			return pagePosition{Count: &publication.ReportCount, ID: publication.ID}
+		})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to encode cursor",
+				Error:  err.Error(),
+			})
+		}
+
+		response := responses.GetModerationQueueDTO{
+			Publications: make([]responses.PublicationDTO, len(publications)),
+			Reports:      []responses.PublicationReportDTO{},
+			Page:         pageDTO,
+		}
+		publicationIDs := make([]uint, len(publications))
+		for i, publication := range publications {
+			response.Publications[i] = publicationToDTO(publication)
+			publicationIDs[i] = publication.ID
+		}
+
+		if len(publicationIDs) > 0 {
+			var reports []dbmodels.PublicationReport
+			err := db.Where("resolved = ? AND publication_id IN ?", false, publicationIDs).Order("created_at").Find(&reports).Error
+			if err != nil {
+				return c.Status(500).JSON(responses.ErrorResponse{
+					Status: "internal server error",
+					Error:  err.Error(),
+				})
+			}
+			for _, report := range reports {
+				response.Reports = append(response.Reports, responses.PublicationReportDTO{
+					ID:            report.ID,
+					PublicationID: report.PublicationID,
+					UserID:        report.UserID,
+					Reason:        report.Reason,
+					Comment:       report.Comment,
+					CreatedAt:     report.CreatedAt,
+				})
+			}
+		}
+
+		return c.Status(200).JSON(response)
+	}
+}
+
+// ModeratePublication lets a moderator unpublish or restore a publication
+// @Summary Moderate a publication
+// @Description Sets the publication status to unpublished or back to published and resolves its open reports. Only for moderators
+// @Tags Library
+// @Accept json
+// @Produce json
+// @Param input body requests.ModeratePublication true "Moderation decision"
+// @Success 200 {object} responses.ModeratePublicationDTO "Publication moderated"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "Publication not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/library/moderate [put]
+func ModeratePublication(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.ModeratePublication{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		var publication dbmodels.Publication
+		result := db.First(&publication, data.ID)
+		if result.Error == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "publication not found",
+			})
+		} else if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			err := tx.Model(&publication).Updates(map[string]interface{}{
+				"status":          data.Status,
+				"moderated_by_id": userID,
+				"moderation_note": data.Note,
+			}).Error
+			if err != nil {
+				return err
+			}
+			return tx.Model(&dbmodels.PublicationReport{}).
+				Where("publication_id = ? AND resolved = ?", publication.ID, false).
+				Update("resolved", true).Error
+		})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to moderate publication",
+				Error:  err.Error(),
+			})
+		}
+
+		if err := preloadPublication(db).First(&publication, publication.ID).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.ModeratePublicationDTO{
+			Status:      "publication moderated",
+			Publication: publicationToDTO(publication),
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"gera-ai/internal/config"
+	dbmodels "gera-ai/internal/models/database"
+	"gera-ai/internal/utils/password"
+
+	"gorm.io/gorm"
+)
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+// checkDummyPassword сравнивает пароль с заранее посчитанным хэшем, чтобы ответ
+// для несуществующего логина занимал столько же времени, сколько для неверного пароля
+func checkDummyPassword(pass string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = password.HashPassword("gera-ai-dummy-password")
+	})
+	password.CheckPasswordHash(pass, dummyHash)
+}
+
+func loginFailureKeys(login, ip string) (string, string) {
+	return "login:" + strings.ToLower(login), "ip:" + ip
+}
+
+// loginRetryAfter возвращает, сколько нужно подождать до следующей попытки входа.
+// Ноль - попытка разрешена
+func loginRetryAfter(db *gorm.DB, keys ...string) (time.Duration, error) {
+	guard := config.Config.LoginGuard
+	now := time.Now()
+
+	var failures []dbmodels.LoginFailure
+	if err := db.Where(`"key" IN ?`, keys).Find(&failures).Error; err != nil {
+		return 0, err
+	}
+
+	var wait time.Duration
+	for _, failure := range failures {
+		if failure.LockedUntil != nil && failure.LockedUntil.After(now) {
+			wait = max(wait, failure.LockedUntil.Sub(now))
+			continue
+		}
+		if now.Sub(failure.LastFailureAt) > guard.Window || failure.Failures < guard.DelayAfter {
+			continue
+		}
+
+		// Задержка удваивается с каждой неудачей: 1, 2, 4 ... секунд, но не больше MaxDelay
+		delay := guard.MaxDelay
+		if shift := failure.Failures - guard.DelayAfter; shift < 16 {
+			delay = min(time.Second<<shift, guard.MaxDelay)
+		}
+		if next := failure.LastFailureAt.Add(delay); next.After(now) {
+			wait = max(wait, next.Sub(now))
+		}
+	}
+	return wait, nil
+}
+
+// registerLoginFailure увеличивает счетчик неудач по ключу и блокирует его при достижении limit.
+// Счетчик обнуляется, если с прошлой неудачи прошло больше Window. Возвращает true, если ключ заблокирован
+func registerLoginFailure(db *gorm.DB, key string, limit int) (bool, error) {
+	guard := config.Config.LoginGuard
+	now := time.Now()
+
+	// Один запрос, чтобы параллельные попытки не теряли неудачи
+	var failure dbmodels.LoginFailure
+	err := db.Raw(`
+		INSERT INTO login_failures ("key", failures, last_failure_at, locked_until)
+		VALUES (?, 1, ?, CASE WHEN 1 >= ? THEN ?::timestamptz END)
+		ON CONFLICT ("key") DO UPDATE SET
+			failures = CASE WHEN login_failures.last_failure_at < ? THEN 1 ELSE login_failures.failures + 1 END,
+			last_failure_at = EXCLUDED.last_failure_at,
+			locked_until = CASE
+				WHEN (CASE WHEN login_failures.last_failure_at < ? THEN 1 ELSE login_failures.failures + 1 END) >= ?
+				THEN ?::timestamptz
+			END
+		RETURNING *`,
+		key, now, limit, now.Add(guard.LockoutDuration),
+		now.Add(-guard.Window),
+		now.Add(-guard.Window), limit, now.Add(guard.LockoutDuration),
+	).Scan(&failure).Error
+	if err != nil {
+		return false, err
+	}
+	return failure.LockedUntil != nil, nil
+}
+
+// registerFailedLogin учитывает неудачный вход по логину и по адресу
+// и записывает событие аудита для каждой новой блокировки
+func registerFailedLogin(db *gorm.DB, login, ip string, userID *uint) error {
+	guard := config.Config.LoginGuard
+	accountKey, ipKey := loginFailureKeys(login, ip)
+
+	accountLocked, err := registerLoginFailure(db, accountKey, guard.AccountLimit)
+	if err != nil {
+		return err
+	}
+	ipLocked, err := registerLoginFailure(db, ipKey, guard.IPLimit)
+	if err != nil {
+		return err
+	}
+
+	if accountLocked {
+		recordAudit(db, dbmodels.AuditLoginLockout, userID, ip,
+			fmt.Sprintf("login %q locked for %s after %d failed attempts", login, guard.LockoutDuration, guard.AccountLimit))
+	}
+	if ipLocked {
+		recordAudit(db, dbmodels.AuditLoginLockout, nil, ip,
+			fmt.Sprintf("address %s locked for %s after %d failed attempts", ip, guard.LockoutDuration, guard.IPLimit))
+	}
+	return nil
+}
+
+// resetLoginFailures сбрасывает счетчик по логину после успешного входа.
+// Счетчик адреса не сбрасывается, иначе перебор чужих логинов можно чередовать со входом в свой
+func resetLoginFailures(db *gorm.DB, login string) error {
+	accountKey, _ := loginFailureKeys(login, "")
+	return db.Where(`"key" = ?`, accountKey).Delete(&dbmodels.LoginFailure{}).Error
+}
+
+// recordAudit сохраняет событие аудита. Ошибка записи только логируется,
+// чтобы сбой аудита не ломал основной запрос
+func recordAudit(db *gorm.DB, eventType string, userID *uint, ip, details string) {
+	event := dbmodels.AuditEvent{
+		Type:      eventType,
+		UserID:    userID,
+		IP:        ip,
+		Details:   details,
+		CreatedAt: time.Now(),
+	}
+	if err := db.Create(&event).Error; err != nil {
+		log.Printf("failed to record audit event %s: %v", eventType, err)
+	}
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"gera-ai/internal/config"
+	dbmodels "gera-ai/internal/models/database"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testPassword = "correct-password"
+
+func setLoginGuardConfig(t *testing.T) {
+	t.Helper()
+	setRefreshConfig(t)
+	config.Config.LoginGuard = config.LoginGuardConfig{
+		DelayAfter:      3,
+		MaxDelay:        time.Minute,
+		AccountLimit:    10,
+		IPLimit:         50,
+		LockoutDuration: time.Minute * 15,
+		Window:          time.Minute * 15,
+	}
+}
+
+var loginFailureColumns = []string{"id", "key", "failures", "last_failure_at", "locked_until"}
+
+func TestLoginRetryAfter(t *testing.T) {
+	now := time.Now()
+	lockedUntil := now.Add(time.Minute * 10)
+	lockedBefore := now.Add(-time.Minute)
+
+	type failure struct {
+		failures    int
+		lastFailure time.Time
+		lockedUntil *time.Time
+	}
+	tests := []struct {
+		name     string
+		failures []failure
+		want     time.Duration
+	}{
+		{"no failures", nil, 0},
+		{"below delay threshold", []failure{{2, now, nil}}, 0},
+		{"first delay", []failure{{3, now, nil}}, time.Second},
+		{"delay doubles", []failure{{4, now, nil}}, time.Second * 2},
+		{"delay doubles again", []failure{{5, now, nil}}, time.Second * 4},
+		{"delay capped", []failure{{9, now, nil}}, time.Minute},
+		{"large shift capped", []failure{{40, now, nil}}, time.Minute},
+		{"delay partly passed", []failure{{5, now.Add(-time.Second * 3), nil}}, time.Second},
+		{"delay passed", []failure{{5, now.Add(-time.Second * 5), nil}}, 0},
+		{"outside window", []failure{{9, now.Add(-time.Minute * 16), nil}}, 0},
+		{"locked", []failure{{10, now, &lockedUntil}}, time.Minute * 10},
+		{"lock expired", []failure{{2, now.Add(-time.Minute * 2), &lockedBefore}}, 0},
+		{"longest of login and address", []failure{{3, now, nil}, {5, now, nil}}, time.Second * 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setLoginGuardConfig(t)
+			db, mock := newMockDB(t)
+
+			rows := sqlmock.NewRows(loginFailureColumns)
+			for i, f := range tt.failures {
+				rows.AddRow(i+1, "key", f.failures, f.lastFailure, f.lockedUntil)
+			}
+			mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "login_failures" WHERE "key" IN ($1,$2)`)).
+				WithArgs("login:teacher", "ip:203.0.113.7").
+				WillReturnRows(rows)
+
+			got, err := loginRetryAfter(db, "login:teacher", "ip:203.0.113.7")
+			if err != nil {
+				t.Fatal(err)
+			}
+			// С момента now прошло немного времени, ожидание может быть чуть меньше
+			if got > tt.want || (tt.want == 0) != (got == 0) || got < tt.want-time.Second {
+				t.Fatalf("loginRetryAfter = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// expectLoginFailure ожидает увеличение счетчика по ключу. locked - ответ базы о блокировке
+func expectLoginFailure(mock sqlmock.Sqlmock, key string, limit int, locked bool) {
+	guard := config.Config.LoginGuard
+	now := time.Now()
+
+	var lockedUntil *time.Time
+	if locked {
+		until := now.Add(guard.LockoutDuration)
+		lockedUntil = &until
+	}
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO login_failures ("key", failures, last_failure_at, locked_until)`)).
+		WithArgs(key, timeNear{now}, limit, timeNear{now.Add(guard.LockoutDuration)},
+			timeNear{now.Add(-guard.Window)},
+			timeNear{now.Add(-guard.Window)}, limit, timeNear{now.Add(guard.LockoutDuration)}).
+		WillReturnRows(sqlmock.NewRows(loginFailureColumns).AddRow(1, key, limit, now, lockedUntil))
+}
+
+func TestRegisterLoginFailure(t *testing.T) {
+	for _, locked := range []bool{false, true} {
+		setLoginGuardConfig(t)
+		db, mock := newMockDB(t)
+
+		expectLoginFailure(mock, "login:teacher", 10, locked)
+		got, err := registerLoginFailure(db, "login:teacher", 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != locked {
+			t.Fatalf("registerLoginFailure = %v, want %v", got, locked)
+		}
+	}
+}
+
+// loginApp возвращает приложение с маршрутом входа и пользователем teacher с паролем testPassword
+func loginApp(t *testing.T) (*fiber.App, sqlmock.Sqlmock, func()) {
+	t.Helper()
+	setLoginGuardConfig(t)
+	db, mock := newMockDB(t)
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	app.Post("/api/auth/login", Login(db))
+	expectUser := func() {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE login = $1`)).
+			WithArgs("Teacher", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "login", "password_hash", "role"}).
+				AddRow(5, "Teacher", string(hash), dbmodels.UserRoleTeacher))
+	}
+	return app, mock, expectUser
+}
+
+func postLogin(t *testing.T, app *fiber.App, pass string) (int, string) {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"login": "Teacher", "password": pass})
+	request := httptest.NewRequest("POST", "/api/auth/login", strings.NewReader(string(body)))
+	request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	response, err := app.Test(request, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return response.StatusCode, response.Header.Get(fiber.HeaderRetryAfter)
+}
+
+func expectNoFailures(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "login_failures" WHERE "key" IN ($1,$2)`)).
+		WithArgs("login:teacher", "ip:0.0.0.0").
+		WillReturnRows(sqlmock.NewRows(loginFailureColumns))
+}
+
+func TestLoginLockoutThreshold(t *testing.T) {
+	app, mock, expectUser := loginApp(t)
+
+	// Неудача, на которой счетчик логина достигает лимита, блокирует его и пишется в аудит
+	expectNoFailures(mock)
+	expectUser()
+	expectLoginFailure(mock, "login:teacher", 10, true)
+	expectLoginFailure(mock, "ip:0.0.0.0", 50, false)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "audit_events"`)).
+		WithArgs(dbmodels.AuditLoginLockout, 5, "0.0.0.0", `login "Teacher" locked for 15m0s after 10 failed attempts`, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	if status, _ := postLogin(t, app, "wrong-password"); status != 401 {
+		t.Fatalf("login = %d, want 401", status)
+	}
+
+	// Пока логин заблокирован, даже верный пароль не проверяется
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "login_failures" WHERE "key" IN ($1,$2)`)).
+		WillReturnRows(sqlmock.NewRows(loginFailureColumns).
+			AddRow(1, "login:teacher", 10, time.Now(), time.Now().Add(time.Minute*15)))
+
+	status, retryAfter := postLogin(t, app, testPassword)
+	if status != 429 || retryAfter != "900" {
+		t.Fatalf("login = %d, Retry-After %q; want 429, 900", status, retryAfter)
+	}
+}
+
+func TestLoginResetsFailures(t *testing.T) {
+	app, mock, expectUser := loginApp(t)
+
+	// Успешный вход сбрасывает счетчик логина, но не адреса
+	expectNoFailures(mock)
+	expectUser()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "login_failures" WHERE "key" = $1`)).
+		WithArgs("login:teacher").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "sessions"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "refresh_tokens"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(8))
+	mock.ExpectCommit()
+
+	if status, _ := postLogin(t, app, testPassword); status != 200 {
+		t.Fatalf("login = %d, want 200", status)
+	}
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newMockDB возвращает gorm поверх sqlmock с диалектом postgres. Ожидания проверяются в конце теста
+func newMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		sqlDB.Close()
+	})
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, mock
+}
+
+// timeNear - аргумент запроса, который отличается от want не больше чем на минуту
+type timeNear struct {
+	want time.Time
+}
+
+func (arg timeNear) Match(value driver.Value) bool {
+	got, ok := value.(time.Time)
+	if !ok {
+		return false
+	}
+	diff := got.Sub(arg.want)
+	return diff > -time.Minute && diff < time.Minute
+}
@@ -0,0 +1,569 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	dbmodels "gera-ai/internal/models/database"
+	"gera-ai/internal/models/requests"
+	"gera-ai/internal/models/responses"
+	"gera-ai/internal/utils/jwtUtils"
+	"gera-ai/internal/utils/oauth"
+	"gera-ai/internal/utils/token"
+	"gera-ai/internal/utils/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// oauthStateTTL - сколько пользователь может провести на странице входа провайдера
+const oauthStateTTL = time.Minute * 10
+
+// oauthBindingCookie - cookie, которая привязывает state к браузеру, начавшему вход.
+// Без нее злоумышленник может подсунуть жертве callback со своим кодом и войти
+// ей под своей учетной записью или привязать свою учетную запись к ее аккаунту
+const oauthBindingCookie = "oauth_binding"
+
+// setOAuthBinding ставит или, при пустом value, удаляет cookie привязки
+func setOAuthBinding(c *fiber.Ctx, value string, expires time.Time) {
+	c.Cookie(&fiber.Cookie{
+		Name:     oauthBindingCookie,
+		Value:    value,
+		Path:     "/api/auth/oauth",
+		Expires:  expires,
+		Secure:   c.Protocol() == "https",
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
+
+func identityToDTO(identity dbmodels.ExternalIdentity) responses.IdentityDTO {
+	return responses.IdentityDTO{
+		ID:        identity.ID,
+		Provider:  identity.Provider,
+		Email:     identity.Email,
+		Login:     identity.Login,
+		CreatedAt: identity.CreatedAt,
+	}
+}
+
+// beginOAuth сохраняет state, PKCE verifier и nonce, ставит cookie привязки
+// и возвращает адрес страницы входа провайдера
+func beginOAuth(db *gorm.DB, c *fiber.Ctx, provider *oauth.Provider, linkUserID *uint) (string, error) {
+	var secrets [4]string
+	for i := range secrets {
+		value, err := token.Generate()
+		if err != nil {
+			return "", err
+		}
+		secrets[i] = value
+	}
+	state, verifier, nonce, binding := secrets[0], secrets[1], secrets[2], secrets[3]
+
+	authURL, err := provider.AuthCodeURL(state, verifier, nonce)
+	if err != nil {
+		return "", err
+	}
+
+	// Брошенные входы удаляются при начале новых
+	if err := db.Where("expires_at < ?", time.Now()).Delete(&dbmodels.OAuthState{}).Error; err != nil {
+		return "", err
+	}
+	err = db.Create(&dbmodels.OAuthState{
+		StateHash:    token.Hash(state),
+		Provider:     provider.Name(),
+		CodeVerifier: verifier,
+		BindingHash:  token.Hash(binding),
+		Nonce:        nonce,
+		LinkUserID:   linkUserID,
+		ExpiresAt:    time.Now().Add(oauthStateTTL),
+		CreatedAt:    time.Now(),
+	}).Error
+	if err != nil {
+		return "", err
+	}
+
+	setOAuthBinding(c, binding, time.Now().Add(oauthStateTTL))
+	return authURL, nil
+}
+
+// consumeOAuthState находит и удаляет state, чтобы callback нельзя было повторить,
+// и проверяет, что callback пришел из того же браузера, что и начало входа
+func consumeOAuthState(db *gorm.DB, providerName, state, binding string) (dbmodels.OAuthState, error) {
+	var stored dbmodels.OAuthState
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("state_hash = ? AND provider = ?", token.Hash(state), providerName).First(&stored)
+		if result.Error != nil {
+			return result.Error
+		}
+		return tx.Delete(&stored).Error
+	})
+	if err == gorm.ErrRecordNotFound {
+		return stored, errors.New("unknown or already used state")
+	} else if err != nil {
+		return stored, err
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		return stored, errors.New("login attempt expired, start again")
+	}
+	if binding == "" || subtle.ConstantTimeCompare([]byte(token.Hash(binding)), []byte(stored.BindingHash)) != 1 {
+		return stored, errors.New("login was started in another browser")
+	}
+	return stored, nil
+}
+
+// oauthLogin подбирает свободный логин для нового пользователя по профилю провайдера.
+// Логин должен проходить ту же валидацию, что и при регистрации: 5-20 букв и цифр
+func oauthLogin(db *gorm.DB, providerName string, info oauth.UserInfo) (string, error) {
+	source := info.Login
+	if source == "" {
+		source, _, _ = strings.Cut(info.Email, "@")
+	}
+
+	var base strings.Builder
+	for _, r := range source {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			base.WriteRune(r)
+		}
+	}
+	login := base.String()
+	if len(login) > 15 {
+		login = login[:15]
+	}
+	if len(login) < 5 {
+		login = providerName + login
+	}
+
+	candidate := login
+	for attempt := 0; attempt < 10; attempt++ {
+		var count int64
+		if err := db.Unscoped().Model(&dbmodels.User{}).Where("login = ?", candidate).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 && len(candidate) >= 5 && len(candidate) <= 20 {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%.15s%05d", login, rand.IntN(100000))
+	}
+	return "", errors.New("failed to pick a free login")
+}
+
+// GetOAuthProviders lists configured external login providers
+// @Summary      List login providers
+// @Description  Returns names of the configured OAuth2/OpenID Connect providers
+// @Tags         Auth
+// @Produce      json
+// @Success      200      {object}  responses.OAuthProvidersDTO
+// @Router       /api/auth/oauth/providers [get]
+func GetOAuthProviders(providers oauth.Providers) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		names := make([]string, 0, len(providers))
+		for name := range providers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		return c.Status(200).JSON(responses.OAuthProvidersDTO{
+			Providers: names,
+		})
+	}
+}
+
+// StartOAuth starts a login with an external provider
+// @Summary      Start external login
+// @Description  Starts the authorization code flow with PKCE. The client opens the returned URL; the provider redirects back to the configured redirect URL with code and state, which are passed to the callback endpoint. Sets an HttpOnly cookie that the callback must be sent with
+// @Tags         Auth
+// @Produce      json
+// @Param        provider  path      string  true  "Provider name, e.g. yandex"
+// @Success      200       {object}  responses.OAuthStartDTO
+// @Failure      404       {object}  responses.ErrorResponse
+// @Failure      502       {object}  responses.ErrorResponse
+// @Router       /api/auth/oauth/{provider}/start [get]
+func StartOAuth(db *gorm.DB, providers oauth.Providers) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		provider, ok := providers[c.Params("provider")]
+		if !ok {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "provider not found",
+			})
+		}
+
+		authURL, err := beginOAuth(db, c, provider, nil)
+		if err != nil {
+			return c.Status(502).JSON(responses.ErrorResponse{
+				Status: "failed to start login",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.OAuthStartDTO{
+			AuthorizationURL: authURL,
+		})
+	}
+}
+
+// LinkOAuth starts linking an external account to the current user
+// @Summary      Link external account
+// @Description  Same as start, but the callback links the external account to the logged in user instead of logging in
+// @Tags         Auth
+// @Produce      json
+// @Param        provider  path      string  true  "Provider name, e.g. yandex"
+// @Success      200       {object}  responses.OAuthStartDTO
+// @Failure      400       {object}  responses.ErrorResponse
+// @Failure      404       {object}  responses.ErrorResponse
+// @Failure      502       {object}  responses.ErrorResponse
+// @Router       /api/auth/oauth/{provider}/link [post]
+func LinkOAuth(db *gorm.DB, providers oauth.Providers) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		provider, ok := providers[c.Params("provider")]
+		if !ok {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "provider not found",
+			})
+		}
+
+		authURL, err := beginOAuth(db, c, provider, &userID)
+		if err != nil {
+			return c.Status(502).JSON(responses.ErrorResponse{
+				Status: "failed to start login",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.OAuthStartDTO{
+			AuthorizationURL: authURL,
+		})
+	}
+}
+
+// OAuthCallback finishes a login or link with an external provider
+// @Summary      External login callback
+// @Description  Exchanges the code for the provider profile. Requires the cookie set by start or link, and a valid ID token from OpenID Connect providers. For a login returns tokens like /api/auth/login, registering a new user on first login. For a link returns the linked identity
+// @Tags         Auth
+// @Produce      json
+// @Param        provider  path      string  true  "Provider name, e.g. yandex"
+// @Param        code      query     string  true  "Authorization code"
+// @Param        state     query     string  true  "State from the start request"
+// @Success      200       {object}  responses.AuthDTO
+// @Success      201       {object}  responses.IdentityStatusDTO
+// @Failure      400       {object}  responses.ErrorResponse
+// @Failure      403       {object}  responses.ErrorResponse
+// @Failure      404       {object}  responses.ErrorResponse
+// @Failure      409       {object}  responses.ErrorResponse
+// @Failure      422       {object}  responses.ValidationErrorResponse
+// @Failure      502       {object}  responses.ErrorResponse
+// @Failure      500       {object}  responses.ErrorResponse
+// @Router       /api/auth/oauth/{provider}/callback [get]
+func OAuthCallback(db *gorm.DB, providers oauth.Providers) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Провайдер сообщает об отказе пользователя через параметр error
+		if providerError := c.Query("error"); providerError != "" {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "login cancelled",
+				Error:  providerError + " " + c.Query("error_description"),
+			})
+		}
+
+		data := requests.OAuthCallback{
+			Provider: c.Params("provider"),
+			Code:     c.Query("code"),
+			State:    c.Query("state"),
+		}
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		provider, ok := providers[data.Provider]
+		if !ok {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "provider not found",
+			})
+		}
+
+		state, err := consumeOAuthState(db, provider.Name(), data.State, c.Cookies(oauthBindingCookie))
+		setOAuthBinding(c, "", time.Now().Add(-time.Hour))
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid state",
+				Error:  err.Error(),
+			})
+		}
+
+		tokens, err := provider.Exchange(data.Code, state.CodeVerifier)
+		if err != nil {
+			return c.Status(502).JSON(responses.ErrorResponse{
+				Status: "provider error",
+				Error:  err.Error(),
+			})
+		}
+		info, err := provider.UserInfo(tokens.AccessToken)
+		if err != nil {
+			return c.Status(502).JSON(responses.ErrorResponse{
+				Status: "provider error",
+				Error:  err.Error(),
+			})
+		}
+
+		// OpenID Connect провайдер подписывает ID токен: профиль принимается, только если
+		// токен выдан нам, для этого входа, и относится к тому же пользователю
+		if provider.OpenID() {
+			subject, err := provider.VerifyIDToken(tokens.IDToken, state.Nonce)
+			if err != nil {
+				return c.Status(400).JSON(responses.ErrorResponse{
+					Status: "invalid id token",
+					Error:  err.Error(),
+				})
+			}
+			if subject != info.Subject {
+				return c.Status(400).JSON(responses.ErrorResponse{
+					Status: "invalid id token",
+					Error:  "ID token and profile belong to different users",
+				})
+			}
+		}
+
+		var identity dbmodels.ExternalIdentity
+		result := db.Preload("User").Where("provider = ? AND subject = ?", provider.Name(), info.Subject).First(&identity)
+		if result.Error != nil && result.Error != gorm.ErrRecordNotFound {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+		found := result.Error == nil
+
+		// Привязка к пользователю, который начал вход из своего аккаунта
+		if state.LinkUserID != nil {
+			if found && identity.UserID != *state.LinkUserID {
+				return c.Status(409).JSON(responses.ErrorResponse{
+					Status: "identity already linked",
+					Error:  "this " + provider.Name() + " account is linked to another user",
+				})
+			}
+			if !found {
+				identity = dbmodels.ExternalIdentity{
+					UserID:    *state.LinkUserID,
+					Provider:  provider.Name(),
+					Subject:   info.Subject,
+					Email:     truncateRunes(info.Email, 255),
+					Login:     truncateRunes(info.Login, 100),
+					CreatedAt: time.Now(),
+				}
+				if err := db.Create(&identity).Error; err != nil {
+					return c.Status(500).JSON(responses.ErrorResponse{
+						Status: "failed to link identity",
+						Error:  err.Error(),
+					})
+				}
+			}
+			return c.Status(201).JSON(responses.IdentityStatusDTO{
+				Status:   "identity linked",
+				Identity: identityToDTO(identity),
+			})
+		}
+
+		user := identity.User
+		if !found {
+			// Первый вход через провайдера регистрирует пользователя без пароля
+			login, err := oauthLogin(db, provider.Name(), info)
+			if err != nil {
+				return c.Status(500).JSON(responses.ErrorResponse{
+					Status: "failed to register user",
+					Error:  err.Error(),
+				})
+			}
+			username := info.Name
+			if username == "" {
+				username = login
+			}
+
+			user = dbmodels.User{
+				Login:     login,
+				Username:  truncateRunes(username, 35),
+				Role:      dbmodels.UserRoleTeacher,
+				CreatedAt: time.Now(),
+			}
+			err = db.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Create(&user).Error; err != nil {
+					return err
+				}
+				return tx.Create(&dbmodels.ExternalIdentity{
+					UserID:    user.ID,
+					Provider:  provider.Name(),
+					Subject:   info.Subject,
+					Email:     truncateRunes(info.Email, 255),
+					Login:     truncateRunes(info.Login, 100),
+					CreatedAt: time.Now(),
+				}).Error
+			})
+			if err != nil {
+				return c.Status(500).JSON(responses.ErrorResponse{
+					Status: "failed to register user",
+					Error:  err.Error(),
+				})
+			}
+		}
+
+		if user.ID == 0 {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "user not found",
+			})
+		}
+		if user.BlockedAt != nil {
+			return c.Status(403).JSON(responses.ErrorResponse{
+				Status: "error",
+				Error:  "Account is blocked",
+			})
+		}
+
+		auth, err := startSession(db, c, user)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "error",
+				Error:  "Failed to start session",
+			})
+		}
+
+		auth.Status = "ok"
+		return c.Status(200).JSON(auth)
+	}
+}
+
+// GetIdentities lists external accounts linked to the user
+// @Summary      List linked accounts
+// @Description  Returns external provider accounts linked to the user
+// @Tags         Auth
+// @Produce      json
+// @Success      200      {object}  responses.GetIdentitiesDTO
+// @Failure      400      {object}  responses.ErrorResponse
+// @Failure      500      {object}  responses.ErrorResponse
+// @Router       /api/auth/identities [get]
+func GetIdentities(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		var identities []dbmodels.ExternalIdentity
+		if err := db.Where("user_id = ?", userID).Order("created_at").Find(&identities).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+
+		identitiesDTO := make([]responses.IdentityDTO, len(identities))
+		for i, identity := range identities {
+			identitiesDTO[i] = identityToDTO(identity)
+		}
+
+		return c.Status(200).JSON(responses.GetIdentitiesDTO{
+			Identities: identitiesDTO,
+		})
+	}
+}
+
+// UnlinkIdentity removes a linked external account
+// @Summary      Unlink external account
+// @Description  Unlinks an external account. The last way to log in cannot be removed: a user without a password must keep at least one linked account
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      requests.UnlinkIdentity  true  "Identity"
+// @Success      200      {object}  responses.IdentityStatusDTO
+// @Failure      400      {object}  responses.ErrorResponse
+// @Failure      404      {object}  responses.ErrorResponse
+// @Failure      409      {object}  responses.ErrorResponse
+// @Failure      422      {object}  responses.ValidationErrorResponse
+// @Failure      500      {object}  responses.ErrorResponse
+// @Router       /api/auth/identities/unlink [delete]
+func UnlinkIdentity(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.UnlinkIdentity{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		var identity dbmodels.ExternalIdentity
+		result := db.Preload("User").Where("id = ? AND user_id = ?", data.ID, userID).First(&identity)
+		if result.Error == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "identity not found",
+			})
+		} else if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		if identity.User.PasswordHash == "" {
+			var count int64
+			if err := db.Model(&dbmodels.ExternalIdentity{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+				return c.Status(500).JSON(responses.ErrorResponse{
+					Status: "internal server error",
+					Error:  err.Error(),
+				})
+			}
+			if count <= 1 {
+				return c.Status(409).JSON(responses.ErrorResponse{
+					Status: "last login method",
+					Error:  "set a password or link another account before unlinking this one",
+				})
+			}
+		}
+
+		if err := db.Unscoped().Delete(&identity).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to unlink identity",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.IdentityStatusDTO{
+			Status:   "identity unlinked",
+			Identity: identityToDTO(identity),
+		})
+	}
+}
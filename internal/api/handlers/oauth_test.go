@@ -0,0 +1,315 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	dbmodels "gera-ai/internal/models/database"
+	"gera-ai/internal/utils/oauth"
+	"gera-ai/internal/utils/token"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	stubClientID = "gera-ai"
+	stubSubject  = "stub-user-1"
+)
+
+// stubProvider - OpenID Connect провайдер для тестов: discovery, токены, ключи и профиль
+type stubProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	// nonce, который провайдер запишет в ID токен. Пустой - взять из адреса входа
+	nonce string
+	// subject в ID токене. Пустой - тот же, что в профиле
+	subject string
+}
+
+func newStubProvider(t *testing.T) *stubProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub := &stubProvider{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 stub.server.URL,
+			"authorization_endpoint": stub.server.URL + "/authorize",
+			"token_endpoint":         stub.server.URL + "/token",
+			"userinfo_endpoint":      stub.server.URL + "/userinfo",
+			"jwks_uri":               stub.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "stub",
+				"alg": "RS256",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		subject := stub.subject
+		if subject == "" {
+			subject = stubSubject
+		}
+		idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":   stub.server.URL,
+			"aud":   stubClientID,
+			"sub":   subject,
+			"nonce": stub.nonce,
+			"iat":   time.Now().Unix(),
+			"exp":   time.Now().Add(time.Minute * 5).Unix(),
+		})
+		idToken.Header["kid"] = "stub"
+		signed, err := idToken.SignedString(key)
+		if err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "stub-access-token",
+			"id_token":     signed,
+		})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"sub":   stubSubject,
+			"email": "student@example.com",
+		})
+	})
+	stub.server = httptest.NewServer(mux)
+	t.Cleanup(stub.server.Close)
+	return stub
+}
+
+func (stub *stubProvider) providers() oauth.Providers {
+	return oauth.NewProviders([]oauth.Config{{
+		Name:         "stub",
+		ClientID:     stubClientID,
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost/oauth/stub/callback",
+		Issuer:       stub.server.URL,
+	}})
+}
+
+// oauthStart начинает вход и возвращает state, nonce из адреса входа и cookie привязки
+func oauthStart(t *testing.T, app *fiber.App, mock sqlmock.Sqlmock) (state, nonce string, binding *http.Cookie) {
+	t.Helper()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "o_auth_states" WHERE expires_at < $1`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "o_auth_states"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	response, err := app.Test(httptest.NewRequest("GET", "/api/auth/oauth/stub/start", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != 200 {
+		body, _ := io.ReadAll(response.Body)
+		t.Fatalf("start = %d %s, want 200", response.StatusCode, body)
+	}
+
+	var body struct {
+		AuthorizationURL string `json:"authorization_url"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	authURL, err := url.Parse(body.AuthorizationURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, nonce = authURL.Query().Get("state"), authURL.Query().Get("nonce")
+	if state == "" || nonce == "" {
+		t.Fatalf("authorization URL %s has no state or nonce", body.AuthorizationURL)
+	}
+
+	for _, cookie := range response.Cookies() {
+		if cookie.Name == oauthBindingCookie {
+			binding = cookie
+		}
+	}
+	if binding == nil || !binding.HttpOnly || binding.SameSite != http.SameSiteLaxMode {
+		t.Fatalf("expected an HttpOnly SameSite=Lax binding cookie, got %+v", binding)
+	}
+	return state, nonce, binding
+}
+
+// expectConsumeState ожидает поиск и удаление state. Без строки state считается неизвестным
+func expectConsumeState(mock sqlmock.Sqlmock, state string, row *dbmodels.OAuthState) {
+	mock.ExpectBegin()
+	rows := sqlmock.NewRows([]string{"id", "state_hash", "provider", "code_verifier", "binding_hash", "nonce", "link_user_id", "expires_at", "created_at"})
+	if row != nil {
+		rows.AddRow(row.ID, row.StateHash, row.Provider, row.CodeVerifier, row.BindingHash, row.Nonce, nil, row.ExpiresAt, row.CreatedAt)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "o_auth_states" WHERE state_hash = $1 AND provider = $2`)).
+		WithArgs(token.Hash(state), "stub", 1).
+		WillReturnRows(rows)
+	if row == nil {
+		mock.ExpectRollback()
+		return
+	}
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "o_auth_states" WHERE "o_auth_states"."id" = $1`)).
+		WithArgs(row.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+}
+
+func storedState(state, nonce, binding string, expiresAt time.Time) *dbmodels.OAuthState {
+	return &dbmodels.OAuthState{
+		ID:           1,
+		StateHash:    token.Hash(state),
+		Provider:     "stub",
+		CodeVerifier: "verifier",
+		BindingHash:  token.Hash(binding),
+		Nonce:        nonce,
+		ExpiresAt:    expiresAt,
+		CreatedAt:    time.Now(),
+	}
+}
+
+func oauthCallback(t *testing.T, app *fiber.App, state string, binding *http.Cookie) (int, map[string]interface{}) {
+	t.Helper()
+	request := httptest.NewRequest("GET", "/api/auth/oauth/stub/callback?code=code&state="+url.QueryEscape(state), nil)
+	if binding != nil {
+		request.AddCookie(binding)
+	}
+	response, err := app.Test(request, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body map[string]interface{}
+	json.NewDecoder(response.Body).Decode(&body)
+	return response.StatusCode, body
+}
+
+func setupOAuthTest(t *testing.T) (*stubProvider, *fiber.App, sqlmock.Sqlmock) {
+	t.Helper()
+	setRefreshConfig(t)
+	db, mock := newMockDB(t)
+	stub := newStubProvider(t)
+	providers := stub.providers()
+
+	app := fiber.New()
+	app.Get("/api/auth/oauth/:provider/start", StartOAuth(db, providers))
+	app.Get("/api/auth/oauth/:provider/callback", OAuthCallback(db, providers))
+	return stub, app, mock
+}
+
+func TestOAuthLoginAndReplay(t *testing.T) {
+	stub, app, mock := setupOAuthTest(t)
+
+	state, nonce, binding := oauthStart(t, app, mock)
+	stub.nonce = nonce
+
+	// Учетная запись уже привязана: вход открывает сессию ее пользователя
+	expectConsumeState(mock, state, storedState(state, nonce, binding.Value, time.Now().Add(oauthStateTTL)))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "external_identities" WHERE (provider = $1 AND subject = $2)`)).
+		WithArgs("stub", stubSubject, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "provider", "subject"}).AddRow(4, 5, "stub", stubSubject))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE "users"."id" = $1`)).
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "login", "role", "blocked_at"}).AddRow(5, "student", dbmodels.UserRoleTeacher, nil))
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "sessions"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "refresh_tokens"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(8))
+	mock.ExpectCommit()
+
+	status, body := oauthCallback(t, app, state, binding)
+	if status != 200 || body["token"] == "" || body["refresh_token"] == "" {
+		t.Fatalf("callback = %d %v, want 200 with tokens", status, body)
+	}
+
+	// Тот же state второй раз уже удален
+	expectConsumeState(mock, state, nil)
+	status, body = oauthCallback(t, app, state, binding)
+	if status != 400 || body["status"] != "invalid state" {
+		t.Fatalf("replayed callback = %d %v, want 400 invalid state", status, body)
+	}
+}
+
+func TestOAuthCallbackExpiredState(t *testing.T) {
+	_, app, mock := setupOAuthTest(t)
+
+	expectConsumeState(mock, "state", storedState("state", "nonce", "binding", time.Now().Add(-time.Minute)))
+	status, body := oauthCallback(t, app, "state", &http.Cookie{Name: oauthBindingCookie, Value: "binding"})
+	if status != 400 || body["error"] != "login attempt expired, start again" {
+		t.Fatalf("callback = %d %v, want 400 expired", status, body)
+	}
+}
+
+func TestOAuthCallbackFromAnotherBrowser(t *testing.T) {
+	tests := []struct {
+		name    string
+		binding *http.Cookie
+	}{
+		{"no cookie", nil},
+		{"other cookie", &http.Cookie{Name: oauthBindingCookie, Value: "attacker"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, app, mock := setupOAuthTest(t)
+
+			expectConsumeState(mock, "state", storedState("state", "nonce", "binding", time.Now().Add(oauthStateTTL)))
+			status, body := oauthCallback(t, app, "state", tt.binding)
+			if status != 400 || body["error"] != "login was started in another browser" {
+				t.Fatalf("callback = %d %v, want 400 another browser", status, body)
+			}
+		})
+	}
+}
+
+func TestOAuthCallbackInvalidIDToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		nonce   string
+		subject string
+	}{
+		{"wrong nonce", "other-nonce", ""},
+		{"other subject", "", "stub-user-2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub, app, mock := setupOAuthTest(t)
+
+			state, nonce, binding := oauthStart(t, app, mock)
+			stub.nonce, stub.subject = tt.nonce, tt.subject
+			if stub.nonce == "" {
+				stub.nonce = nonce
+			}
+
+			expectConsumeState(mock, state, storedState(state, nonce, binding.Value, time.Now().Add(oauthStateTTL)))
+			status, body := oauthCallback(t, app, state, binding)
+			if status != 400 || body["status"] != "invalid id token" {
+				t.Fatalf("callback = %d %v, want 400 invalid id token", status, body)
+			}
+		})
+	}
+}
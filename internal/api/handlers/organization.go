@@ -0,0 +1,790 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	dbmodels "gera-ai/internal/models/database"
+	"gera-ai/internal/models/requests"
+	"gera-ai/internal/models/responses"
+	"gera-ai/internal/utils/jwtUtils"
+	"gera-ai/internal/utils/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// activeOrganizationID возвращает активную организацию запроса или nil для личного пространства
+func activeOrganizationID(c *fiber.Ctx) *uint {
+	organizationID, _, ok := jwtUtils.ExtractOrganization(c)
+	if !ok {
+		return nil
+	}
+	return &organizationID
+}
+
+// scopeToWorkspace ограничивает выборку записями активной организации,
+// а без нее - записями пользователя
+func scopeToWorkspace(c *fiber.Ctx, db *gorm.DB, userID uint) *gorm.DB {
+	if organizationID := activeOrganizationID(c); organizationID != nil {
+		return db.Where("organization_id = ?", *organizationID)
+	}
+	return db.Where("author_id = ?", userID)
+}
+
+// organizationRole возвращает роль пользователя в организации, пустую строку - если он не участник
+func organizationRole(db *gorm.DB, organizationID, userID uint) (string, error) {
+	var roles []string
+	err := db.Model(&dbmodels.OrganizationMember{}).
+		Where("organization_id = ? AND user_id = ?", organizationID, userID).
+		Pluck("role", &roles).Error
+	if err != nil || len(roles) == 0 {
+		return "", err
+	}
+	return roles[0], nil
+}
+
+// generationsThisMonth считает генерации организации с начала текущего месяца
+func generationsThisMonth(db *gorm.DB, organizationID uint) (int64, error) {
+	now := time.Now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	var count int64
+	err := db.Model(&dbmodels.Generation{}).
+		Where("organization_id = ? AND created_at >= ?", organizationID, monthStart).
+		Count(&count).Error
+	return count, err
+}
+
+// checkGenerationLimit проверяет месячный лимит генераций активной организации.
+// Возвращает HTTP статус, с которым нужно ответить при ошибке
+func checkGenerationLimit(c *fiber.Ctx, db *gorm.DB) (int, error) {
+	organizationID := activeOrganizationID(c)
+	if organizationID == nil {
+		return 200, nil
+	}
+
+	var organization dbmodels.Organization
+	if err := db.First(&organization, *organizationID).Error; err != nil {
+		return 500, err
+	}
+	if organization.MonthlyGenerationLimit == 0 {
+		return 200, nil
+	}
+
+	used, err := generationsThisMonth(db, organization.ID)
+	if err != nil {
+		return 500, err
+	}
+	if used >= int64(organization.MonthlyGenerationLimit) {
+		return 429, fmt.Errorf("organization used all %d generations of this month", organization.MonthlyGenerationLimit)
+	}
+	return 200, nil
+}
+
+// canManageOrganization - управлять участниками и настройками могут владелец и администраторы
+func canManageOrganization(role string) bool {
+	return role == dbmodels.OrganizationRoleOwner || role == dbmodels.OrganizationRoleAdmin
+}
+
+func organizationToDTO(organization dbmodels.Organization, role string) responses.OrganizationDTO {
+	return responses.OrganizationDTO{
+		ID:                     organization.ID,
+		Name:                   organization.Name,
+		Role:                   role,
+		DefaultLanguage:        organization.DefaultLanguage,
+		MonthlyGenerationLimit: organization.MonthlyGenerationLimit,
+	}
+}
+
+// CreateOrganization creates an organization owned by the user
+// @Summary Create an organization
+// @Description Creates a school workspace. The creator becomes its owner
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Param input body requests.CreateOrganization true "Organization data"
+// @Success 200 {object} responses.CreateOrganizationDTO "Organization created"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/org/new [post]
+func CreateOrganization(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.CreateOrganization{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		organization := dbmodels.Organization{
+			Name:            data.Name,
+			OwnerID:         userID,
+			DefaultLanguage: "ru",
+			CreatedAt:       time.Now(),
+		}
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&organization).Error; err != nil {
+				return err
+			}
+			return tx.Create(&dbmodels.OrganizationMember{
+				OrganizationID: organization.ID,
+				UserID:         userID,
+				Role:           dbmodels.OrganizationRoleOwner,
+				CreatedAt:      time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to create organization",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.CreateOrganizationDTO{
+			Status:       "organization created",
+			Organization: organizationToDTO(organization, dbmodels.OrganizationRoleOwner),
+		})
+	}
+}
+
+// GetOrganizations lists organizations of the user
+// @Summary List my organizations
+// @Description Returns the organizations the user is a member of with the user's role, in the order the user joined them
+// @Tags Organizations
+// @Produce json
+// @Param cursor query string false "Cursor returned as page.next_cursor by the previous page"
+// @Param limit query int false "Page size (default is 20, max is 100)"
+// @Param include_total query bool false "Count all organizations and return page.total"
+// @Success 200 {object} responses.GetOrganizationsDTO "Organizations successfully retrieved"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/org/all [get]
+func GetOrganizations(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		page, err := parsePage(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid page",
+				Error:  err.Error(),
+			})
+		}
+		if validationErrors := validator.ValidateStruct(page); validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		// Организация могла быть удалена, а участие - нет. Такие участия отсекаются в запросе,
+		// чтобы страница не оказалась короче limit
+		query := db.Model(&dbmodels.OrganizationMember{}).
+			InnerJoins("Organization").
+			Where("organization_members.user_id = ?", userID)
+		total, err := countPage(query, page)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		order := pageOrder{TimeColumn: "organization_members.created_at", IDColumn: "organization_members.id"}
+		query, err = paginate(query, order, page)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid cursor",
+				Error:  err.Error(),
+			})
+		}
+
+		var memberships []dbmodels.OrganizationMember
+		if err := query.Find(&memberships).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+
+		memberships, pageDTO, err := finishPage(memberships, page, total, func(membership dbmodels.OrganizationMember) pagePosition {
+			return pagePosition{Time: &membership.CreatedAt, ID: membership.ID}
+		})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to encode cursor",
+				Error:  err.Error(),
+			})
+		}
+
+		organizations := make([]responses.OrganizationDTO, len(memberships))
+		for i, membership := range memberships {
+			organizations[i] = organizationToDTO(membership.Organization, membership.Role)
+		}
+
+		return c.Status(200).JSON(responses.GetOrganizationsDTO{
+			Organizations: organizations,
+			Page:          pageDTO,
+		})
+	}
+}
+
+// GetOrganization retrieves an organization with its members
+// @Summary Get an organization
+// @Description Returns the organization settings, members and generations used this month. Only for members
+// @Tags Organizations
+// @Produce json
+// @Param id path int true "Organization ID"
+// @Success 200 {object} responses.GetOrganizationDTO "Organization successfully retrieved"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or ID"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "Organization not found"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/org/get/{id} [get]
+func GetOrganization(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		organizationID, err := strconv.Atoi(c.Params("id"))
+		if err != nil || organizationID <= 0 {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid organization ID",
+				Error:  "organization ID must be a positive integer",
+			})
+		}
+
+		data := requests.GetOrganization{ID: uint(organizationID)}
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		var organization dbmodels.Organization
+		result := db.Preload("Members", func(query *gorm.DB) *gorm.DB {
+			return query.Order("created_at")
+		}).Preload("Members.User").First(&organization, data.ID)
+		if result.Error == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "organization not found",
+			})
+		} else if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		role := ""
+		members := make([]responses.OrganizationMemberDTO, len(organization.Members))
+		for i, member := range organization.Members {
+			if member.UserID == userID {
+				role = member.Role
+			}
+			members[i] = responses.OrganizationMemberDTO{
+				UserID:   member.UserID,
+				Login:    member.User.Login,
+				Username: member.User.Username,
+				Role:     member.Role,
+				JoinedAt: member.CreatedAt,
+			}
+		}
+		if role == "" {
+			return c.Status(403).JSON(responses.ErrorResponse{
+				Status: "forbidden",
+				Error:  "you are not a member of this organization",
+			})
+		}
+
+		used, err := generationsThisMonth(db, organization.ID)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.GetOrganizationDTO{
+			Organization:    organizationToDTO(organization, role),
+			Members:         members,
+			GenerationsUsed: used,
+		})
+	}
+}
+
+// EditOrganizationSettings updates organization name and settings
+// @Summary Edit organization settings
+// @Description Updates the name, default language and monthly generation limit (0 means unlimited). Only for the owner and admins
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Param input body requests.EditOrganizationSettings true "Organization settings"
+// @Success 200 {object} responses.EditOrganizationSettingsDTO "Settings updated"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "Organization not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/org/settings [put]
+func EditOrganizationSettings(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.EditOrganizationSettings{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		var organization dbmodels.Organization
+		result := db.First(&organization, data.ID)
+		if result.Error == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "organization not found",
+			})
+		} else if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		role, err := organizationRole(db, organization.ID, userID)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		if !canManageOrganization(role) {
+			return c.Status(403).JSON(responses.ErrorResponse{
+				Status: "forbidden",
+				Error:  "only the owner and admins can change organization settings",
+			})
+		}
+
+		organization.Name = data.Name
+		organization.DefaultLanguage = data.DefaultLanguage
+		organization.MonthlyGenerationLimit = data.MonthlyGenerationLimit
+		organization.UpdatedAt = time.Now()
+		if err := db.Save(&organization).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to update organization",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.EditOrganizationSettingsDTO{
+			Status:       "organization updated",
+			Organization: organizationToDTO(organization, role),
+		})
+	}
+}
+
+// AddOrganizationMember adds a user to an organization
+// @Summary Add a member
+// @Description Adds a user by login as a teacher or admin. Admins can add teachers, only the owner can add admins
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Param input body requests.AddOrganizationMember true "Member data"
+// @Success 200 {object} responses.OrganizationMemberStatusDTO "Member added"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "User not found"
+// @Failure 409 {object} responses.ErrorResponse "Already a member"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/org/member/add [post]
+func AddOrganizationMember(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.AddOrganizationMember{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		role, err := organizationRole(db, data.ID, userID)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		if !canManageOrganization(role) || (data.Role == dbmodels.OrganizationRoleAdmin && role != dbmodels.OrganizationRoleOwner) {
+			return c.Status(403).JSON(responses.ErrorResponse{
+				Status: "forbidden",
+				Error:  "you cannot add members with this role",
+			})
+		}
+
+		var user dbmodels.User
+		result := db.Where("login = ?", data.Login).First(&user)
+		if result.Error == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "user not found",
+			})
+		} else if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		// Ранее исключенный участник возвращается с новой ролью
+		member := dbmodels.OrganizationMember{
+			OrganizationID: data.ID,
+			UserID:         user.ID,
+			Role:           data.Role,
+			CreatedAt:      time.Now(),
+			UpdatedAt:      time.Now(),
+		}
+		result = db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "organization_id"}, {Name: "user_id"}},
+			Where:     clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "organization_members.deleted_at IS NOT NULL"}}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"role": data.Role, "created_at": time.Now(), "updated_at": time.Now(), "deleted_at": nil}),
+		}).Create(&member)
+		if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to add member",
+				Error:  result.Error.Error(),
+			})
+		}
+		if result.RowsAffected == 0 {
+			return c.Status(409).JSON(responses.ErrorResponse{
+				Status: "already a member",
+			})
+		}
+
+		return c.Status(200).JSON(responses.OrganizationMemberStatusDTO{
+			Status: "member added",
+		})
+	}
+}
+
+// ChangeOrganizationMemberRole changes the role of a member
+// @Summary Change a member role
+// @Description Switches a member between teacher and admin. Only the owner can do it
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Param input body requests.ChangeOrganizationMemberRole true "New role"
+// @Success 200 {object} responses.OrganizationMemberStatusDTO "Role changed"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "Member not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/org/member/role [put]
+func ChangeOrganizationMemberRole(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.ChangeOrganizationMemberRole{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		role, err := organizationRole(db, data.ID, userID)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		if role != dbmodels.OrganizationRoleOwner {
+			return c.Status(403).JSON(responses.ErrorResponse{
+				Status: "forbidden",
+				Error:  "only the owner can change roles",
+			})
+		}
+		if data.UserID == userID {
+			return c.Status(422).JSON(responses.ErrorResponse{
+				Status: "invalid member",
+				Error:  "the owner cannot change their own role",
+			})
+		}
+
+		result := db.Model(&dbmodels.OrganizationMember{}).
+			Where("organization_id = ? AND user_id = ?", data.ID, data.UserID).
+			Update("role", data.Role)
+		if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to change role",
+				Error:  result.Error.Error(),
+			})
+		}
+		if result.RowsAffected == 0 {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "member not found",
+			})
+		}
+
+		return c.Status(200).JSON(responses.OrganizationMemberStatusDTO{
+			Status: "role changed",
+		})
+	}
+}
+
+// RemoveOrganizationMember removes a member from an organization
+// @Summary Remove a member
+// @Description Removes a member. The owner and admins can remove teachers, only the owner can remove admins, any member can leave. The owner cannot be removed. Tasks of the member stay in the organization
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Param input body requests.RemoveOrganizationMember true "Member to remove"
+// @Success 200 {object} responses.OrganizationMemberStatusDTO "Member removed"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "Member not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/org/member/remove [delete]
+func RemoveOrganizationMember(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.RemoveOrganizationMember{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		role, err := organizationRole(db, data.ID, userID)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		memberRole, err := organizationRole(db, data.ID, data.UserID)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		if memberRole == "" {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "member not found",
+			})
+		}
+		if memberRole == dbmodels.OrganizationRoleOwner {
+			return c.Status(422).JSON(responses.ErrorResponse{
+				Status: "invalid member",
+				Error:  "the owner cannot leave the organization",
+			})
+		}
+
+		allowed := data.UserID == userID ||
+			role == dbmodels.OrganizationRoleOwner ||
+			(role == dbmodels.OrganizationRoleAdmin && memberRole == dbmodels.OrganizationRoleTeacher)
+		if !allowed {
+			return c.Status(403).JSON(responses.ErrorResponse{
+				Status: "forbidden",
+				Error:  "you cannot remove this member",
+			})
+		}
+
+		result := db.Where("organization_id = ? AND user_id = ?", data.ID, data.UserID).
+			Delete(&dbmodels.OrganizationMember{})
+		if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to remove member",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.OrganizationMemberStatusDTO{
+			Status: "member removed",
+		})
+	}
+}
+
+// moveResource переносит запись автора в организацию, в которой он состоит.
+// organizationID = 0 возвращает запись в личное пространство
+func moveResource(db *gorm.DB, userID uint, resourceType string, id, organizationID uint) (int, error) {
+	if status, err := checkResourceOwner(db, userID, resourceType, id); err != nil {
+		return status, err
+	}
+
+	var target *uint
+	if organizationID != 0 {
+		role, err := organizationRole(db, organizationID, userID)
+		if err != nil {
+			return 500, err
+		}
+		if role == "" {
+			return 403, errors.New("you are not a member of this organization")
+		}
+		target = &organizationID
+	}
+
+	err := db.Table(taggedResources[resourceType].table).
+		Where("id = ?", id).
+		Update("organization_id", target).Error
+	if err != nil {
+		return 500, err
+	}
+	return 200, nil
+}
+
+// MoveToOrganization moves a task or template into an organization workspace
+// @Summary Move to an organization
+// @Description Moves the author's task or template into an organization the author belongs to. organization_id 0 moves it back to the personal space
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Param input body requests.MoveToOrganization true "Resource and organization"
+// @Success 200 {object} responses.MoveToOrganizationDTO "Resource moved"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "Resource not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/org/move [put]
+func MoveToOrganization(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.MoveToOrganization{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		if status, err := moveResource(db, userID, data.ResourceType, data.ID, data.OrganizationID); err != nil {
+			message := "resource unavailable"
+			if status == 500 {
+				message = "failed to move " + strings.ReplaceAll(data.ResourceType, "_", " ")
+			}
+			return c.Status(status).JSON(responses.ErrorResponse{
+				Status: message,
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.MoveToOrganizationDTO{
+			Status: strings.ReplaceAll(data.ResourceType, "_", " ") + " moved",
+		})
+	}
+}
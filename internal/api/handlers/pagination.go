@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gera-ai/internal/models/requests"
+	"gera-ai/internal/models/responses"
+	"gera-ai/internal/utils/cursor"
+	"gera-ai/internal/utils/listQuery"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// defaultPageLimit - размер страницы, если клиент не передал limit
+const defaultPageLimit = 20
+
+// pagePosition - позиция в списке, которую хранит курсор. Заполняются только поля,
+// по которым отсортирован список. Формат совместим с прежним курсором истории генераций
+type pagePosition struct {
+	Sort  string     `json:"s,omitempty"`
+	Count *int       `json:"n,omitempty"`
+	Time  *time.Time `json:"c,omitempty"`
+	Text  *string    `json:"t,omitempty"`
+	ID    uint       `json:"i"`
+}
+
+// pageOrder описывает сортировку списка: необязательные столбцы счетчика, времени и текста
+// и столбец id, который делает порядок однозначным. Sort - сортировка, выбранная клиентом,
+// она сохраняется в курсоре, чтобы курсор одной сортировки нельзя было передать с другой
+type pageOrder struct {
+	Sort        string
+	Field       string
+	CountColumn string
+	TimeColumn  string
+	TextColumn  string
+	IDColumn    string
+	Desc        bool
+}
+
+// sortedPageOrder строит порядок страницы по сортировке из запроса, а без нее возвращает fallback
+func sortedPageOrder(sort listQuery.Sort, fallback pageOrder) pageOrder {
+	if sort.Name == "" {
+		return fallback
+	}
+	order := pageOrder{
+		Sort:     sort.Key(),
+		Field:    sort.Name,
+		IDColumn: fallback.IDColumn,
+		Desc:     sort.Desc,
+	}
+	switch sort.Kind {
+	case listQuery.Time:
+		order.TimeColumn = sort.Column
+	case listQuery.Text:
+		order.TextColumn = sort.Column
+	case listQuery.Number:
+		order.CountColumn = sort.Column
+	}
+	return order
+}
+
+// recordPosition возвращает позицию записи для списков с полями title, created_at и updated_at
+func (order pageOrder) recordPosition(id uint, title string, createdAt, updatedAt time.Time) pagePosition {
+	position := pagePosition{Sort: order.Sort, ID: id}
+	switch order.Field {
+	case "title":
+		position.Text = &title
+	case "created_at":
+		position.Time = &createdAt
+	case "updated_at":
+		position.Time = &updatedAt
+	}
+	return position
+}
+
+func (order pageOrder) columns() []string {
+	var columns []string
+	for _, column := range []string{order.CountColumn, order.TimeColumn, order.TextColumn} {
+		if column != "" {
+			columns = append(columns, column)
+		}
+	}
+	return append(columns, order.IDColumn)
+}
+
+// values возвращает значения позиции в порядке столбцов сортировки
+func (order pageOrder) values(position pagePosition) ([]interface{}, error) {
+	if position.Sort != order.Sort {
+		return nil, cursor.ErrInvalidCursor
+	}
+
+	var values []interface{}
+	if order.CountColumn != "" {
+		if position.Count == nil {
+			return nil, cursor.ErrInvalidCursor
+		}
+		values = append(values, *position.Count)
+	}
+	if order.TimeColumn != "" {
+		if position.Time == nil {
+			return nil, cursor.ErrInvalidCursor
+		}
+		values = append(values, *position.Time)
+	}
+	if order.TextColumn != "" {
+		if position.Text == nil {
+			return nil, cursor.ErrInvalidCursor
+		}
+		values = append(values, *position.Text)
+	}
+	return append(values, position.ID), nil
+}
+
+// parsePage читает параметры страницы из запроса: cursor, limit, include_total и устаревший offset
+func parsePage(c *fiber.Ctx) (requests.Page, error) {
+	page := requests.Page{
+		Cursor:       c.Query("cursor"),
+		IncludeTotal: c.QueryBool("include_total", false),
+	}
+
+	var err error
+	page.Limit, err = strconv.Atoi(c.Query("limit", strconv.Itoa(defaultPageLimit)))
+	if err != nil {
+		return page, fmt.Errorf("invalid limit %q", c.Query("limit"))
+	}
+	page.Offset, err = strconv.Atoi(c.Query("offset", "0"))
+	if err != nil {
+		return page, fmt.Errorf("invalid offset %q", c.Query("offset"))
+	}
+	return page, nil
+}
+
+// countPage считает все записи запроса без учета курсора, если клиент запросил total
+func countPage(query *gorm.DB, page requests.Page) (*int64, error) {
+	if !page.IncludeTotal {
+		return nil, nil
+	}
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, err
+	}
+	return &total, nil
+}
+
+// paginate сортирует запрос, пропускает записи до курсора и запрашивает на одну запись больше
+// страницы, чтобы понять, есть ли следующая. Ошибка означает неверный курсор
+func paginate(query *gorm.DB, order pageOrder, page requests.Page) (*gorm.DB, error) {
+	columns := order.columns()
+	direction, comparison := "ASC", ">"
+	if order.Desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if page.Cursor != "" {
+		var position pagePosition
+		if err := cursor.Decode(page.Cursor, &position); err != nil {
+			return nil, err
+		}
+		values, err := order.values(position)
+		if err != nil {
+			return nil, err
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+		query = query.Where(fmt.Sprintf("(%s) %s (%s)", strings.Join(columns, ", "), comparison, placeholders), values...)
+	} else if page.Offset > 0 {
+		query = query.Offset(page.Offset * page.Limit)
+	}
+
+	for _, column := range columns {
+		query = query.Order(column + " " + direction)
+	}
+	return query.Limit(page.Limit + 1), nil
+}
+
+// finishPage отбрасывает лишнюю запись, запрошенную paginate, и собирает метаданные страницы
+func finishPage[T any](records []T, page requests.Page, total *int64, position func(T) pagePosition) ([]T, responses.PageDTO, error) {
+	dto := responses.PageDTO{
+		Limit: page.Limit,
+		Total: total,
+	}
+	if len(records) <= page.Limit {
+		return records, dto, nil
+	}
+
+	records = records[:page.Limit]
+	next, err := cursor.Encode(position(records[len(records)-1]))
+	if err != nil {
+		return nil, dto, err
+	}
+	dto.NextCursor = next
+	dto.HasMore = true
+	return records, dto, nil
+}
@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"gera-ai/internal/models/requests"
+	"gera-ai/internal/utils/cursor"
+	"gera-ai/internal/utils/jwtUtils"
+	"gera-ai/internal/utils/validator"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+	"gorm.io/gorm"
+)
+
+// pageFromQuery разбирает параметры страницы из строки запроса так же, как обработчики
+func pageFromQuery(t *testing.T, query string) (requests.Page, error) {
+	t.Helper()
+	app := fiber.New()
+	ctx := app.AcquireCtx(&fasthttp.RequestCtx{})
+	defer app.ReleaseCtx(ctx)
+	ctx.Request().SetRequestURI("/?" + query)
+	return parsePage(ctx)
+}
+
+func TestParsePage(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    requests.Page
+		wantErr bool
+	}{
+		{"defaults", "", requests.Page{Limit: 20}, false},
+		{"all parameters", "cursor=abc&limit=50&include_total=true", requests.Page{Cursor: "abc", Limit: 50, IncludeTotal: true}, false},
+		{"legacy offset", "limit=10&offset=3", requests.Page{Limit: 10, Offset: 3}, false},
+		{"limit is not a number", "limit=ten", requests.Page{}, true},
+		{"offset is not a number", "offset=1.5", requests.Page{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pageFromQuery(t, tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePage error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Fatalf("parsePage = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePageLimitValidation(t *testing.T) {
+	tests := []struct {
+		query string
+		valid bool
+	}{
+		{"limit=1", true},
+		{"limit=100", true},
+		{"limit=0", false},
+		{"limit=101", false},
+		{"limit=-5", false},
+		{"offset=-1", false},
+	}
+	for _, tt := range tests {
+		page, err := pageFromQuery(t, tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if valid := validator.ValidateStruct(page) == nil; valid != tt.valid {
+			t.Errorf("%s: valid = %v, want %v", tt.query, valid, tt.valid)
+		}
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	count := 7
+	createdAt := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	title := "Дроби"
+	want := pagePosition{Sort: "-title", Count: &count, Time: &createdAt, Text: &title, ID: 42}
+
+	value, err := cursor.Encode(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Курсор передается в строке запроса как есть
+	if regexp.MustCompile(`[^A-Za-z0-9_-]`).MatchString(value) {
+		t.Fatalf("cursor %q is not URL safe", value)
+	}
+
+	var got pagePosition
+	if err := cursor.Decode(value, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Decode = %+v, want %+v", got, want)
+	}
+}
+
+// rawCursor кодирует произвольный JSON так же, как cursor.Encode
+func rawCursor(json string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(json))
+}
+
+func TestPaginateRejectsInvalidCursor(t *testing.T) {
+	order := pageOrder{Sort: "-created_at", Field: "created_at", TimeColumn: "created_at", IDColumn: "id", Desc: true}
+	valid, err := cursor.Encode(pagePosition{Sort: "-created_at", Time: &time.Time{}, ID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "%%%"},
+		{"not json", rawCursor("created_at=2024-03-01")},
+		{"tampered byte", valid[:len(valid)-3] + "!" + valid[len(valid)-2:]},
+		{"truncated", valid[:len(valid)/2]},
+		{"wrong id type", rawCursor(`{"s":"-created_at","c":"2024-03-01T00:00:00Z","i":"1"}`)},
+		{"wrong time type", rawCursor(`{"s":"-created_at","c":1709251200,"i":1}`)},
+		{"missing time", rawCursor(`{"s":"-created_at","i":1}`)},
+		{"another sort key", rawCursor(`{"s":"title","t":"a","c":"2024-03-01T00:00:00Z","i":1}`)},
+		{"same field, other direction", rawCursor(`{"s":"created_at","c":"2024-03-01T00:00:00Z","i":1}`)},
+		{"no sort key", rawCursor(`{"c":"2024-03-01T00:00:00Z","i":1}`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := newMockDB(t)
+			_, err := paginate(db, order, requests.Page{Cursor: tt.cursor, Limit: 20})
+			if !errors.Is(err, cursor.ErrInvalidCursor) {
+				t.Fatalf("paginate error = %v, want %v", err, cursor.ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestPaginateCursorCondition(t *testing.T) {
+	db, _ := newMockDB(t)
+	db = db.Session(&gorm.Session{DryRun: true})
+
+	reportCount := 3
+	value, err := cursor.Encode(pagePosition{Count: &reportCount, ID: 11})
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := pageOrder{CountColumn: "report_count", IDColumn: "id", Desc: true}
+	query, err := paginate(db.Table("publications"), order, requests.Page{Cursor: value, Limit: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rows []map[string]interface{}
+	statement := query.Find(&rows).Statement
+	want := `SELECT * FROM "publications" WHERE (report_count, id) < ($1, $2) ORDER BY report_count DESC,id DESC LIMIT $3`
+	if got := statement.SQL.String(); got != want {
+		t.Fatalf("SQL = %s, want %s", got, want)
+	}
+	if !reflect.DeepEqual(statement.Vars, []interface{}{3, uint(11), 21}) {
+		t.Fatalf("vars = %v", statement.Vars)
+	}
+}
+
+func TestFinishPage(t *testing.T) {
+	position := func(id uint) pagePosition { return pagePosition{ID: id} }
+	page := requests.Page{Limit: 2}
+
+	records, dto, err := finishPage([]uint{5, 4, 3}, page, nil, position)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || !dto.HasMore || dto.NextCursor == "" {
+		t.Fatalf("finishPage = %v, %+v; want two records and a next cursor", records, dto)
+	}
+	var next pagePosition
+	if err := cursor.Decode(dto.NextCursor, &next); err != nil || next.ID != 4 {
+		t.Fatalf("next cursor points to %+v, %v; want id 4", next, err)
+	}
+
+	// Последняя страница: лишней записи нет, курсора тоже
+	records, dto, err = finishPage([]uint{3}, page, nil, position)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || dto.HasMore || dto.NextCursor != "" {
+		t.Fatalf("finishPage = %v, %+v; want the last page", records, dto)
+	}
+}
+
+// getTrash выполняет GET /trash/all от имени пользователя 5
+func getTrash(t *testing.T, db *gorm.DB, query string) (int, string) {
+	t.Helper()
+	app := fiber.New()
+	app.Get("/trash/all", func(c *fiber.Ctx) error {
+		jwtUtils.SetAccessToken(c, 5, "teacher", 0, "")
+		return c.Next()
+	}, GetTrash(db))
+
+	response, err := app.Test(httptest.NewRequest("GET", "/trash/all?"+query, nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return response.StatusCode, string(body)
+}
+
+func TestGetTrashPage(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	deletedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	resourceType := "task"
+	value, err := cursor.Encode(pagePosition{Time: &deletedAt, Text: &resourceType, ID: 9})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Выбранные таблицы объединяются в один запрос, курсор сравнивается со всей тройкой
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM (SELECT $1::text AS resource_type, id, title AS title, deleted_at FROM tasks WHERE author_id = $2 AND deleted_at IS NOT NULL `+
+		`UNION ALL SELECT $3::text AS resource_type, id, title AS title, deleted_at FROM condition_templates WHERE author_id = $4 AND deleted_at IS NOT NULL) AS trash `+
+		`WHERE (deleted_at, resource_type, id) < ($5, $6, $7) ORDER BY deleted_at DESC,resource_type DESC,id DESC LIMIT $8`)).
+		WithArgs("task", 5, "condition_template", 5, deletedAt, "task", 9, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"resource_type", "id", "title", "deleted_at"}).
+			AddRow("task", 4, "Дроби", deletedAt).
+			AddRow("condition_template", 9, "Проценты", deletedAt.Add(-time.Hour)))
+
+	status, body := getTrash(t, db, "type=task,condition_template&limit=1&cursor="+value)
+	if status != 200 {
+		t.Fatalf("GET = %d %s, want 200", status, body)
+	}
+	if !regexp.MustCompile(`"items":\[\{"resource_type":"task","id":4,`).MatchString(body) ||
+		!regexp.MustCompile(`"has_more":true`).MatchString(body) {
+		t.Fatalf("unexpected page %s", body)
+	}
+}
+
+func TestGetTrashInvalidCursor(t *testing.T) {
+	db, _ := newMockDB(t)
+
+	// Курсор другого списка: без текста типа ресурса. В базу запрос не уходит
+	value, err := cursor.Encode(pagePosition{Time: &time.Time{}, ID: 9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status, body := getTrash(t, db, "cursor="+value); status != 400 {
+		t.Fatalf("GET = %d %s, want 400", status, body)
+	}
+}
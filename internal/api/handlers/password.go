@@ -0,0 +1,310 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"gera-ai/internal/config"
+	dbmodels "gera-ai/internal/models/database"
+	"gera-ai/internal/models/requests"
+	"gera-ai/internal/models/responses"
+	"gera-ai/internal/utils/jwtUtils"
+	"gera-ai/internal/utils/mailer"
+	"gera-ai/internal/utils/password"
+	"gera-ai/internal/utils/token"
+	"gera-ai/internal/utils/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// passwordResetEmail - текст письма со ссылкой сброса пароля
+const passwordResetEmail = `Здравствуйте, %s!
+
+Кто-то запросил сброс пароля для вашей учетной записи %s в Gera-AI.
+Чтобы задать новый пароль, перейдите по ссылке:
+
+%s
+
+Ссылка действует до %s и сработает только один раз.
+Если вы не запрашивали сброс, просто проигнорируйте это письмо.`
+
+// ChangePassword changes the password of the logged in user
+// @Summary      Change password
+// @Description  Changes the password. The old password is required unless the user has none (registered through an external provider). Other sessions are revoked
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      requests.ChangePassword  true  "Old and new password"
+// @Success      200      {object}  responses.SessionStatusDTO
+// @Failure      400      {object}  responses.ErrorResponse
+// @Failure      401      {object}  responses.ErrorResponse
+// @Failure      422      {object}  responses.ValidationErrorResponse
+// @Failure      500      {object}  responses.ErrorResponse
+// @Router       /api/auth/password/change [put]
+func ChangePassword(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+		sessionID, err := jwtUtils.ExtractSessionID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.ChangePassword{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		var user dbmodels.User
+		if err := db.First(&user, userID).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+
+		if user.PasswordHash != "" && !password.CheckPasswordHash(data.OldPassword, user.PasswordHash) {
+			return c.Status(401).JSON(responses.ErrorResponse{
+				Status: "wrong password",
+				Error:  "old password does not match",
+			})
+		}
+
+		hashedPassword, err := password.HashPassword(data.NewPassword)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to hash password",
+				Error:  err.Error(),
+			})
+		}
+		if err := db.Model(&user).Update("password_hash", hashedPassword).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to change password",
+				Error:  err.Error(),
+			})
+		}
+
+		// Текущее устройство остается в системе, остальные выходят
+		var otherSessions []uint
+		err = db.Model(&dbmodels.Session{}).
+			Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, sessionID).
+			Pluck("id", &otherSessions).Error
+		if err == nil && len(otherSessions) > 0 {
+			_, err = revokeSessions(db, userID, otherSessions...)
+		}
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to revoke sessions",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.SessionStatusDTO{
+			Status: "password changed",
+		})
+	}
+}
+
+// RequestPasswordReset emails a password reset link
+// @Summary      Request password reset
+// @Description  Sends a single-use, expiring reset link to the email of the account found by login or email. The response is the same whether the account exists or not
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      requests.RequestPasswordReset  true  "Login or email"
+// @Success      200      {object}  responses.SessionStatusDTO
+// @Failure      400      {object}  responses.ErrorResponse
+// @Failure      422      {object}  responses.ValidationErrorResponse
+// @Failure      500      {object}  responses.ErrorResponse
+// @Router       /api/auth/password/reset/request [post]
+func RequestPasswordReset(db *gorm.DB, mail mailer.Mailer) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		data := requests.RequestPasswordReset{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		// Ответ не зависит от того, найден ли пользователь, чтобы по нему нельзя было проверять логины
+		sent := responses.SessionStatusDTO{
+			Status: "if the account exists and has an email, a reset link was sent",
+		}
+
+		var user dbmodels.User
+		result := db.Where("login = ? OR email = ?", data.Login, data.Login).First(&user)
+		if result.Error == gorm.ErrRecordNotFound || (result.Error == nil && (user.Email == nil || user.BlockedAt != nil)) {
+			return c.Status(200).JSON(sent)
+		} else if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		resetToken, err := token.Generate()
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to create reset token",
+				Error:  err.Error(),
+			})
+		}
+		expiresAt := time.Now().Add(config.Config.PasswordResetTTL)
+		err = db.Create(&dbmodels.PasswordResetToken{
+			UserID:    user.ID,
+			TokenHash: token.Hash(resetToken),
+			ExpiresAt: expiresAt,
+			CreatedAt: time.Now(),
+		}).Error
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to create reset token",
+				Error:  err.Error(),
+			})
+		}
+
+		message := mailer.Message{
+			To:      *user.Email,
+			Subject: "Сброс пароля Gera-AI",
+			Body: fmt.Sprintf(passwordResetEmail,
+				user.Username, user.Login, config.Config.PasswordResetURL+resetToken, expiresAt.Format("02.01.2006 15:04 MST")),
+		}
+		// Письмо уходит в фоне, чтобы время ответа не выдавало существование учетной записи
+		go func() {
+			if err := mail.Send(message); err != nil {
+				log.Printf("failed to send password reset to user %d: %v", user.ID, err)
+			}
+		}()
+
+		return c.Status(200).JSON(sent)
+	}
+}
+
+// ConfirmPasswordReset sets a new password with a reset token
+// @Summary      Reset password
+// @Description  Sets a new password using the token from the reset email. The token works once; all sessions of the user are revoked
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      requests.ConfirmPasswordReset  true  "Token and new password"
+// @Success      200      {object}  responses.SessionStatusDTO
+// @Failure      400      {object}  responses.ErrorResponse
+// @Failure      422      {object}  responses.ValidationErrorResponse
+// @Failure      500      {object}  responses.ErrorResponse
+// @Router       /api/auth/password/reset/confirm [post]
+func ConfirmPasswordReset(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		data := requests.ConfirmPasswordReset{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		hashedPassword, err := password.HashPassword(data.NewPassword)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to hash password",
+				Error:  err.Error(),
+			})
+		}
+
+		// Токен гасится условным обновлением, поэтому параллельный повтор не пройдет
+		var resetToken dbmodels.PasswordResetToken
+		result := db.Where("token_hash = ?", token.Hash(data.Token)).First(&resetToken)
+		if result.Error != nil && result.Error != gorm.ErrRecordNotFound {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+		if result.Error == gorm.ErrRecordNotFound || time.Now().After(resetToken.ExpiresAt) {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  "reset link is invalid or expired",
+			})
+		}
+
+		var used bool
+		err = db.Transaction(func(tx *gorm.DB) error {
+			result := tx.Model(&resetToken).Where("used_at IS NULL").Update("used_at", time.Now())
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				used = true
+				return nil
+			}
+
+			if err := tx.Model(&dbmodels.User{}).Where("id = ?", resetToken.UserID).Update("password_hash", hashedPassword).Error; err != nil {
+				return err
+			}
+			// Остальные ссылки из прошлых писем больше не нужны
+			return tx.Model(&dbmodels.PasswordResetToken{}).
+				Where("user_id = ? AND used_at IS NULL", resetToken.UserID).
+				Update("used_at", time.Now()).Error
+		})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to reset password",
+				Error:  err.Error(),
+			})
+		}
+		if used {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  "reset link is invalid or expired",
+			})
+		}
+
+		if _, err := revokeSessions(db, resetToken.UserID); err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to revoke sessions",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.SessionStatusDTO{
+			Status: "password reset",
+		})
+	}
+}
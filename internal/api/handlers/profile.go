@@ -0,0 +1,390 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gera-ai/internal/config"
+	dbmodels "gera-ai/internal/models/database"
+	"gera-ai/internal/models/requests"
+	"gera-ai/internal/models/responses"
+	"gera-ai/internal/trash"
+	"gera-ai/internal/utils/jwtUtils"
+	"gera-ai/internal/utils/password"
+	"gera-ai/internal/utils/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// deletedUsername - имя, под которым остаются публикации и записи организаций удаленного пользователя
+const deletedUsername = "Удаленный пользователь"
+
+func profileToDTO(user dbmodels.User) responses.ProfileDTO {
+	return responses.ProfileDTO{
+		ID:          user.ID,
+		Login:       user.Login,
+		Username:    user.Username,
+		Email:       user.Email,
+		Role:        user.Role,
+		Language:    user.Language,
+		Style:       user.Style,
+		HasPassword: user.PasswordHash != "",
+		CreatedAt:   user.CreatedAt,
+	}
+}
+
+// GetProfile returns the profile of the logged in user
+// @Summary Get profile
+// @Description Returns the profile of the user with the default language and story style for generation
+// @Tags Profile
+// @Produce json
+// @Success 200 {object} responses.ProfileDTO "Profile successfully retrieved"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/me [get]
+func GetProfile(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		var user dbmodels.User
+		if err := db.First(&user, userID).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(profileToDTO(user))
+	}
+}
+
+// UpdateProfile changes the profile of the logged in user
+// @Summary Update profile
+// @Description Changes the name, email and generation defaults. Only the passed fields change; an empty email removes it (password reset by email stops working)
+// @Tags Profile
+// @Accept json
+// @Produce json
+// @Param input body requests.UpdateProfile true "Profile fields"
+// @Success 200 {object} responses.EditProfileDTO "Profile updated"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 409 {object} responses.ErrorResponse "Email is taken"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/me [put]
+func UpdateProfile(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.UpdateProfile{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		var user dbmodels.User
+		if err := db.First(&user, userID).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+
+		if data.Username != nil {
+			user.Username = *data.Username
+		}
+		if data.Language != nil {
+			user.Language = *data.Language
+		}
+		if data.Style != nil {
+			user.Style = *data.Style
+		}
+		if data.Email != nil {
+			user.Email = nil
+			if *data.Email != "" {
+				var count int64
+				err := db.Unscoped().Model(&dbmodels.User{}).
+					Where("email = ? AND id <> ?", *data.Email, userID).
+					Count(&count).Error
+				if err != nil {
+					return c.Status(500).JSON(responses.ErrorResponse{
+						Status: "internal server error",
+						Error:  err.Error(),
+					})
+				}
+				if count > 0 {
+					return c.Status(409).JSON(responses.ErrorResponse{
+						Status: "email taken",
+						Error:  "another user already uses this email",
+					})
+				}
+				user.Email = data.Email
+			}
+		}
+
+		err = db.Model(&user).Select("username", "email", "language", "style").Updates(&user).Error
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to update profile",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.EditProfileDTO{
+			Status:  "profile updated",
+			Profile: profileToDTO(user),
+		})
+	}
+}
+
+// checkReauthentication проверяет, что пользователь недавно подтвердил личность:
+// паролем, если он задан, иначе входом через провайдера не раньше ReauthWindow назад
+func checkReauthentication(db *gorm.DB, c *fiber.Ctx, user dbmodels.User, pass string) (int, error) {
+	if user.PasswordHash != "" {
+		if !password.CheckPasswordHash(pass, user.PasswordHash) {
+			return 401, errors.New("password does not match")
+		}
+		return 200, nil
+	}
+
+	sessionID, err := jwtUtils.ExtractSessionID(c)
+	if err != nil {
+		return 400, err
+	}
+	var session dbmodels.Session
+	if err := db.First(&session, sessionID).Error; err != nil {
+		return 500, err
+	}
+	if time.Since(session.CreatedAt) > config.Config.ReauthWindow {
+		return 401, fmt.Errorf("log in again and retry within %s", config.Config.ReauthWindow)
+	}
+	return 200, nil
+}
+
+// handOverOrganizations передает организации пользователя самому старому администратору,
+// а если его нет - самому старому участнику. Организации без других участников удаляются,
+// их ID возвращаются
+func handOverOrganizations(tx *gorm.DB, userID uint) ([]uint, error) {
+	var organizations []dbmodels.Organization
+	if err := tx.Where("owner_id = ?", userID).Find(&organizations).Error; err != nil {
+		return nil, err
+	}
+
+	var deleted []uint
+
+	for _, organization := range organizations {
+		var successor dbmodels.OrganizationMember
+		err := tx.Where("organization_id = ? AND user_id <> ?", organization.ID, userID).
+			Order(fmt.Sprintf("role = '%s' DESC, id", dbmodels.OrganizationRoleAdmin)).
+			First(&successor).Error
+		if err == gorm.ErrRecordNotFound {
+			if err := tx.Delete(&organization).Error; err != nil {
+				return nil, err
+			}
+			deleted = append(deleted, organization.ID)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if err := tx.Model(&successor).Update("role", dbmodels.OrganizationRoleOwner).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.Model(&organization).Update("owner_id", successor.UserID).Error; err != nil {
+			return nil, err
+		}
+	}
+	return deleted, nil
+}
+
+// deleteAccount удаляет личные задачи, шаблоны и историю пользователя вместе со связями,
+// а саму учетную запись обезличивает: на нее продолжают ссылаться записи организаций,
+// публикации библиотеки и ревизии. Вызывается внутри транзакции
+func deleteAccount(tx *gorm.DB, user dbmodels.User) error {
+	deletedOrganizations, err := handOverOrganizations(tx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	// Записи в пространстве организации принадлежат ей и остаются,
+	// кроме записей удаленных организаций, которые больше никому не доступны
+	for _, resourceType := range trash.ResourceTypes {
+		resource := trash.Resources[resourceType]
+
+		query := tx.Table(resource.Table).Where(resource.OwnerColumn+" = ?", user.ID)
+		if len(deletedOrganizations) > 0 {
+			query = query.Where("organization_id IS NULL OR organization_id IN ?", deletedOrganizations)
+		} else {
+			query = query.Where("organization_id IS NULL")
+		}
+
+		var ids []uint
+		err := query.Pluck("id", &ids).Error
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		err = tx.Table(resource.Table).
+			Where("id IN ? AND deleted_at IS NULL", ids).
+			Update("deleted_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+		if err := trash.Purge(tx, resourceType, ids); err != nil {
+			return err
+		}
+	}
+
+	var tagIDs []uint
+	if err := tx.Unscoped().Model(&dbmodels.Tag{}).Where("author_id = ?", user.ID).Pluck("id", &tagIDs).Error; err != nil {
+		return err
+	}
+	if len(tagIDs) > 0 {
+		for _, statement := range []string{
+			"DELETE FROM task_tags WHERE tag_id IN ?",
+			"DELETE FROM condition_template_tags WHERE tag_id IN ?",
+			"DELETE FROM interests_template_tags WHERE tag_id IN ?",
+			"DELETE FROM tags WHERE id IN ?",
+		} {
+			if err := tx.Exec(statement, tagIDs).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err := revokeSessions(tx, user.ID); err != nil {
+		return err
+	}
+	for _, model := range []interface{}{
+		&dbmodels.GenerationFeedback{},
+		&dbmodels.Share{},
+		&dbmodels.OrganizationMember{},
+		&dbmodels.ExternalIdentity{},
+		&dbmodels.AccessToken{},
+		&dbmodels.PasswordResetToken{},
+	} {
+		if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Where("link_user_id = ?", user.ID).Delete(&dbmodels.OAuthState{}).Error; err != nil {
+		return err
+	}
+	if err := resetLoginFailures(tx, user.Login); err != nil {
+		return err
+	}
+
+	// Готовые архивы перестают скачиваться сразу, файлы удалит фоновая очистка
+	err = tx.Model(&dbmodels.DataExport{}).
+		Where("user_id = ? AND status = ?", user.ID, dbmodels.DataExportReady).
+		Update("expires_at", time.Now()).Error
+	if err != nil {
+		return err
+	}
+	err = tx.Model(&dbmodels.DataExport{}).
+		Where("user_id = ? AND status IN ?", user.ID, []string{dbmodels.DataExportPending, dbmodels.DataExportProcessing}).
+		Updates(map[string]interface{}{"status": dbmodels.DataExportFailed, "error": "account deleted"}).Error
+	if err != nil {
+		return err
+	}
+
+	// Логин с подчеркиванием нельзя зарегистрировать, поэтому он не пересечется с новыми
+	return tx.Unscoped().Model(&user).Updates(map[string]interface{}{
+		"login":         fmt.Sprintf("deleted_%d", user.ID),
+		"username":      deletedUsername,
+		"email":         nil,
+		"password_hash": "",
+		"deleted_at":    time.Now(),
+	}).Error
+}
+
+// DeleteAccount deletes the account of the logged in user
+// @Summary Delete account
+// @Description Permanently deletes personal tasks, templates, tags, history and feedback and anonymizes the account. Records in organization workspaces and library publications stay under an anonymous author; owned organizations pass to the oldest admin or member. Requires the password, or a login within the re-authentication window for accounts without one
+// @Tags Profile
+// @Accept json
+// @Produce json
+// @Param input body requests.DeleteAccount true "Password"
+// @Success 200 {object} responses.DeleteAccountDTO "Account deleted"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 401 {object} responses.ErrorResponse "Re-authentication failed"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/me [delete]
+func DeleteAccount(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.DeleteAccount{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		var user dbmodels.User
+		if err := db.First(&user, userID).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+
+		if status, err := checkReauthentication(db, c, user, data.Password); err != nil {
+			return c.Status(status).JSON(responses.ErrorResponse{
+				Status: "re-authentication required",
+				Error:  err.Error(),
+			})
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			return deleteAccount(tx, user)
+		})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to delete account",
+				Error:  err.Error(),
+			})
+		}
+
+		recordAudit(db, dbmodels.AuditAccountDeleted, &user.ID, c.IP(),
+			fmt.Sprintf("user %q deleted the account", user.Login))
+
+		return c.Status(200).JSON(responses.DeleteAccountDTO{
+			Status: "account deleted",
+		})
+	}
+}
@@ -0,0 +1,401 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	dbmodels "gera-ai/internal/models/database"
+	"gera-ai/internal/models/requests"
+	"gera-ai/internal/models/responses"
+	"gera-ai/internal/utils/diff"
+	"gera-ai/internal/utils/jwtUtils"
+	"gera-ai/internal/utils/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// taskRevision делает снимок задачи
+func taskRevision(task dbmodels.Task, authorID uint) dbmodels.Revision {
+	return dbmodels.Revision{
+		ResourceType: dbmodels.RevisionResourceTask,
+		ResourceID:   task.ID,
+		AuthorID:     authorID,
+		Title:        task.Title,
+		Condition:    task.Condition,
+		Answer:       task.Answer,
+	}
+}
+
+// conditionTemplateRevision делает снимок шаблона условия
+func conditionTemplateRevision(template dbmodels.ConditionTemplate, authorID uint) dbmodels.Revision {
+	return dbmodels.Revision{
+		ResourceType: dbmodels.RevisionResourceConditionTemplate,
+		ResourceID:   template.ID,
+		AuthorID:     authorID,
+		Title:        template.Title,
+		Condition:    template.Condition,
+	}
+}
+
+// recordRevision сохраняет снимок под следующим номером
+func recordRevision(tx *gorm.DB, revision *dbmodels.Revision) error {
+	var last int
+	err := tx.Model(&dbmodels.Revision{}).
+		Where("resource_type = ? AND resource_id = ?", revision.ResourceType, revision.ResourceID).
+		Select("COALESCE(MAX(number), 0)").
+		Scan(&last).Error
+	if err != nil {
+		return err
+	}
+
+	revision.Number = last + 1
+	revision.CreatedAt = time.Now()
+	return tx.Create(revision).Error
+}
+
+// ensureBaseRevision сохраняет текущее состояние как первую ревизию, если истории еще нет.
+// Нужно для задач и шаблонов, созданных до появления ревизий
+func ensureBaseRevision(tx *gorm.DB, revision dbmodels.Revision) error {
+	var count int64
+	err := tx.Model(&dbmodels.Revision{}).
+		Where("resource_type = ? AND resource_id = ?", revision.ResourceType, revision.ResourceID).
+		Count(&count).Error
+	if err != nil || count > 0 {
+		return err
+	}
+	return recordRevision(tx, &revision)
+}
+
+func revisionToDTO(revision dbmodels.Revision) responses.RevisionDTO {
+	return responses.RevisionDTO{
+		Number:       revision.Number,
+		AuthorID:     revision.AuthorID,
+		Title:        revision.Title,
+		Condition:    revision.Condition,
+		Answer:       revision.Answer,
+		RestoredFrom: revision.RestoredFrom,
+		CreatedAt:    revision.CreatedAt,
+	}
+}
+
+// parseRevisionedResource читает тип и ID сущности из пути
+func parseRevisionedResource(c *fiber.Ctx) (string, uint, error) {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id <= 0 {
+		return "", 0, fmt.Errorf("ID must be a positive integer")
+	}
+	return c.Params("type"), uint(id), nil
+}
+
+// GetRevisions lists the revisions of a task or condition template
+// @Summary List revisions
+// @Description Returns revisions of a task or condition template, newest first
+// @Tags Revisions
+// @Produce json
+// @Param type path string true "Resource type" Enums(task, condition_template)
+// @Param id path int true "Resource ID"
+// @Param cursor query string false "Cursor returned as page.next_cursor by the previous page"
+// @Param limit query int false "Page size (default is 20, max is 100)"
+// @Param include_total query bool false "Count all items and return page.total"
+// @Success 200 {object} responses.GetRevisionsDTO "Revisions successfully retrieved"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or ID"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "Resource not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/revision/all/{type}/{id} [get]
+func GetRevisions(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		resourceType, id, err := parseRevisionedResource(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid ID",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.GetRevisions{ResourceType: resourceType, ID: id}
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		page, err := parsePage(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid page",
+				Error:  err.Error(),
+			})
+		}
+		if validationErrors := validator.ValidateStruct(page); validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		if status, err := checkResourceViewer(db, userID, data.ResourceType, data.ID); err != nil {
+			return c.Status(status).JSON(responses.ErrorResponse{
+				Status: "resource unavailable",
+				Error:  err.Error(),
+			})
+		}
+
+		query := db.Model(&dbmodels.Revision{}).Where("resource_type = ? AND resource_id = ?", data.ResourceType, data.ID)
+		total, err := countPage(query, page)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		order := pageOrder{CountColumn: "number", IDColumn: "id", Desc: true}
+		query, err = paginate(query, order, page)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid cursor",
+				Error:  err.Error(),
+			})
+		}
+
+		var revisions []dbmodels.Revision
+		if err := query.Find(&revisions).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+
+		revisions, pageDTO, err := finishPage(revisions, page, total, func(revision dbmodels.Revision) pagePosition {
+			return pagePosition{Count: &revision.Number, ID: revision.ID}
+		})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to encode cursor",
+				Error:  err.Error(),
+			})
+		}
+
+		revisionsDTO := make([]responses.RevisionDTO, len(revisions))
+		for i, revision := range revisions {
+			revisionsDTO[i] = revisionToDTO(revision)
+		}
+
+		return c.Status(200).JSON(responses.GetRevisionsDTO{
+			Revisions: revisionsDTO,
+			Page:      pageDTO,
+		})
+	}
+}
+
+// DiffRevisions shows a word-level diff between two revisions
+// @Summary Diff two revisions
+// @Description Returns a word-level diff of the title, condition and answer between two revisions
+// @Tags Revisions
+// @Produce json
+// @Param type path string true "Resource type" Enums(task, condition_template)
+// @Param id path int true "Resource ID"
+// @Param from query int true "Older revision number"
+// @Param to query int true "Newer revision number"
+// @Success 200 {object} responses.RevisionDiffDTO "Diff successfully built"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or ID"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "Resource or revision not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/revision/diff/{type}/{id} [get]
+func DiffRevisions(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		resourceType, id, err := parseRevisionedResource(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid ID",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.DiffRevisions{
+			ResourceType: resourceType,
+			ID:           id,
+			From:         c.QueryInt("from"),
+			To:           c.QueryInt("to"),
+		}
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		if status, err := checkResourceViewer(db, userID, data.ResourceType, data.ID); err != nil {
+			return c.Status(status).JSON(responses.ErrorResponse{
+				Status: "resource unavailable",
+				Error:  err.Error(),
+			})
+		}
+
+		var revisions []dbmodels.Revision
+		result := db.Where("resource_type = ? AND resource_id = ? AND number IN ?", data.ResourceType, data.ID, []int{data.From, data.To}).
+			Find(&revisions)
+		if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		found := make(map[int]dbmodels.Revision, len(revisions))
+		for _, revision := range revisions {
+			found[revision.Number] = revision
+		}
+		from, okFrom := found[data.From]
+		to, okTo := found[data.To]
+		if !okFrom || !okTo {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "revision not found",
+			})
+		}
+
+		response := responses.RevisionDiffDTO{
+			From:      from.Number,
+			To:        to.Number,
+			Title:     diff.Words(from.Title, to.Title),
+			Condition: diff.Words(from.Condition, to.Condition),
+		}
+		if data.ResourceType == dbmodels.RevisionResourceTask {
+			response.Answer = diff.Words(from.Answer, to.Answer)
+		}
+
+		return c.Status(200).JSON(response)
+	}
+}
+
+// RestoreRevision restores a task or condition template to an old revision
+// @Summary Restore a revision
+// @Description Replaces the current content with the content of an old revision. The restore itself is recorded as a new revision
+// @Tags Revisions
+// @Accept json
+// @Produce json
+// @Param input body requests.RestoreRevision true "Revision to restore"
+// @Success 200 {object} responses.RestoreRevisionDTO "Revision successfully restored"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "Resource or revision not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/revision/restore [post]
+func RestoreRevision(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.RestoreRevision{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		if status, err := checkResourceEditor(db, userID, data.ResourceType, data.ID); err != nil {
+			return c.Status(status).JSON(responses.ErrorResponse{
+				Status: "resource unavailable",
+				Error:  err.Error(),
+			})
+		}
+
+		var old dbmodels.Revision
+		result := db.Where("resource_type = ? AND resource_id = ? AND number = ?", data.ResourceType, data.ID, data.Number).
+			Take(&old)
+		if result.Error == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "revision not found",
+			})
+		} else if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		var restored dbmodels.Revision
+		err = db.Transaction(func(tx *gorm.DB) error {
+			switch data.ResourceType {
+			case dbmodels.RevisionResourceTask:
+				var task dbmodels.Task
+				if err := tx.First(&task, data.ID).Error; err != nil {
+					return err
+				}
+				task.Title = old.Title
+				task.Condition = old.Condition
+				task.Answer = old.Answer
+				task.UpdatedAt = time.Now()
+				if err := tx.Save(&task).Error; err != nil {
+					return err
+				}
+				restored = taskRevision(task, userID)
+			case dbmodels.RevisionResourceConditionTemplate:
+				var template dbmodels.ConditionTemplate
+				if err := tx.First(&template, data.ID).Error; err != nil {
+					return err
+				}
+				template.Title = old.Title
+				template.Condition = old.Condition
+				template.UpdatedAt = time.Now()
+				if err := tx.Save(&template).Error; err != nil {
+					return err
+				}
+				restored = conditionTemplateRevision(template, userID)
+			}
+
+			restored.RestoredFrom = &old.Number
+			return recordRevision(tx, &restored)
+		})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to restore revision",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.RestoreRevisionDTO{
+			Status:   "revision restored",
+			Revision: revisionToDTO(restored),
+		})
+	}
+}
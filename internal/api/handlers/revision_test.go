@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	dbmodels "gera-ai/internal/models/database"
+	"gera-ai/internal/utils/jwtUtils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+)
+
+// expectSharedTask ожидает проверку доступа к задаче 7 автора 1, которой поделились с пользователем 5
+func expectSharedTask(mock sqlmock.Sqlmock, role string) {
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT author_id, organization_id FROM "tasks" WHERE id = $1 AND deleted_at IS NULL`)).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"author_id", "organization_id"}).AddRow(1, nil))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "role" FROM "shares" WHERE (resource_type = $1 AND resource_id = $2 AND user_id = $3)`)).
+		WithArgs("task", 7, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow(role))
+}
+
+func TestDiffRevisionsAllowsViewer(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	app := fiber.New()
+	app.Get("/revision/diff/:type/:id", func(c *fiber.Ctx) error {
+		jwtUtils.SetAccessToken(c, 5, "teacher", 0, "")
+		return c.Next()
+	}, DiffRevisions(db))
+
+	expectSharedTask(mock, dbmodels.ShareRoleViewer)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "revisions" WHERE resource_type = $1 AND resource_id = $2 AND number IN ($3,$4)`)).
+		WithArgs("task", 7, 1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"number", "title", "condition", "answer"}).
+			AddRow(1, "Дроби", "1/2 + 1/4", "3/4").
+			AddRow(2, "Дроби", "1/2 + 1/3", "5/6"))
+
+	response, err := app.Test(httptest.NewRequest("GET", "/revision/diff/task/7?from=1&to=2", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != 200 {
+		t.Fatalf("diff = %d, want 200", response.StatusCode)
+	}
+}
+
+func TestRestoreRevisionNeedsEditor(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	app := fiber.New()
+	app.Post("/revision/restore", func(c *fiber.Ctx) error {
+		jwtUtils.SetAccessToken(c, 5, "teacher", 0, "")
+		return c.Next()
+	}, RestoreRevision(db))
+
+	// Зритель видит ревизии, но восстановить старую не может
+	expectSharedTask(mock, dbmodels.ShareRoleViewer)
+
+	request := httptest.NewRequest("POST", "/revision/restore", strings.NewReader(`{"resource_type": "task", "id": 7, "number": 1}`))
+	request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	response, err := app.Test(request, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != 403 {
+		t.Fatalf("restore = %d, want 403", response.StatusCode)
+	}
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gera-ai/internal/models/requests"
+	"gera-ai/internal/models/responses"
+	"gera-ai/internal/utils/jwtUtils"
+	"gera-ai/internal/utils/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// searchSources - таблицы, по которым ищет /api/search, и текст, из которого строится сниппет
+var searchSources = []struct {
+	resultType string
+	table      string
+	body       string
+}{
+	{resultType: "task", table: "tasks", body: "condition"},
+	{resultType: "condition_template", table: "condition_templates", body: "condition"},
+	{resultType: "interests_template", table: "interests_templates", body: "interests::text"},
+}
+
+const searchHeadlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2"
+
+// searchHeadline строит выделение для column. Запрос совпадает по русской или английской морфологии,
+// поэтому если русская конфигурация ничего не выделила, выделение строится английской
+func searchHeadline(column string) string {
+	russian := fmt.Sprintf("ts_headline('russian', %s, q.query, @options)", column)
+	return fmt.Sprintf("CASE WHEN strpos(%[1]s, '<mark>') > 0 THEN %[1]s ELSE ts_headline('english', %[2]s, q.query, @options) END", russian, column)
+}
+
+// Search performs a full-text search over tasks and templates
+// @Summary Full-text search
+// @Description Searches the user's tasks and templates using Russian and English stemming. Results are ranked, matches are wrapped in <mark>
+// @Tags Search
+// @Produce json
+// @Param q query string true "Search query (web search syntax: quotes, OR, -)"
+// @Param type query string false "Comma separated result types" Enums(task, condition_template, interests_template)
+// @Param limit query int false "Maximum number of results (default is 20, max is 100)"
+// @Success 200 {object} responses.SearchDTO "Search results"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or query"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/search [get]
+func Search(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		authorID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.Search{Query: strings.TrimSpace(c.Query("q"))}
+		if types := c.Query("type"); types != "" {
+			data.Types = strings.Split(types, ",")
+		}
+		data.Limit, err = strconv.Atoi(c.Query("limit", "20"))
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid limit",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		selected := make(map[string]bool, len(data.Types))
+		for _, resultType := range data.Types {
+			selected[resultType] = true
+		}
+
+		var parts []string
+		for _, source := range searchSources {
+			if len(selected) > 0 && !selected[source.resultType] {
+				continue
+			}
+			parts = append(parts, fmt.Sprintf(
+				`SELECT '%[1]s' AS type, s.id, s.title,
+					ts_rank(s.search_vector, q.query) AS rank,
+					%[3]s AS title_highlight,
+					%[4]s AS snippet
+				FROM %[2]s AS s, q
+				WHERE s.author_id = @author AND s.deleted_at IS NULL AND s.search_vector @@ q.query`,
+				source.resultType, source.table, searchHeadline("s.title"), searchHeadline("s."+source.body),
+			))
+		}
+
+		query := `WITH q AS (
+				SELECT websearch_to_tsquery('russian', @query) || websearch_to_tsquery('english', @query) AS query
+			) ` + strings.Join(parts, " UNION ALL ") + ` ORDER BY rank DESC, id DESC LIMIT @limit`
+
+		results := []responses.SearchResultDTO{}
+		result := db.Raw(query, map[string]interface{}{
+			"query":   data.Query,
+			"author":  authorID,
+			"options": searchHeadlineOptions,
+			"limit":   data.Limit,
+		}).Scan(&results)
+		if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.SearchDTO{
+			Results: results,
+		})
+	}
+}
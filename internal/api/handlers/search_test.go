@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"gera-ai/internal/utils/jwtUtils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSearchHighlightsEnglishMatches(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	app := fiber.New()
+	app.Get("/search", func(c *fiber.Ctx) error {
+		jwtUtils.SetAccessToken(c, 5, "teacher", 0, "")
+		return c.Next()
+	}, Search(db))
+
+	// Запрос совпадает и по английской морфологии, поэтому русское выделение без <mark> заменяется английским
+	mock.ExpectQuery(regexp.QuoteMeta(`CASE WHEN strpos(ts_headline('russian', s.condition, q.query, $`) + `\d+` +
+		regexp.QuoteMeta(`), '<mark>') > 0 THEN ts_headline('russian', s.condition, q.query, $`) + `\d+` +
+		regexp.QuoteMeta(`) ELSE ts_headline('english', s.condition, q.query, $`)).
+		WillReturnRows(sqlmock.NewRows([]string{"type", "id", "title", "rank", "title_highlight", "snippet"}))
+
+	response, err := app.Test(httptest.NewRequest("GET", "/search?q=equations&type=task", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != 200 {
+		t.Fatalf("search = %d, want 200", response.StatusCode)
+	}
+}
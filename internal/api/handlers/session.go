@@ -0,0 +1,443 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gera-ai/internal/config"
+	dbmodels "gera-ai/internal/models/database"
+	"gera-ai/internal/models/requests"
+	"gera-ai/internal/models/responses"
+	"gera-ai/internal/utils/jwtUtils"
+	"gera-ai/internal/utils/token"
+	"gera-ai/internal/utils/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+var errRefreshTokenReused = errors.New("refresh token reuse detected, session revoked")
+
+// issueAccessToken выпускает короткоживущий JWT с ID, ролью пользователя и ID сессии
+func issueAccessToken(user dbmodels.User, sessionID uint) (string, time.Time, error) {
+	expiresAt := time.Now().Add(config.Config.JWTExpiration)
+	claims := jwt.MapClaims{
+		"id":   fmt.Sprint(user.ID),
+		"role": user.Role,
+		"sid":  fmt.Sprint(sessionID),
+		"exp":  expiresAt.Unix(),
+		"iat":  time.Now().Unix(),
+	}
+	signedToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(config.Config.JWTSecret))
+	return signedToken, expiresAt, err
+}
+
+// newRefreshToken создает refresh токен сессии и сохраняет его хеш
+func newRefreshToken(tx *gorm.DB, sessionID uint) (string, error) {
+	refreshToken, err := token.Generate()
+	if err != nil {
+		return "", err
+	}
+	err = tx.Create(&dbmodels.RefreshToken{
+		SessionID: sessionID,
+		TokenHash: token.Hash(refreshToken),
+		CreatedAt: time.Now(),
+	}).Error
+	return refreshToken, err
+}
+
+// issueTokens выпускает пару access и refresh токенов для сессии
+func issueTokens(tx *gorm.DB, user dbmodels.User, sessionID uint) (responses.AuthDTO, error) {
+	refreshToken, err := newRefreshToken(tx, sessionID)
+	if err != nil {
+		return responses.AuthDTO{}, err
+	}
+	accessToken, expiresAt, err := issueAccessToken(user, sessionID)
+	if err != nil {
+		return responses.AuthDTO{}, err
+	}
+	return responses.AuthDTO{
+		Token:        accessToken,
+		ExpiresAt:    expiresAt,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// startSession создает сессию для устройства, с которого пришел запрос
+func startSession(db *gorm.DB, c *fiber.Ctx, user dbmodels.User) (responses.AuthDTO, error) {
+	var auth responses.AuthDTO
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		session := dbmodels.Session{
+			UserID:     user.ID,
+			UserAgent:  truncateRunes(c.Get(fiber.HeaderUserAgent), 300),
+			IP:         c.IP(),
+			LastUsedAt: now,
+			ExpiresAt:  now.Add(config.Config.RefreshExpiration),
+			CreatedAt:  now,
+		}
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+
+		var err error
+		auth, err = issueTokens(tx, user, session.ID)
+		return err
+	})
+	return auth, err
+}
+
+// revokeSessions завершает сессии пользователя и удаляет их refresh токены.
+// Без sessionIDs завершаются все сессии
+func revokeSessions(db *gorm.DB, userID uint, sessionIDs ...uint) (int64, error) {
+	var revoked int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&dbmodels.Session{}).Where("user_id = ? AND revoked_at IS NULL", userID)
+		if len(sessionIDs) > 0 {
+			query = query.Where("id IN ?", sessionIDs)
+		}
+
+		var ids []uint
+		if err := query.Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		if err := tx.Model(&dbmodels.Session{}).Where("id IN ?", ids).Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		revoked = int64(len(ids))
+		return tx.Unscoped().Where("session_id IN ?", ids).Delete(&dbmodels.RefreshToken{}).Error
+	})
+	return revoked, err
+}
+
+// rotateRefreshToken меняет refresh токен на новую пару токенов.
+// Возвращает HTTP статус, с которым нужно ответить при ошибке
+func rotateRefreshToken(db *gorm.DB, refreshToken string) (responses.AuthDTO, int, error) {
+	var stored dbmodels.RefreshToken
+	result := db.Preload("Session.User").Where("token_hash = ?", token.Hash(refreshToken)).First(&stored)
+	if result.Error == gorm.ErrRecordNotFound {
+		return responses.AuthDTO{}, 401, errors.New("invalid refresh token")
+	} else if result.Error != nil {
+		return responses.AuthDTO{}, 500, result.Error
+	}
+
+	session := stored.Session
+	now := time.Now()
+	if session.RevokedAt != nil || now.After(session.ExpiresAt) {
+		return responses.AuthDTO{}, 401, errors.New("session expired")
+	}
+	if session.User.ID == 0 || session.User.BlockedAt != nil {
+		if _, err := revokeSessions(db, session.UserID, session.ID); err != nil {
+			return responses.AuthDTO{}, 500, err
+		}
+		return responses.AuthDTO{}, 403, errors.New("account is blocked")
+	}
+
+	// Refresh токен живет столько же, сколько сессия после его выпуска. Использованные токены
+	// хранятся весь этот срок, поэтому повторное использование обнаруживается, пока токен действует
+	if stored.UsedAt == nil && now.After(stored.CreatedAt.Add(config.Config.RefreshExpiration)) {
+		return responses.AuthDTO{}, 401, errors.New("refresh token expired")
+	}
+
+	// Токен помечается использованным условно, чтобы два параллельных обновления
+	// одним токеном тоже считались повторным использованием
+	// Model без загруженных связей, иначе gorm заодно сохраняет сессию и пользователя
+	result = db.Model(&dbmodels.RefreshToken{}).Where("id = ? AND used_at IS NULL", stored.ID).Update("used_at", now)
+	if result.Error != nil {
+		return responses.AuthDTO{}, 500, result.Error
+	}
+	if result.RowsAffected == 0 {
+		if _, err := revokeSessions(db, session.UserID, session.ID); err != nil {
+			return responses.AuthDTO{}, 500, err
+		}
+		return responses.AuthDTO{}, 401, errRefreshTokenReused
+	}
+
+	var auth responses.AuthDTO
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&dbmodels.Session{}).Where("id = ?", session.ID).Updates(map[string]interface{}{
+			"last_used_at": now,
+			"expires_at":   now.Add(config.Config.RefreshExpiration),
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Unscoped().
+			Where("session_id = ? AND used_at < ?", session.ID, now.Add(-config.Config.RefreshExpiration)).
+			Delete(&dbmodels.RefreshToken{}).Error
+		if err != nil {
+			return err
+		}
+
+		auth, err = issueTokens(tx, session.User, session.ID)
+		return err
+	})
+	if err != nil {
+		return responses.AuthDTO{}, 500, err
+	}
+	return auth, 200, nil
+}
+
+// RefreshSession exchanges a refresh token for a new token pair
+// @Summary      Refresh tokens
+// @Description  Exchanges a refresh token for a new access token and a new refresh token. Each refresh token can be used once: reusing it revokes the whole session
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      requests.RefreshToken  true  "Refresh token"
+// @Success      200      {object}  responses.AuthDTO
+// @Failure      400      {object}  responses.ErrorResponse
+// @Failure      401      {object}  responses.ErrorResponse
+// @Failure      403      {object}  responses.ErrorResponse
+// @Failure      422      {object}  responses.ValidationErrorResponse
+// @Failure      500      {object}  responses.ErrorResponse
+// @Router       /api/auth/refresh [post]
+func RefreshSession(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		data := requests.RefreshToken{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		auth, status, err := rotateRefreshToken(db, data.RefreshToken)
+		if err != nil {
+			return c.Status(status).JSON(responses.ErrorResponse{
+				Status: "refresh failed",
+				Error:  err.Error(),
+			})
+		}
+
+		auth.Status = "ok"
+		return c.Status(200).JSON(auth)
+	}
+}
+
+// Logout ends the current session
+// @Summary      Logout
+// @Description  Revokes the session of the access token. With all=true revokes every session of the user
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      requests.Logout  false  "Logout options"
+// @Success      200      {object}  responses.SessionStatusDTO
+// @Failure      400      {object}  responses.ErrorResponse
+// @Failure      500      {object}  responses.ErrorResponse
+// @Router       /api/auth/logout [post]
+func Logout(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+		sessionID, err := jwtUtils.ExtractSessionID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.Logout{}
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(&data); err != nil {
+				return c.Status(400).JSON(responses.ErrorResponse{
+					Status: "invalid json",
+					Error:  err.Error(),
+				})
+			}
+		}
+
+		if data.All {
+			_, err = revokeSessions(db, userID)
+		} else {
+			_, err = revokeSessions(db, userID, sessionID)
+		}
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to logout",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.SessionStatusDTO{
+			Status: "logged out",
+		})
+	}
+}
+
+// GetSessions lists active sessions of the user
+// @Summary      List sessions
+// @Description  Returns active sessions (devices) of the user, most recently used first
+// @Tags         Auth
+// @Produce      json
+// @Param        cursor         query     string  false  "Cursor returned as page.next_cursor by the previous page"
+// @Param        limit          query     int     false  "Page size (default is 20, max is 100)"
+// @Param        include_total  query     bool    false  "Count all sessions and return page.total"
+// @Success      200      {object}  responses.GetSessionsDTO
+// @Failure      400      {object}  responses.ErrorResponse
+// @Failure      500      {object}  responses.ErrorResponse
+// @Router       /api/auth/sessions [get]
+func GetSessions(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+		currentID, err := jwtUtils.ExtractSessionID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		page, err := parsePage(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid page",
+				Error:  err.Error(),
+			})
+		}
+		if validationErrors := validator.ValidateStruct(page); validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		query := db.Model(&dbmodels.Session{}).Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now())
+		total, err := countPage(query, page)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		order := pageOrder{TimeColumn: "last_used_at", IDColumn: "id", Desc: true}
+		query, err = paginate(query, order, page)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid cursor",
+				Error:  err.Error(),
+			})
+		}
+
+		var sessions []dbmodels.Session
+		if err := query.Find(&sessions).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+
+		sessions, pageDTO, err := finishPage(sessions, page, total, func(session dbmodels.Session) pagePosition {
+			return pagePosition{Time: &session.LastUsedAt, ID: session.ID}
+		})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to encode cursor",
+				Error:  err.Error(),
+			})
+		}
+
+		sessionsDTO := make([]responses.SessionDTO, len(sessions))
+		for i, session := range sessions {
+			sessionsDTO[i] = responses.SessionDTO{
+				ID:         session.ID,
+				UserAgent:  session.UserAgent,
+				IP:         session.IP,
+				Current:    session.ID == currentID,
+				CreatedAt:  session.CreatedAt,
+				LastUsedAt: session.LastUsedAt,
+				ExpiresAt:  session.ExpiresAt,
+			}
+		}
+
+		return c.Status(200).JSON(responses.GetSessionsDTO{
+			Sessions: sessionsDTO,
+			Page:     pageDTO,
+		})
+	}
+}
+
+// RevokeSession ends one session of the user
+// @Summary      Revoke a session
+// @Description  Logs out a device. Its refresh token stops working immediately, its access token is rejected on the next request
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      requests.RevokeSession  true  "Session"
+// @Success      200      {object}  responses.SessionStatusDTO
+// @Failure      400      {object}  responses.ErrorResponse
+// @Failure      404      {object}  responses.ErrorResponse
+// @Failure      422      {object}  responses.ValidationErrorResponse
+// @Failure      500      {object}  responses.ErrorResponse
+// @Router       /api/auth/sessions/revoke [delete]
+func RevokeSession(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.RevokeSession{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		revoked, err := revokeSessions(db, userID, data.ID)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to revoke session",
+				Error:  err.Error(),
+			})
+		}
+		if revoked == 0 {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "session not found",
+			})
+		}
+
+		return c.Status(200).JSON(responses.SessionStatusDTO{
+			Status: "session revoked",
+		})
+	}
+}
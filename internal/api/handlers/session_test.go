@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"gera-ai/internal/config"
+	dbmodels "gera-ai/internal/models/database"
+	"gera-ai/internal/utils/token"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+const testRefreshToken = "refresh-token"
+
+func setRefreshConfig(t *testing.T) {
+	t.Helper()
+	previous := config.Config
+	config.Config.JWTSecret = "test-secret"
+	config.Config.JWTExpiration = time.Minute * 15
+	config.Config.RefreshExpiration = time.Hour * 24 * 30
+	t.Cleanup(func() { config.Config = previous })
+}
+
+// expectStoredRefreshToken ожидает поиск refresh токена вместе с сессией и пользователем
+func expectStoredRefreshToken(mock sqlmock.Sqlmock, createdAt time.Time, usedAt *time.Time) {
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "refresh_tokens" WHERE token_hash = $1`)).
+		WithArgs(token.Hash(testRefreshToken), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "session_id", "token_hash", "used_at", "created_at"}).
+			AddRow(7, 3, token.Hash(testRefreshToken), usedAt, createdAt))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "sessions" WHERE "sessions"."id" = $1`)).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "expires_at", "revoked_at"}).
+			AddRow(3, 5, time.Now().Add(time.Hour), nil))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE "users"."id" = $1`)).
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "login", "role", "blocked_at"}).
+			AddRow(5, "teacher", dbmodels.UserRoleTeacher, nil))
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	setRefreshConfig(t)
+	db, mock := newMockDB(t)
+
+	expectStoredRefreshToken(mock, time.Now().Add(-time.Hour), nil)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "refresh_tokens" SET "used_at"=$1,"updated_at"=$2 WHERE (id = $3 AND used_at IS NULL) AND "refresh_tokens"."deleted_at" IS NULL`)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "sessions" SET "expires_at"=$1,"last_used_at"=$2,"updated_at"=$3`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// Использованные токены удаляются только после срока жизни refresh токена
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "refresh_tokens" WHERE session_id = $1 AND used_at < $2`)).
+		WithArgs(3, timeNear{time.Now().Add(-config.Config.RefreshExpiration)}).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "refresh_tokens"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(8))
+	mock.ExpectCommit()
+
+	auth, status, err := rotateRefreshToken(db, testRefreshToken)
+	if err != nil || status != 200 {
+		t.Fatalf("rotateRefreshToken = %d, %v", status, err)
+	}
+	if auth.Token == "" || auth.RefreshToken == "" || auth.RefreshToken == testRefreshToken {
+		t.Fatalf("expected a new token pair, got %+v", auth)
+	}
+}
+
+func TestRotateRefreshTokenReuseRevokesSession(t *testing.T) {
+	setRefreshConfig(t)
+	db, mock := newMockDB(t)
+
+	// Токен уже использован месяц назад, но все еще хранится: повторное использование завершает сессию
+	usedAt := time.Now().Add(-time.Hour * 24 * 29)
+	expectStoredRefreshToken(mock, usedAt.Add(-time.Hour), &usedAt)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "refresh_tokens" SET "used_at"=$1`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "sessions" WHERE (user_id = $1 AND revoked_at IS NULL) AND id IN ($2)`)).
+		WithArgs(5, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "sessions" SET "revoked_at"=$1`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "refresh_tokens" WHERE session_id IN ($1)`)).
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	_, status, err := rotateRefreshToken(db, testRefreshToken)
+	if status != 401 || err != errRefreshTokenReused {
+		t.Fatalf("rotateRefreshToken = %d, %v; want 401, %v", status, err, errRefreshTokenReused)
+	}
+}
+
+func TestRotateRefreshTokenExpired(t *testing.T) {
+	setRefreshConfig(t)
+	db, mock := newMockDB(t)
+
+	// Неиспользованный токен старше срока жизни отклоняется без изменений в базе
+	expectStoredRefreshToken(mock, time.Now().Add(-config.Config.RefreshExpiration-time.Minute), nil)
+
+	_, status, err := rotateRefreshToken(db, testRefreshToken)
+	if status != 401 || err == nil || err.Error() != "refresh token expired" {
+		t.Fatalf("rotateRefreshToken = %d, %v; want 401, refresh token expired", status, err)
+	}
+}
+
+func TestRotateRefreshTokenUnknown(t *testing.T) {
+	setRefreshConfig(t)
+	db, mock := newMockDB(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "refresh_tokens" WHERE token_hash = $1`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, status, err := rotateRefreshToken(db, testRefreshToken)
+	if status != 401 || err == nil {
+		t.Fatalf("rotateRefreshToken = %d, %v; want 401", status, err)
+	}
+}
@@ -0,0 +1,779 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	dbmodels "gera-ai/internal/models/database"
+	"gera-ai/internal/models/requests"
+	"gera-ai/internal/models/responses"
+	"gera-ai/internal/utils/jsonUtils"
+	"gera-ai/internal/utils/jwtUtils"
+	"gera-ai/internal/utils/token"
+	"gera-ai/internal/utils/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Уровни доступа к задаче или шаблону, каждый следующий включает предыдущие
+const (
+	accessNone = iota
+	accessViewer
+	accessEditor
+	accessOwner
+)
+
+// resourceAccess возвращает уровень доступа пользователя к задаче или шаблону с автором authorID.
+// Участники организации видят ее записи, владелец и администраторы могут их редактировать
+func resourceAccess(db *gorm.DB, userID uint, resourceType string, resourceID, authorID uint, organizationID *uint) (int, error) {
+	if userID == authorID {
+		return accessOwner, nil
+	}
+
+	access := accessNone
+	if organizationID != nil {
+		var memberRoles []string
+		err := db.Model(&dbmodels.OrganizationMember{}).
+			Where("organization_id = ? AND user_id = ?", *organizationID, userID).
+			Pluck("role", &memberRoles).Error
+		if err != nil {
+			return accessNone, err
+		}
+		if len(memberRoles) > 0 {
+			access = accessViewer
+			if memberRoles[0] != dbmodels.OrganizationRoleTeacher {
+				access = accessEditor
+			}
+		}
+	}
+
+	var roles []string
+	err := db.Model(&dbmodels.Share{}).
+		Where("resource_type = ? AND resource_id = ? AND user_id = ?", resourceType, resourceID, userID).
+		Pluck("role", &roles).Error
+	if err != nil {
+		return accessNone, err
+	}
+
+	if len(roles) > 0 && roles[0] == dbmodels.ShareRoleEditor {
+		return accessEditor, nil
+	}
+	if len(roles) > 0 && access < accessViewer {
+		return accessViewer, nil
+	}
+	return access, nil
+}
+
+// findResourceAuthor возвращает автора задачи или шаблона.
+// Возвращает HTTP статус, с которым нужно ответить при ошибке
+func findResourceAuthor(db *gorm.DB, resourceType string, id uint) (uint, int, error) {
+	var authorIDs []uint
+	if err := db.Table(taggedResources[resourceType].table).Where("id = ? AND deleted_at IS NULL", id).Pluck("author_id", &authorIDs).Error; err != nil {
+		return 0, 500, err
+	}
+	if len(authorIDs) == 0 {
+		return 0, 404, fmt.Errorf("%s %d not found", strings.ReplaceAll(resourceType, "_", " "), id)
+	}
+	return authorIDs[0], 200, nil
+}
+
+// checkResourceOwner проверяет, что пользователь - автор задачи или шаблона
+func checkResourceOwner(db *gorm.DB, userID uint, resourceType string, id uint) (int, error) {
+	authorID, status, err := findResourceAuthor(db, resourceType, id)
+	if err != nil {
+		return status, err
+	}
+	if authorID != userID {
+		return 403, fmt.Errorf("you are not the author of this %s", strings.ReplaceAll(resourceType, "_", " "))
+	}
+	return 200, nil
+}
+
+// checkResourceViewer проверяет, что пользователь может видеть задачу или шаблон
+func checkResourceViewer(db *gorm.DB, userID uint, resourceType string, id uint) (int, error) {
+	return checkResourceAccess(db, userID, resourceType, id, accessViewer, "view")
+}
+
+// checkResourceEditor проверяет, что пользователь может менять задачу или шаблон:
+// это автор, редактор или администратор организации записи
+func checkResourceEditor(db *gorm.DB, userID uint, resourceType string, id uint) (int, error) {
+	return checkResourceAccess(db, userID, resourceType, id, accessEditor, "edit")
+}
+
+// checkResourceAccess проверяет, что у пользователя есть хотя бы уровень доступа required.
+// Возвращает HTTP статус, с которым нужно ответить при ошибке
+func checkResourceAccess(db *gorm.DB, userID uint, resourceType string, id uint, required int, action string) (int, error) {
+	var records []struct {
+		AuthorID       uint
+		OrganizationID *uint
+	}
+	err := db.Table(taggedResources[resourceType].table).
+		Select("author_id, organization_id").
+		Where("id = ? AND deleted_at IS NULL", id).
+		Scan(&records).Error
+	if err != nil {
+		return 500, err
+	}
+	if len(records) == 0 {
+		return 404, fmt.Errorf("%s %d not found", strings.ReplaceAll(resourceType, "_", " "), id)
+	}
+
+	access, err := resourceAccess(db, userID, resourceType, id, records[0].AuthorID, records[0].OrganizationID)
+	if err != nil {
+		return 500, err
+	}
+	if access < required {
+		return 403, fmt.Errorf("you cannot %s this %s", action, strings.ReplaceAll(resourceType, "_", " "))
+	}
+	return 200, nil
+}
+
+// GrantShare gives another teacher access to a task or template
+// @Summary Share a task or template
+// @Description Grants a user viewer or editor access to a task or template. Granting again changes the role. Only the author can share
+// @Tags Sharing
+// @Accept json
+// @Produce json
+// @Param input body requests.GrantShare true "Grant data"
+// @Success 200 {object} responses.GrantShareDTO "Access granted"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "Resource or user not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/share/grant [post]
+func GrantShare(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.GrantShare{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		if status, err := checkResourceOwner(db, userID, data.ResourceType, data.ID); err != nil {
+			return c.Status(status).JSON(responses.ErrorResponse{
+				Status: "resource unavailable",
+				Error:  err.Error(),
+			})
+		}
+
+		var grantee dbmodels.User
+		result := db.Where("login = ?", data.Login).First(&grantee)
+		if result.Error == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "user not found",
+			})
+		} else if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		if grantee.ID == userID {
+			return c.Status(422).JSON(responses.ErrorResponse{
+				Status: "invalid user",
+				Error:  "you cannot share a resource with yourself",
+			})
+		}
+
+		// Повторная выдача меняет роль, в том числе у ранее отозванного доступа
+		share := dbmodels.Share{
+			ResourceType: data.ResourceType,
+			ResourceID:   data.ID,
+			UserID:       grantee.ID,
+			Role:         data.Role,
+			GrantedByID:  userID,
+			CreatedAt:    time.Now(),
+			UpdatedAt:    time.Now(),
+		}
+		result = db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "resource_type"}, {Name: "resource_id"}, {Name: "user_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"role": data.Role, "granted_by_id": userID, "updated_at": time.Now(), "deleted_at": nil}),
+		}).Create(&share)
+		if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to share resource",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.GrantShareDTO{
+			Status: "access granted",
+			Share: responses.ShareDTO{
+				ResourceType: share.ResourceType,
+				ResourceID:   share.ResourceID,
+				UserID:       grantee.ID,
+				Login:        grantee.Login,
+				Role:         data.Role,
+				CreatedAt:    share.CreatedAt,
+			},
+		})
+	}
+}
+
+// RevokeShare removes access to a task or template
+// @Summary Revoke access
+// @Description Removes access of a user to a task or template. The author can revoke anyone, a user can leave a resource shared with them
+// @Tags Sharing
+// @Accept json
+// @Produce json
+// @Param input body requests.RevokeShare true "Grant to revoke"
+// @Success 200 {object} responses.RevokeShareDTO "Access revoked"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "Resource or grant not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/share/revoke [delete]
+func RevokeShare(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.RevokeShare{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		if data.UserID != userID {
+			if status, err := checkResourceOwner(db, userID, data.ResourceType, data.ID); err != nil {
+				return c.Status(status).JSON(responses.ErrorResponse{
+					Status: "resource unavailable",
+					Error:  err.Error(),
+				})
+			}
+		}
+
+		result := db.Where("resource_type = ? AND resource_id = ? AND user_id = ?", data.ResourceType, data.ID, data.UserID).
+			Delete(&dbmodels.Share{})
+		if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to revoke access",
+				Error:  result.Error.Error(),
+			})
+		}
+		if result.RowsAffected == 0 {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "share not found",
+			})
+		}
+
+		return c.Status(200).JSON(responses.RevokeShareDTO{
+			Status: "access revoked",
+		})
+	}
+}
+
+// GetShares lists the grants and links of a task or template
+// @Summary List grants and links
+// @Description Returns the users a task or template is shared with and its share links, oldest first. Only the author can see them. Grants and links are paged separately
+// @Tags Sharing
+// @Produce json
+// @Param type path string true "Resource type" Enums(task, condition_template, interests_template)
+// @Param id path int true "Resource ID"
+// @Param cursor query string false "Cursor returned as page.next_cursor by the previous page of grants"
+// @Param links_cursor query string false "Cursor returned as links_page.next_cursor by the previous page of links"
+// @Param limit query int false "Page size of grants and of links (default is 20, max is 100)"
+// @Param include_total query bool false "Count all grants and links and return page.total and links_page.total"
+// @Success 200 {object} responses.GetSharesDTO "Grants successfully retrieved"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or ID"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "Resource not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/share/all/{type}/{id} [get]
+func GetShares(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		resourceID, err := strconv.Atoi(c.Params("id"))
+		if err != nil || resourceID <= 0 {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid ID",
+				Error:  "ID must be a positive integer",
+			})
+		}
+
+		data := requests.GetShares{ResourceType: c.Params("type"), ID: uint(resourceID)}
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		page, err := parsePage(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid page",
+				Error:  err.Error(),
+			})
+		}
+		if validationErrors := validator.ValidateStruct(page); validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+		// У ссылок свой курсор, размер страницы общий
+		linksPage := page
+		linksPage.Cursor = c.Query("links_cursor")
+
+		if status, err := checkResourceOwner(db, userID, data.ResourceType, data.ID); err != nil {
+			return c.Status(status).JSON(responses.ErrorResponse{
+				Status: "resource unavailable",
+				Error:  err.Error(),
+			})
+		}
+
+		order := pageOrder{TimeColumn: "created_at", IDColumn: "id"}
+
+		sharesQuery := db.Model(&dbmodels.Share{}).Where("resource_type = ? AND resource_id = ?", data.ResourceType, data.ID)
+		sharesTotal, err := countPage(sharesQuery, page)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		sharesQuery, err = paginate(sharesQuery, order, page)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid cursor",
+				Error:  err.Error(),
+			})
+		}
+		var shares []dbmodels.Share
+		if err := sharesQuery.Preload("User").Find(&shares).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		shares, sharesPage, err := finishPage(shares, page, sharesTotal, func(share dbmodels.Share) pagePosition {
+			return pagePosition{Time: &share.CreatedAt, ID: share.ID}
+		})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to encode cursor",
+				Error:  err.Error(),
+			})
+		}
+
+		linksQuery := db.Model(&dbmodels.ShareLink{}).Where("resource_type = ? AND resource_id = ?", data.ResourceType, data.ID)
+		linksTotal, err := countPage(linksQuery, linksPage)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		linksQuery, err = paginate(linksQuery, order, linksPage)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid cursor",
+				Error:  err.Error(),
+			})
+		}
+		var links []dbmodels.ShareLink
+		if err := linksQuery.Find(&links).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		links, linksPageDTO, err := finishPage(links, linksPage, linksTotal, func(link dbmodels.ShareLink) pagePosition {
+			return pagePosition{Time: &link.CreatedAt, ID: link.ID}
+		})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to encode cursor",
+				Error:  err.Error(),
+			})
+		}
+
+		response := responses.GetSharesDTO{
+			Shares:    make([]responses.ShareDTO, len(shares)),
+			Page:      sharesPage,
+			Links:     make([]responses.ShareLinkDTO, len(links)),
+			LinksPage: linksPageDTO,
+		}
+		for i, share := range shares {
+			response.Shares[i] = shareToDTO(share)
+		}
+		for i, link := range links {
+			response.Links[i] = responses.ShareLinkDTO{
+				ID:        link.ID,
+				ExpiresAt: link.ExpiresAt,
+				CreatedAt: link.CreatedAt,
+			}
+		}
+
+		return c.Status(200).JSON(response)
+	}
+}
+
+// GetSharedWithMe lists resources other teachers shared with the user
+// @Summary Resources shared with me
+// @Description Returns the tasks and templates other teachers shared with the current user, newest first
+// @Tags Sharing
+// @Produce json
+// @Param cursor query string false "Cursor returned as page.next_cursor by the previous page"
+// @Param limit query int false "Page size (default is 20, max is 100)"
+// @Param include_total query bool false "Count all items and return page.total"
+// @Success 200 {object} responses.GetSharedWithMeDTO "Grants successfully retrieved"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/share/received [get]
+func GetSharedWithMe(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		page, err := parsePage(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid page",
+				Error:  err.Error(),
+			})
+		}
+		if validationErrors := validator.ValidateStruct(page); validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		query := db.Model(&dbmodels.Share{}).Where("user_id = ?", userID)
+		total, err := countPage(query, page)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		order := pageOrder{TimeColumn: "created_at", IDColumn: "id", Desc: true}
+		query, err = paginate(query, order, page)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid cursor",
+				Error:  err.Error(),
+			})
+		}
+
+		var shares []dbmodels.Share
+		if err := query.Preload("User").Find(&shares).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+
+		shares, pageDTO, err := finishPage(shares, page, total, func(share dbmodels.Share) pagePosition {
+			return pagePosition{Time: &share.CreatedAt, ID: share.ID}
+		})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to encode cursor",
+				Error:  err.Error(),
+			})
+		}
+
+		sharesDTO := make([]responses.ShareDTO, len(shares))
+		for i, share := range shares {
+			sharesDTO[i] = shareToDTO(share)
+		}
+
+		return c.Status(200).JSON(responses.GetSharedWithMeDTO{
+			Shares: sharesDTO,
+			Page:   pageDTO,
+		})
+	}
+}
+
+// CreateShareLink creates a read-only link to a task or template
+// @Summary Create a share link
+// @Description Creates a read-only link with an unguessable token. The token is returned only once. Without expires_in_days the link does not expire
+// @Tags Sharing
+// @Accept json
+// @Produce json
+// @Param input body requests.CreateShareLink true "Link data"
+// @Success 200 {object} responses.CreateShareLinkDTO "Link created"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "Resource not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/share/link/new [post]
+func CreateShareLink(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.CreateShareLink{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		if status, err := checkResourceOwner(db, userID, data.ResourceType, data.ID); err != nil {
+			return c.Status(status).JSON(responses.ErrorResponse{
+				Status: "resource unavailable",
+				Error:  err.Error(),
+			})
+		}
+
+		linkToken, err := token.Generate()
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to generate token",
+				Error:  err.Error(),
+			})
+		}
+
+		link := dbmodels.ShareLink{
+			ResourceType: data.ResourceType,
+			ResourceID:   data.ID,
+			TokenHash:    token.Hash(linkToken),
+			CreatedByID:  userID,
+			CreatedAt:    time.Now(),
+		}
+		if data.ExpiresInDays > 0 {
+			expiresAt := time.Now().AddDate(0, 0, data.ExpiresInDays)
+			link.ExpiresAt = &expiresAt
+		}
+
+		if err := db.Create(&link).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to create link",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.CreateShareLinkDTO{
+			Status: "link created",
+			Link: responses.ShareLinkDTO{
+				ID:        link.ID,
+				Token:     linkToken,
+				ExpiresAt: link.ExpiresAt,
+				CreatedAt: link.CreatedAt,
+			},
+		})
+	}
+}
+
+// DeleteShareLink revokes a share link
+// @Summary Delete a share link
+// @Description Revokes a read-only link. Only the author of the resource can revoke it
+// @Tags Sharing
+// @Accept json
+// @Produce json
+// @Param input body requests.DeleteShareLink true "Link to delete"
+// @Success 200 {object} responses.DeleteShareLinkDTO "Link deleted"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "Link not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/share/link/delete [delete]
+func DeleteShareLink(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.DeleteShareLink{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		var link dbmodels.ShareLink
+		result := db.First(&link, data.ID)
+		if result.Error == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "link not found",
+			})
+		} else if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		if status, err := checkResourceOwner(db, userID, link.ResourceType, link.ResourceID); err != nil {
+			return c.Status(status).JSON(responses.ErrorResponse{
+				Status: "resource unavailable",
+				Error:  err.Error(),
+			})
+		}
+
+		if err := db.Delete(&link).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to delete link",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.DeleteShareLinkDTO{
+			Status: "link deleted",
+		})
+	}
+}
+
+// GetSharedResource opens a task or template by a share link
+// @Summary Open a share link
+// @Description Returns a task or template by the token of a read-only link. Does not require authorization
+// @Tags Sharing
+// @Produce json
+// @Param token path string true "Link token"
+// @Success 200 {object} responses.SharedResourceDTO "Shared resource"
+// @Failure 404 {object} responses.ErrorResponse "Link not found or expired"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/share/link/{token} [get]
+func GetSharedResource(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var link dbmodels.ShareLink
+		result := db.Where("token_hash = ?", token.Hash(c.Params("token"))).
+			Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+			Take(&link)
+		if result.Error == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "link not found",
+			})
+		} else if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		response := responses.SharedResourceDTO{ResourceType: link.ResourceType, ID: link.ResourceID}
+		switch link.ResourceType {
+		case "task":
+			var task dbmodels.Task
+			result = db.First(&task, link.ResourceID)
+			response.Title, response.Condition, response.Answer = task.Title, task.Condition, task.Answer
+		case "condition_template":
+			var template dbmodels.ConditionTemplate
+			result = db.First(&template, link.ResourceID)
+			response.Title, response.Condition = template.Title, template.Condition
+		case "interests_template":
+			var template dbmodels.InterestsTemplate
+			result = db.First(&template, link.ResourceID)
+			if result.Error == nil {
+				interests, err := jsonUtils.ConvertInterestsToList(template.Interests)
+				response.Title, response.Interests = template.Title, interests
+				if err != nil {
+					return c.Status(500).JSON(responses.ErrorResponse{
+						Status: "failed to decode interests",
+						Error:  err.Error(),
+					})
+				}
+			}
+		}
+		if result.Error == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(responses.ErrorResponse{
+				Status: "link not found",
+			})
+		} else if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(response)
+	}
+}
+
+func shareToDTO(share dbmodels.Share) responses.ShareDTO {
+	return responses.ShareDTO{
+		ResourceType: share.ResourceType,
+		ResourceID:   share.ResourceID,
+		UserID:       share.UserID,
+		Login:        share.User.Login,
+		Role:         share.Role,
+		CreatedAt:    share.CreatedAt,
+	}
+}
@@ -0,0 +1,378 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	dbmodels "gera-ai/internal/models/database"
+	"gera-ai/internal/models/requests"
+	"gera-ai/internal/models/responses"
+	"gera-ai/internal/utils/jwtUtils"
+	"gera-ai/internal/utils/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// taggedResource описывает сущность, которой можно проставить метки, предметы и классы
+type taggedResource struct {
+	table     string
+	joinTable string
+	column    string
+	model     func(id uint) interface{}
+}
+
+func (r taggedResource) tagsTable() string     { return r.joinTable + "_tags" }
+func (r taggedResource) subjectsTable() string { return r.joinTable + "_subjects" }
+func (r taggedResource) gradesTable() string   { return r.joinTable + "_grades" }
+
+var taggedResources = map[string]taggedResource{
+	"task": {
+		table: "tasks", joinTable: "task", column: "task_id",
+		model: func(id uint) interface{} { return &dbmodels.Task{ID: id} },
+	},
+	"condition_template": {
+		table: "condition_templates", joinTable: "condition_template", column: "condition_template_id",
+		model: func(id uint) interface{} { return &dbmodels.ConditionTemplate{ID: id} },
+	},
+	"interests_template": {
+		table: "interests_templates", joinTable: "interests_template", column: "interests_template_id",
+		model: func(id uint) interface{} { return &dbmodels.InterestsTemplate{ID: id} },
+	},
+	// публикации библиотеки фильтруются только по предметам и классам
+	"publication": {
+		table: "publications", joinTable: "publication", column: "publication_id",
+		model: func(id uint) interface{} { return &dbmodels.Publication{ID: id} },
+	},
+}
+
+// normalizeTag приводит метку к виду, в котором она хранится
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// parseClassificationFilter читает фильтры tag, subject и grade из query (значения через запятую)
+func parseClassificationFilter(c *fiber.Ctx) (requests.ClassificationFilter, error) {
+	filter := requests.ClassificationFilter{}
+	if tags := c.Query("tag"); tags != "" {
+		for _, tag := range strings.Split(tags, ",") {
+			filter.Tags = append(filter.Tags, normalizeTag(tag))
+		}
+		filter.Tags = uniqueStrings(filter.Tags)
+	}
+	if subjects := c.Query("subject"); subjects != "" {
+		filter.Subjects = strings.Split(subjects, ",")
+	}
+	if grades := c.Query("grade"); grades != "" {
+		for _, value := range strings.Split(grades, ",") {
+			grade, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return filter, fmt.Errorf("invalid grade %q", value)
+			}
+			filter.Grades = append(filter.Grades, grade)
+		}
+	}
+	return filter, nil
+}
+
+// applyClassificationFilter ограничивает выборку сущностей фильтрами.
+// Должны совпасть все метки, а из предметов и классов - хотя бы один
+func applyClassificationFilter(query *gorm.DB, resourceType string, filter requests.ClassificationFilter) *gorm.DB {
+	resource := taggedResources[resourceType]
+	idColumn := resource.table + ".id"
+
+	if len(filter.Tags) > 0 {
+		query = query.Where(idColumn+" IN (?)", query.Session(&gorm.Session{NewDB: true}).
+			Table(resource.tagsTable()+" AS jt").
+			Select("jt."+resource.column).
+			Joins("JOIN tags ON tags.id = jt.tag_id").
+			Where("tags.name IN ?", filter.Tags).
+			Group("jt."+resource.column).
+			Having("COUNT(DISTINCT tags.id) = ?", len(filter.Tags)))
+	}
+	if len(filter.Subjects) > 0 {
+		query = query.Where(idColumn+" IN (?)", query.Session(&gorm.Session{NewDB: true}).
+			Table(resource.subjectsTable()+" AS jt").
+			Select("jt."+resource.column).
+			Joins("JOIN subjects ON subjects.id = jt.subject_id").
+			Where("subjects.code IN ?", filter.Subjects))
+	}
+	if len(filter.Grades) > 0 {
+		query = query.Where(idColumn+" IN (?)", query.Session(&gorm.Session{NewDB: true}).
+			Table(resource.gradesTable()+" AS jt").
+			Select("jt."+resource.column).
+			Joins("JOIN grade_levels ON grade_levels.id = jt.grade_level_id").
+			Where("grade_levels.grade IN ?", filter.Grades))
+	}
+	return query
+}
+
+// preloadClassification подгружает метки, предметы и классы
+func preloadClassification(query *gorm.DB) *gorm.DB {
+	return query.Preload("Tags").Preload("Subjects").Preload("Grades")
+}
+
+func classificationToDTO(tags []dbmodels.Tag, subjects []dbmodels.Subject, grades []dbmodels.GradeLevel) *responses.ClassificationDTO {
+	dto := &responses.ClassificationDTO{
+		Tags:     make([]string, 0, len(tags)),
+		Subjects: make([]string, 0, len(subjects)),
+		Grades:   make([]int, 0, len(grades)),
+	}
+	for _, tag := range tags {
+		dto.Tags = append(dto.Tags, tag.Name)
+	}
+	for _, subject := range subjects {
+		dto.Subjects = append(dto.Subjects, subject.Code)
+	}
+	for _, grade := range grades {
+		dto.Grades = append(dto.Grades, grade.Grade)
+	}
+	sort.Strings(dto.Tags)
+	sort.Strings(dto.Subjects)
+	sort.Ints(dto.Grades)
+	return dto
+}
+
+// GetClassificationDictionary returns the controlled subjects and grades
+// @Summary Subjects and grades
+// @Description Returns the subjects and grades that can be assigned to tasks and templates
+// @Tags Tags
+// @Produce json
+// @Success 200 {object} responses.GetClassificationDictionaryDTO "Dictionary successfully retrieved"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/tags/dictionary [get]
+func GetClassificationDictionary(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var subjects []dbmodels.Subject
+		if err := db.Order("id").Find(&subjects).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+
+		var grades []int
+		if err := db.Model(&dbmodels.GradeLevel{}).Order("grade").Pluck("grade", &grades).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+
+		subjectsDTO := make([]responses.SubjectDTO, len(subjects))
+		for i, subject := range subjects {
+			subjectsDTO[i] = responses.SubjectDTO{Code: subject.Code, Name: subject.Name}
+		}
+
+		return c.Status(200).JSON(responses.GetClassificationDictionaryDTO{
+			Subjects: subjectsDTO,
+			Grades:   grades,
+		})
+	}
+}
+
+// SetClassification replaces tags, subjects and grades of a task or template
+// @Summary Set tags, subjects and grades
+// @Description Replaces the free tags, subjects and grades of a task or template. Unknown tags are created
+// @Tags Tags
+// @Accept json
+// @Produce json
+// @Param input body requests.SetClassification true "Classification data"
+// @Success 200 {object} responses.SetClassificationDTO "Classification successfully updated"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "Resource not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error or unknown subject"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/tags/set [put]
+func SetClassification(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		authorID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.SetClassification{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+		for i, tag := range data.Tags {
+			data.Tags[i] = normalizeTag(tag)
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		if status, err := checkResourceEditor(db, authorID, data.ResourceType, data.ID); err != nil {
+			return c.Status(status).JSON(responses.ErrorResponse{
+				Status: "resource unavailable",
+				Error:  err.Error(),
+			})
+		}
+
+		var subjects []dbmodels.Subject
+		if len(data.Subjects) > 0 {
+			if err := db.Where("code IN ?", data.Subjects).Find(&subjects).Error; err != nil {
+				return c.Status(500).JSON(responses.ErrorResponse{
+					Status: "internal server error",
+					Error:  err.Error(),
+				})
+			}
+		}
+		if len(subjects) != len(uniqueStrings(data.Subjects)) {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: map[string]string{"Subjects": "unknown subject"},
+			})
+		}
+
+		var grades []dbmodels.GradeLevel
+		if len(data.Grades) > 0 {
+			if err := db.Where("grade IN ?", data.Grades).Find(&grades).Error; err != nil {
+				return c.Status(500).JSON(responses.ErrorResponse{
+					Status: "internal server error",
+					Error:  err.Error(),
+				})
+			}
+		}
+
+		var tags []dbmodels.Tag
+		err = db.Transaction(func(tx *gorm.DB) error {
+			for _, name := range uniqueStrings(data.Tags) {
+				tag := dbmodels.Tag{}
+				if err := tx.Where(dbmodels.Tag{AuthorID: authorID, Name: name}).FirstOrCreate(&tag).Error; err != nil {
+					return err
+				}
+				tags = append(tags, tag)
+			}
+
+			model := taggedResources[data.ResourceType].model(data.ID)
+			if err := tx.Model(model).Association("Tags").Replace(tags); err != nil {
+				return err
+			}
+			if err := tx.Model(model).Association("Subjects").Replace(subjects); err != nil {
+				return err
+			}
+			return tx.Model(model).Association("Grades").Replace(grades)
+		})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to update classification",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.SetClassificationDTO{
+			Status:         "classification updated",
+			Classification: *classificationToDTO(tags, subjects, grades),
+		})
+	}
+}
+
+// GetTagFacets counts tags, subjects and grades of the user's tasks or templates
+// @Summary Tag facets
+// @Description Counts tags, subjects and grades among the user's tasks or templates that match the given filters
+// @Tags Tags
+// @Produce json
+// @Param type query string true "Resource type" Enums(task, condition_template, interests_template)
+// @Param tag query string false "Comma separated tags, all of them must match"
+// @Param subject query string false "Comma separated subject codes, any of them must match"
+// @Param grade query string false "Comma separated grades, any of them must match"
+// @Success 200 {object} responses.GetTagFacetsDTO "Facets successfully retrieved"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or filter"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/tags/facets [get]
+func GetTagFacets(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		authorID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		filter, err := parseClassificationFilter(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid filter",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.GetTagFacets{ResourceType: c.Query("type"), Filter: filter}
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		resource := taggedResources[data.ResourceType]
+		matching := applyClassificationFilter(
+			db.Table(resource.table).
+				Select(resource.table+".id").
+				Where(resource.table+".author_id = ? AND "+resource.table+".deleted_at IS NULL", authorID),
+			data.ResourceType, data.Filter,
+		)
+
+		response := responses.GetTagFacetsDTO{}
+		facets := []struct {
+			target    *[]responses.FacetValueDTO
+			joinTable string
+			join      string
+			value     string
+		}{
+			{target: &response.Tags, joinTable: resource.tagsTable(), join: "JOIN tags AS d ON d.id = jt.tag_id", value: "d.name"},
+			{target: &response.Subjects, joinTable: resource.subjectsTable(), join: "JOIN subjects AS d ON d.id = jt.subject_id", value: "d.code"},
+			{target: &response.Grades, joinTable: resource.gradesTable(), join: "JOIN grade_levels AS d ON d.id = jt.grade_level_id", value: "d.grade::text"},
+		}
+
+		for _, facet := range facets {
+			*facet.target = []responses.FacetValueDTO{}
+			result := db.Table(facet.joinTable+" AS jt").
+				Select(facet.value+" AS value, COUNT(*) AS count").
+				Joins(facet.join).
+				Where("jt."+resource.column+" IN (?)", matching).
+				Group(facet.value).
+				Order("count DESC, value").
+				Scan(facet.target)
+			if result.Error != nil {
+				return c.Status(500).JSON(responses.ErrorResponse{
+					Status: "internal server error",
+					Error:  result.Error.Error(),
+				})
+			}
+		}
+
+		return c.Status(200).JSON(response)
+	}
+}
+
+// uniqueStrings убирает повторы, сохраняя порядок
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
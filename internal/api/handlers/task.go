@@ -8,25 +8,70 @@ import (
 	"gera-ai/internal/models/requests"
 	"gera-ai/internal/models/responses"
 	"gera-ai/internal/utils/jwtUtils"
+	"gera-ai/internal/utils/listQuery"
+	"gera-ai/internal/utils/taskGenerator"
 	"gera-ai/internal/utils/validator"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
+// createTask сохраняет задачу вместе с первой ревизией. Вызывается внутри транзакции
+func createTask(tx *gorm.DB, authorID uint, organizationID *uint, data requests.CreateTask) (dbmodels.Task, error) {
+	task := dbmodels.Task{
+		AuthorID:       authorID,
+		OrganizationID: organizationID,
+		Title:          data.Title,
+		Condition:      data.Condition,
+		Answer:         data.Answer,
+		CreatedAt:      time.Now(),
+	}
+	if err := tx.Create(&task).Error; err != nil {
+		return task, err
+	}
+	revision := taskRevision(task, authorID)
+	return task, recordRevision(tx, &revision)
+}
+
+// updateTask меняет переданные поля задачи и записывает ревизию от имени userID.
+// Задачи без истории сначала получают снимок до правки. Вызывается внутри транзакции
+func updateTask(tx *gorm.DB, userID uint, task *dbmodels.Task, data requests.PatchTask) error {
+	if err := ensureBaseRevision(tx, taskRevision(*task, task.AuthorID)); err != nil {
+		return err
+	}
+
+	if data.Title != nil {
+		task.Title = *data.Title
+	}
+	if data.Condition != nil {
+		task.Condition = *data.Condition
+	}
+	if data.Answer != nil {
+		task.Answer = *data.Answer
+	}
+	task.UpdatedAt = time.Now()
+
+	if err := tx.Save(task).Error; err != nil {
+		return err
+	}
+	revision := taskRevision(*task, userID)
+	return recordRevision(tx, &revision)
+}
+
 // CreateTask creates a new task
 // @Summary Create a new task
-// @Description Creates a new task and saves it to the database
+// @Description Creates a new task and saves it to the database. With classify the subject, topic, formulas and difficulty are detected by the language model; a classification failure does not fail the creation
 // @Tags Tasks
 // @Accept json
 // @Produce json
 // @Param input body requests.CreateTask true "Task creation data"
 // @Success 200 {object} responses.CreateTaskResponseDTO "Task successfully created"
 // @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Classification requested with an access token without the generate scope"
 // @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
 // @Failure 500 {object} responses.ErrorResponse "Database error"
 // @Router /api/task/new [post]
-func CreateTask(db *gorm.DB) fiber.Handler {
+func CreateTask(db *gorm.DB, tg *taskGenerator.TaskGenerator) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authorID, err := jwtUtils.ExtractUserID(c)
 		if err != nil {
@@ -53,33 +98,48 @@ func CreateTask(db *gorm.DB) fiber.Handler {
 			})
 		}
 
-		// Создание задачи
-		task := dbmodels.Task{
-			AuthorID:  authorID,
-			Title:     data.Title,
-			Condition: data.Condition,
-			Answer:    data.Answer,
-			CreatedAt: time.Now(),
+		// Классификация обращается к языковой модели, как и /task/classify
+		if data.Classify {
+			if err := checkGenerateScope(c); err != nil {
+				return c.Status(403).JSON(responses.ErrorResponse{
+					Status: "insufficient scope",
+					Error:  err.Error(),
+				})
+			}
 		}
 
-		// Сохранение в базе данных
-		result := db.Create(&task)
-		if result.Error != nil {
+		// Сохранение в базе данных вместе с первой ревизией
+		var task dbmodels.Task
+		err = db.Transaction(func(tx *gorm.DB) error {
+			task, err = createTask(tx, authorID, activeOrganizationID(c), data)
+			return err
+		})
+		if err != nil {
 			return c.Status(500).JSON(responses.ErrorResponse{
-				Status: "failed to create task template",
-				Error:  result.Error.Error(),
+				Status: "failed to create task",
+				Error:  err.Error(),
 			})
 		}
 
+		// Классификация необязательна, поэтому ее ошибка не отменяет создание задачи
+		var classificationError string
+		if data.Classify {
+			if _, err := classifyTask(c, db, tg, authorID, &task); err != nil {
+				classificationError = err.Error()
+			}
+		}
+
 		// Ответ с успешным созданием
 		return c.Status(200).JSON(responses.CreateTaskResponseDTO{
 			Status: "task created",
 			Task: responses.TaskDTO{
-				ID:        task.ID,
-				Title:     task.Title,
-				Condition: task.Condition,
-				Answer:    task.Answer,
+				ID:             task.ID,
+				Title:          task.Title,
+				Condition:      task.Condition,
+				Answer:         task.Answer,
+				Classification: taskClassificationToDTO(task),
 			},
+			ClassificationError: classificationError,
 		})
 	}
 }
@@ -96,6 +156,7 @@ func CreateTask(db *gorm.DB) fiber.Handler {
 // @Failure 404 {object} responses.ErrorResponse "Task not found"
 // @Failure 500 {object} responses.ErrorResponse "Internal server error"
 // @Router /api/task/get/{id} [get]
+// @Router /api/v2/tasks/{id} [get]
 func GetTask(db *gorm.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authorID, err := jwtUtils.ExtractUserID(c)
@@ -127,7 +188,7 @@ func GetTask(db *gorm.DB) fiber.Handler {
 
 		// Получение шаблона задачи из базы данных
 		var task dbmodels.Task
-		result := db.First(&task, data.ID)
+		result := preloadClassification(db).First(&task, data.ID)
 		if result.Error == gorm.ErrRecordNotFound {
 			return c.Status(404).JSON(responses.ErrorResponse{
 				Status: "task not found",
@@ -139,11 +200,18 @@ func GetTask(db *gorm.DB) fiber.Handler {
 			})
 		}
 
-		// Проверка, что текущий пользователь является автором задания
-		if task.AuthorID != authorID {
+		// Доступ есть у автора и у пользователей, с которыми поделились
+		access, err := resourceAccess(db, authorID, "task", task.ID, task.AuthorID, task.OrganizationID)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		if access < accessViewer {
 			return c.Status(403).JSON(responses.ErrorResponse{
 				Status: "forbidden",
-				Error:  "you are not the author of this task",
+				Error:  "you do not have access to this task",
 			})
 		}
 
@@ -151,10 +219,14 @@ func GetTask(db *gorm.DB) fiber.Handler {
 		return c.Status(200).JSON(responses.GetTaskResponseDTO{
 			Status: "success",
 			Task: responses.TaskDTO{
-				ID:        task.ID,
-				Title:     task.Title,
-				Condition: task.Condition,
-				Answer:    task.Answer,
+				ID:                 task.ID,
+				Title:              task.Title,
+				Condition:          task.Condition,
+				Answer:             task.Answer,
+				SourceGenerationID: task.SourceGenerationID,
+				Attribution:        task.Attribution,
+				ClassificationDTO:  classificationToDTO(task.Tags, task.Subjects, task.Grades),
+				Classification:     taskClassificationToDTO(task),
 			},
 		})
 	}
@@ -162,7 +234,7 @@ func GetTask(db *gorm.DB) fiber.Handler {
 
 // EditTask edits an existing task
 // @Summary Edit a task
-// @Description Updates an existing task with new data
+// @Description Updates an existing task with new data and records a revision
 // @Tags Tasks
 // @Accept json
 // @Produce json
@@ -216,32 +288,39 @@ func EditTask(db *gorm.DB) fiber.Handler {
 			})
 		}
 
-		// Проверка, что текущий пользователь является автором шаблона
-		if task.AuthorID != authorID {
+		// Редактировать могут автор и пользователи с ролью editor
+		access, err := resourceAccess(db, authorID, "task", task.ID, task.AuthorID, task.OrganizationID)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		if access < accessEditor {
 			return c.Status(403).JSON(responses.ErrorResponse{
 				Status: "forbidden",
-				Error:  "you are not the author of this task",
+				Error:  "you cannot edit this task",
 			})
 		}
 
-		// Обновление данных шаблона
-		task.Title = data.Title
-		task.Condition = data.Condition
-		task.Answer = data.Answer
-		task.UpdatedAt = time.Now()
-
-		// Сохранение изменений
-		saveResult := db.Save(&task)
-		if saveResult.Error != nil {
+		// Сохранение изменений с записью ревизии
+		err = db.Transaction(func(tx *gorm.DB) error {
+			return updateTask(tx, authorID, &task, requests.PatchTask{
+				Title:     &data.Title,
+				Condition: &data.Condition,
+				Answer:    &data.Answer,
+			})
+		})
+		if err != nil {
 			return c.Status(500).JSON(responses.ErrorResponse{
 				Status: "failed to update task",
-				Error:  saveResult.Error.Error(),
+				Error:  err.Error(),
 			})
 		}
 
-		// Возвращаем обновленный шаблон
+		// Возвращаем обновленную задачу
 		return c.Status(200).JSON(responses.EditTaskResponseDTO{
-			Status: "task template updated",
+			Status: "task updated",
 			Task: responses.TaskDTO{
 				ID:        task.ID,
 				Title:     task.Title,
@@ -307,11 +386,18 @@ func DeleteTask(db *gorm.DB) fiber.Handler {
 			})
 		}
 
-		// Проверка, что текущий пользователь является автором шаблона
-		if task.AuthorID != authorID {
+		// Удалять могут автор и пользователи с ролью editor, удаленное попадает в корзину автора
+		access, err := resourceAccess(db, authorID, "task", task.ID, task.AuthorID, task.OrganizationID)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		if access < accessEditor {
 			return c.Status(403).JSON(responses.ErrorResponse{
 				Status: "forbidden",
-				Error:  "you are not the author of this task",
+				Error:  "you cannot delete this task",
 			})
 		}
 
@@ -330,17 +416,46 @@ func DeleteTask(db *gorm.DB) fiber.Handler {
 	}
 }
 
+// taskListSpec - поля списка задач, по которым можно сортировать и фильтровать
+var taskListSpec = listQuery.Spec{
+	Sort: map[string]listQuery.Field{
+		"title":      {Column: "title", Kind: listQuery.Text},
+		"created_at": {Column: "created_at", Kind: listQuery.Time},
+		"updated_at": {Column: "updated_at", Kind: listQuery.Time},
+	},
+	Filters: map[string]listQuery.Field{
+		"title":     {Column: "title", Kind: listQuery.Text},
+		"condition": {Column: "condition", Kind: listQuery.Text},
+		"created":   {Column: "created_at", Kind: listQuery.Time},
+		"updated":   {Column: "updated_at", Kind: listQuery.Time},
+	},
+}
+
 // GetAllTasks retrieves all tasks for a user
 // @Summary Retrieve all tasks
-// @Description Fetches all tasks created by the authenticated user with pagination
+// @Description Fetches tasks of the authenticated user or the active organization, newest first by default, with sorting, field filters and cursor pagination
 // @Tags Tasks
 // @Produce json
-// @Param offset query int false "Pagination offset (default is 0)"
+// @Param cursor query string false "Cursor returned as page.next_cursor by the previous page"
+// @Param limit query int false "Page size (default is 20, max is 100)"
+// @Param include_total query bool false "Count all matching tasks and return page.total"
+// @Param sort query string false "Sort field, prefix with - for descending order (default is newest first)" Enums(title, -title, created_at, -created_at, updated_at, -updated_at)
+// @Param created_after query string false "Only tasks created after this time (RFC3339)"
+// @Param created_before query string false "Only tasks created before this time (RFC3339)"
+// @Param updated_after query string false "Only tasks updated after this time (RFC3339)"
+// @Param updated_before query string false "Only tasks updated before this time (RFC3339)"
+// @Param title_contains query string false "Only tasks whose title contains this text"
+// @Param condition_contains query string false "Only tasks whose condition contains this text"
+// @Param offset query int false "Deprecated: page number, used only without a cursor"
+// @Param tag query string false "Comma separated tags, all of them must match"
+// @Param subject query string false "Comma separated subject codes, any of them must match"
+// @Param grade query string false "Comma separated grades, any of them must match"
 // @Success 200 {object} responses.GetAllTasksResponseDTO "Tasks successfully retrieved"
-// @Failure 400 {object} responses.ErrorResponse "Invalid token or offset"
-// @Failure 404 {object} responses.ErrorResponse "No tasks found"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token, cursor or filter"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
 // @Failure 500 {object} responses.ErrorResponse "Internal server error"
 // @Router /api/task/all [get]
+// @Router /api/v2/tasks [get]
 func GetAllTasks(db *gorm.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authorID, err := jwtUtils.ExtractUserID(c)
@@ -351,53 +466,94 @@ func GetAllTasks(db *gorm.DB) fiber.Handler {
 			})
 		}
 
-		paramsOffset := c.Query("offset", "0")
-		offset, err := strconv.Atoi(paramsOffset)
-		if offset < 0 {
+		page, err := parsePage(c)
+		if err != nil {
 			return c.Status(400).JSON(responses.ErrorResponse{
-				Status: "invalid offset",
-				Error:  "offset must be a positive integer",
+				Status: "invalid page",
+				Error:  err.Error(),
+			})
+		}
+		if validationErrors := validator.ValidateStruct(page); validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
 			})
 		}
+
+		filter, err := parseClassificationFilter(c)
 		if err != nil {
 			return c.Status(400).JSON(responses.ErrorResponse{
-				Status: "invalid json",
+				Status: "invalid filter",
 				Error:  err.Error(),
 			})
 		}
+		if validationErrors := validator.ValidateStruct(filter); validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
 
-		// Получение шаблона задачи из базы данных
-		var tasks []dbmodels.Task
-		limit := 10
-		result := db.Where("author_id = ?", authorID).
-			Order("id DESC").
-			Offset(offset * limit).
-			Limit(limit).
-			Find(&tasks)
-		if result.Error == gorm.ErrRecordNotFound {
-			return c.Status(404).JSON(responses.ErrorResponse{
-				Status: "task template not found",
+		list, err := listQuery.Parse(c.Queries(), taskListSpec)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid query",
+				Error:  err.Error(),
 			})
-		} else if result.Error != nil {
+		}
+
+		query := applyClassificationFilter(scopeToWorkspace(c, db.Model(&dbmodels.Task{}), authorID), "task", filter)
+		query = list.Apply(query)
+		total, err := countPage(query, page)
+		if err != nil {
 			return c.Status(500).JSON(responses.ErrorResponse{
 				Status: "internal server error",
-				Error:  result.Error.Error(),
+				Error:  err.Error(),
+			})
+		}
+		order := sortedPageOrder(list.Sort, pageOrder{IDColumn: "id", Desc: true})
+		query, err = paginate(query, order, page)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid cursor",
+				Error:  err.Error(),
+			})
+		}
+
+		var tasks []dbmodels.Task
+		if err := preloadClassification(query).Find(&tasks).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+
+		tasks, pageDTO, err := finishPage(tasks, page, total, func(task dbmodels.Task) pagePosition {
+			return order.recordPosition(task.ID, task.Title, task.CreatedAt, task.UpdatedAt)
+		})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to encode cursor",
+				Error:  err.Error(),
 			})
 		}
 
 		tasksDTO := make([]responses.TaskDTO, len(tasks))
 		for i, task := range tasks {
 			tasksDTO[i] = responses.TaskDTO{
-				ID:        task.ID,
-				Title:     task.Title,
-				Condition: task.Condition,
-				Answer:    task.Answer,
+				ID:                task.ID,
+				Title:             task.Title,
+				Condition:         task.Condition,
+				Answer:            task.Answer,
+				ClassificationDTO: classificationToDTO(task.Tags, task.Subjects, task.Grades),
+				Classification:    taskClassificationToDTO(task),
 			}
 		}
 
 		// Возвращаем найденные задачи
 		return c.Status(200).JSON(responses.GetAllTasksResponseDTO{
 			Tasks: tasksDTO,
+			Page:  pageDTO,
 		})
 	}
 }
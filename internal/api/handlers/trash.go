@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gera-ai/internal/config"
+	"gera-ai/internal/models/requests"
+	"gera-ai/internal/models/responses"
+	"gera-ai/internal/trash"
+	"gera-ai/internal/utils/jwtUtils"
+	"gera-ai/internal/utils/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// checkTrashedResource проверяет, что запись лежит в корзине и принадлежит пользователю.
+// Возвращает HTTP статус, с которым нужно ответить при ошибке
+func checkTrashedResource(db *gorm.DB, userID uint, resourceType string, id uint) (int, error) {
+	resource := trash.Resources[resourceType]
+	name := strings.ReplaceAll(resourceType, "_", " ")
+
+	var ownerIDs []uint
+	err := db.Table(resource.Table).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Pluck(resource.OwnerColumn, &ownerIDs).Error
+	if err != nil {
+		return 500, err
+	}
+	if len(ownerIDs) == 0 {
+		return 404, fmt.Errorf("%s %d is not in the trash", name, id)
+	}
+	if ownerIDs[0] != userID {
+		return 403, fmt.Errorf("you are not the author of this %s", name)
+	}
+	return 200, nil
+}
+
+// trashRow - строка корзины из любой таблицы с мягким удалением
+type trashRow struct {
+	ResourceType string
+	ID           uint
+	Title        string
+	DeletedAt    time.Time
+}
+
+// GetTrash lists deleted tasks, templates and history of the user
+// @Summary List the trash
+// @Description Returns soft-deleted tasks, templates and generations of the user, most recently deleted first. Items are purged automatically after the retention period
+// @Tags Trash
+// @Produce json
+// @Param type query string false "Comma separated resource types" Enums(task, condition_template, interests_template, history)
+// @Param cursor query string false "Cursor returned as page.next_cursor by the previous page"
+// @Param limit query int false "Page size (default is 20, max is 100)"
+// @Param include_total query bool false "Count all items and return page.total"
+// @Success 200 {object} responses.GetTrashDTO "Trash successfully retrieved"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/trash/all [get]
+func GetTrash(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.GetTrash{Types: trash.ResourceTypes}
+		if types := c.Query("type"); types != "" {
+			data.Types = uniqueStrings(strings.Split(types, ","))
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		page, err := parsePage(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid page",
+				Error:  err.Error(),
+			})
+		}
+		if validationErrors := validator.ValidateStruct(page); validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		// Корзина собирается из нескольких таблиц одним запросом, чтобы листать ее курсором.
+		// Тип ресурса входит в порядок: id разных таблиц могут совпадать
+		selects := make([]string, len(data.Types))
+		args := make([]interface{}, 0, len(data.Types)*2)
+		for i, resourceType := range data.Types {
+			resource := trash.Resources[resourceType]
+			selects[i] = fmt.Sprintf("SELECT ?::text AS resource_type, id, %s AS title, deleted_at FROM %s WHERE %s = ? AND deleted_at IS NOT NULL",
+				resource.TitleColumn, resource.Table, resource.OwnerColumn)
+			args = append(args, resourceType, userID)
+		}
+		query := db.Table("(?) AS trash", db.Raw(strings.Join(selects, " UNION ALL "), args...))
+
+		total, err := countPage(query, page)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		order := pageOrder{TimeColumn: "deleted_at", TextColumn: "resource_type", IDColumn: "id", Desc: true}
+		query, err = paginate(query, order, page)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid cursor",
+				Error:  err.Error(),
+			})
+		}
+
+		var rows []trashRow
+		if err := query.Scan(&rows).Error; err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+
+		rows, pageDTO, err := finishPage(rows, page, total, func(row trashRow) pagePosition {
+			return pagePosition{Time: &row.DeletedAt, Text: &row.ResourceType, ID: row.ID}
+		})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to encode cursor",
+				Error:  err.Error(),
+			})
+		}
+
+		items := make([]responses.TrashItemDTO, len(rows))
+		for i, row := range rows {
+			items[i] = responses.TrashItemDTO{
+				ResourceType: row.ResourceType,
+				ID:           row.ID,
+				Title:        row.Title,
+				DeletedAt:    row.DeletedAt,
+				PurgeAt:      row.DeletedAt.Add(config.Config.TrashRetention),
+			}
+		}
+
+		return c.Status(200).JSON(responses.GetTrashDTO{
+			Items: items,
+			Page:  pageDTO,
+		})
+	}
+}
+
+// RestoreFromTrash restores a deleted task, template or generation
+// @Summary Restore from the trash
+// @Description Restores a soft-deleted task, template or generation together with its tags and revisions
+// @Tags Trash
+// @Accept json
+// @Produce json
+// @Param input body requests.RestoreFromTrash true "Item to restore"
+// @Success 200 {object} responses.RestoreFromTrashDTO "Item successfully restored"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "Item is not in the trash"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/trash/restore [post]
+func RestoreFromTrash(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.RestoreFromTrash{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		if status, err := checkTrashedResource(db, userID, data.ResourceType, data.ID); err != nil {
+			return c.Status(status).JSON(responses.ErrorResponse{
+				Status: "item unavailable",
+				Error:  err.Error(),
+			})
+		}
+
+		result := db.Table(trash.Resources[data.ResourceType].Table).
+			Where("id = ?", data.ID).
+			Update("deleted_at", nil)
+		if result.Error != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to restore item",
+				Error:  result.Error.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.RestoreFromTrashDTO{
+			Status: "item restored",
+		})
+	}
+}
+
+// PurgeFromTrash permanently deletes an item from the trash
+// @Summary Delete permanently
+// @Description Permanently deletes a task, template or generation that is in the trash. Generations made from it are kept but lose the link
+// @Tags Trash
+// @Accept json
+// @Produce json
+// @Param input body requests.PurgeFromTrash true "Item to delete"
+// @Success 200 {object} responses.PurgeFromTrashDTO "Item permanently deleted"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Forbidden access"
+// @Failure 404 {object} responses.ErrorResponse "Item is not in the trash"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/trash/purge [delete]
+func PurgeFromTrash(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.PurgeFromTrash{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		if status, err := checkTrashedResource(db, userID, data.ResourceType, data.ID); err != nil {
+			return c.Status(status).JSON(responses.ErrorResponse{
+				Status: "item unavailable",
+				Error:  err.Error(),
+			})
+		}
+
+		if err := trash.Purge(db, data.ResourceType, []uint{data.ID}); err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to delete item",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.PurgeFromTrashDTO{
+			Status: "item deleted permanently",
+		})
+	}
+}
@@ -0,0 +1,562 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	dbmodels "gera-ai/internal/models/database"
+	"gera-ai/internal/models/requests"
+	"gera-ai/internal/models/responses"
+	"gera-ai/internal/utils/jsonUtils"
+	"gera-ai/internal/utils/jwtUtils"
+	"gera-ai/internal/utils/taskGenerator"
+	"gera-ai/internal/utils/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// resourceID читает id записи из пути запроса
+func resourceID(c *fiber.Ctx) (uint, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid id %q", c.Params("id"))
+	}
+	return uint(id), nil
+}
+
+// resourceError отвечает на ошибку проверки прав или изменения записи resourceType
+func resourceError(c *fiber.Ctx, status int, resourceType string, err error) error {
+	switch status {
+	case 404:
+		return c.Status(404).JSON(responses.ErrorResponse{
+			Status: strings.ReplaceAll(resourceType, "_", " ") + " not found",
+			Error:  err.Error(),
+		})
+	case 403:
+		return c.Status(403).JSON(responses.ErrorResponse{
+			Status: "forbidden",
+			Error:  err.Error(),
+		})
+	}
+	return c.Status(500).JSON(responses.ErrorResponse{
+		Status: "internal server error",
+		Error:  err.Error(),
+	})
+}
+
+// patchResource находит запись по id из пути, проверяет права редактора и в одной транзакции
+// загружает запись в record и применяет к ней update. Возвращает статус и ошибку
+func patchResource(db *gorm.DB, userID uint, resourceType string, id uint, record interface{}, update func(tx *gorm.DB) error) (int, error) {
+	status := 200
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if status, err = checkResourceEditor(tx, userID, resourceType, id); err != nil {
+			return err
+		}
+		status = 500
+		if err := tx.First(record, id).Error; err != nil {
+			return err
+		}
+		if err := update(tx); err != nil {
+			return err
+		}
+		status = 200
+		return nil
+	})
+	return status, err
+}
+
+// deleteResourceHandler удаляет запись resourceType по id из пути и отвечает 204
+func deleteResourceHandler(db *gorm.DB, resourceType string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		id, err := resourceID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid id",
+				Error:  err.Error(),
+			})
+		}
+
+		// Удаленное, как и в v1, попадает в корзину автора
+		status := 200
+		err = db.Transaction(func(tx *gorm.DB) error {
+			status, err = deleteResource(tx, userID, resourceType, id)
+			return err
+		})
+		if err != nil {
+			return resourceError(c, status, resourceType, err)
+		}
+		return c.SendStatus(204)
+	}
+}
+
+// CreateTaskV2 creates a new task
+// @Summary Create a new task
+// @Description Creates a task in the active organization. Responds with 201 and the Location of the new task. With classify the task is also classified by the language model; a classification failure does not fail the creation
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Param input body requests.CreateTask true "Task creation data"
+// @Success 201 {object} responses.CreateTaskResponseDTO "Task created"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Classification requested with an access token without the generate scope"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/v2/tasks [post]
+func CreateTaskV2(db *gorm.DB, tg *taskGenerator.TaskGenerator) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		authorID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.CreateTask{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		// Классификация обращается к языковой модели, как и /task/classify
+		if data.Classify {
+			if err := checkGenerateScope(c); err != nil {
+				return c.Status(403).JSON(responses.ErrorResponse{
+					Status: "insufficient scope",
+					Error:  err.Error(),
+				})
+			}
+		}
+
+		var task dbmodels.Task
+		err = db.Transaction(func(tx *gorm.DB) error {
+			task, err = createTask(tx, authorID, activeOrganizationID(c), data)
+			return err
+		})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to create task",
+				Error:  err.Error(),
+			})
+		}
+
+		var classificationError string
+		if data.Classify {
+			if _, err := classifyTask(c, db, tg, authorID, &task); err != nil {
+				classificationError = err.Error()
+			}
+		}
+
+		c.Location(fmt.Sprintf("/api/v2/tasks/%d", task.ID))
+		return c.Status(201).JSON(responses.CreateTaskResponseDTO{
+			Status: "task created",
+			Task: responses.TaskDTO{
+				ID:             task.ID,
+				Title:          task.Title,
+				Condition:      task.Condition,
+				Answer:         task.Answer,
+				Classification: taskClassificationToDTO(task),
+			},
+			ClassificationError: classificationError,
+		})
+	}
+}
+
+// PatchTask partially updates a task
+// @Summary Update a task
+// @Description Changes only the fields present in the body. Available to the author and to editors; every change is saved as a revision
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param input body requests.PatchTask true "Fields to change"
+// @Success 200 {object} responses.EditTaskResponseDTO "Task updated"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token, ID or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Access forbidden"
+// @Failure 404 {object} responses.ErrorResponse "Task not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/v2/tasks/{id} [patch]
+func PatchTask(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		id, err := resourceID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid id",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.PatchTask{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		var task dbmodels.Task
+		status, err := patchResource(db, userID, "task", id, &task, func(tx *gorm.DB) error {
+			return updateTask(tx, userID, &task, data)
+		})
+		if err != nil {
+			return resourceError(c, status, "task", err)
+		}
+
+		return c.Status(200).JSON(responses.EditTaskResponseDTO{
+			Status: "task updated",
+			Task: responses.TaskDTO{
+				ID:        task.ID,
+				Title:     task.Title,
+				Condition: task.Condition,
+				Answer:    task.Answer,
+			},
+		})
+	}
+}
+
+// DeleteTaskV2 deletes a task
+// @Summary Delete a task
+// @Description Moves the task to the author's trash. Available to the author and to editors
+// @Tags Tasks
+// @Param id path int true "Task ID"
+// @Success 204 "Task deleted"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or ID"
+// @Failure 403 {object} responses.ErrorResponse "Access forbidden"
+// @Failure 404 {object} responses.ErrorResponse "Task not found"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/v2/tasks/{id} [delete]
+func DeleteTaskV2(db *gorm.DB) fiber.Handler {
+	return deleteResourceHandler(db, "task")
+}
+
+// CreateConditionTemplateV2 creates a new condition template
+// @Summary Create a new condition template
+// @Description Creates a condition template in the active organization. Responds with 201 and the Location of the new template
+// @Tags ConditionTemplate
+// @Accept json
+// @Produce json
+// @Param input body requests.CreateConditionTemplate true "Template data"
+// @Success 201 {object} responses.CreateConditionTemplateDTO "Template created"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/v2/condition-templates [post]
+func CreateConditionTemplateV2(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		authorID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.CreateConditionTemplate{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		var conditionTemplate dbmodels.ConditionTemplate
+		err = db.Transaction(func(tx *gorm.DB) error {
+			conditionTemplate, err = createConditionTemplate(tx, authorID, activeOrganizationID(c), data)
+			return err
+		})
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to create condition template",
+				Error:  err.Error(),
+			})
+		}
+
+		c.Location(fmt.Sprintf("/api/v2/condition-templates/%d", conditionTemplate.ID))
+		return c.Status(201).JSON(responses.CreateConditionTemplateDTO{
+			Status: "condition template created",
+			TaskTemplate: responses.ConditionTemplateDTO{
+				ID:        conditionTemplate.ID,
+				Title:     conditionTemplate.Title,
+				Condition: conditionTemplate.Condition,
+			},
+		})
+	}
+}
+
+// PatchConditionTemplate partially updates a condition template
+// @Summary Update a condition template
+// @Description Changes only the fields present in the body. Available to the author and to editors; every change is saved as a revision
+// @Tags ConditionTemplate
+// @Accept json
+// @Produce json
+// @Param id path int true "Template ID"
+// @Param input body requests.PatchConditionTemplate true "Fields to change"
+// @Success 200 {object} responses.EditConditionTemplateDTO "Template updated"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token, ID or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Access forbidden"
+// @Failure 404 {object} responses.ErrorResponse "Template not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/v2/condition-templates/{id} [patch]
+func PatchConditionTemplate(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		id, err := resourceID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid id",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.PatchConditionTemplate{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		var conditionTemplate dbmodels.ConditionTemplate
+		status, err := patchResource(db, userID, "condition_template", id, &conditionTemplate, func(tx *gorm.DB) error {
+			return updateConditionTemplate(tx, userID, &conditionTemplate, data)
+		})
+		if err != nil {
+			return resourceError(c, status, "condition_template", err)
+		}
+
+		return c.Status(200).JSON(responses.EditConditionTemplateDTO{
+			Status: "condition template updated",
+			TaskTemplate: responses.ConditionTemplateDTO{
+				ID:        conditionTemplate.ID,
+				Title:     conditionTemplate.Title,
+				Condition: conditionTemplate.Condition,
+			},
+		})
+	}
+}
+
+// DeleteConditionTemplateV2 deletes a condition template
+// @Summary Delete a condition template
+// @Description Moves the template to the author's trash. Available to the author and to editors
+// @Tags ConditionTemplate
+// @Param id path int true "Template ID"
+// @Success 204 "Template deleted"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or ID"
+// @Failure 403 {object} responses.ErrorResponse "Access forbidden"
+// @Failure 404 {object} responses.ErrorResponse "Template not found"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/v2/condition-templates/{id} [delete]
+func DeleteConditionTemplateV2(db *gorm.DB) fiber.Handler {
+	return deleteResourceHandler(db, "condition_template")
+}
+
+// CreateInterestsTemplateV2 creates a new interests template
+// @Summary Create a new interests template
+// @Description Creates an interests template in the active organization. Responds with 201 and the Location of the new template
+// @Tags InterestsTemplates
+// @Accept json
+// @Produce json
+// @Param input body requests.CreateInterestsTemplate true "Template data"
+// @Success 201 {object} responses.CreateInterestsTemplateDTO "Template created"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or JSON"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/v2/interests-templates [post]
+func CreateInterestsTemplateV2(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		authorID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.CreateInterestsTemplate{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		interestsTemplate, err := createInterestsTemplate(db, authorID, activeOrganizationID(c), data)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to create interests template",
+				Error:  err.Error(),
+			})
+		}
+
+		c.Location(fmt.Sprintf("/api/v2/interests-templates/%d", interestsTemplate.ID))
+		return c.Status(201).JSON(responses.CreateInterestsTemplateDTO{
+			Status: "interests template created",
+			TaskTemplate: responses.InterestsTemplateDTO{
+				ID:        interestsTemplate.ID,
+				Title:     interestsTemplate.Title,
+				Interests: data.Interests,
+			},
+		})
+	}
+}
+
+// PatchInterestsTemplate partially updates an interests template
+// @Summary Update an interests template
+// @Description Changes only the fields present in the body. Available to the author and to editors
+// @Tags InterestsTemplates
+// @Accept json
+// @Produce json
+// @Param id path int true "Template ID"
+// @Param input body requests.PatchInterestsTemplate true "Fields to change"
+// @Success 200 {object} responses.EditInterestsTemplateDTO "Template updated"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token, ID or JSON"
+// @Failure 403 {object} responses.ErrorResponse "Access forbidden"
+// @Failure 404 {object} responses.ErrorResponse "Template not found"
+// @Failure 422 {object} responses.ValidationErrorResponse "Validation error"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/v2/interests-templates/{id} [patch]
+func PatchInterestsTemplate(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		id, err := resourceID(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid id",
+				Error:  err.Error(),
+			})
+		}
+
+		data := requests.PatchInterestsTemplate{}
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid json",
+				Error:  err.Error(),
+			})
+		}
+
+		validationErrors := validator.ValidateStruct(data)
+		if validationErrors != nil {
+			return c.Status(422).JSON(responses.ValidationErrorResponse{
+				Status: "validation failed",
+				Errors: validationErrors,
+			})
+		}
+
+		var interestsTemplate dbmodels.InterestsTemplate
+		status, err := patchResource(db, userID, "interests_template", id, &interestsTemplate, func(tx *gorm.DB) error {
+			return updateInterestsTemplate(tx, &interestsTemplate, data)
+		})
+		if err != nil {
+			return resourceError(c, status, "interests_template", err)
+		}
+
+		interests, err := jsonUtils.ConvertInterestsToList(interestsTemplate.Interests)
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "failed to decode interests",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.Status(200).JSON(responses.EditInterestsTemplateDTO{
+			Status: "interests template updated",
+			TaskTemplate: responses.InterestsTemplateDTO{
+				ID:        interestsTemplate.ID,
+				Title:     interestsTemplate.Title,
+				Interests: interests,
+			},
+		})
+	}
+}
+
+// DeleteInterestsTemplateV2 deletes an interests template
+// @Summary Delete an interests template
+// @Description Moves the template to the author's trash. Available to the author and to editors
+// @Tags InterestsTemplates
+// @Param id path int true "Template ID"
+// @Success 204 "Template deleted"
+// @Failure 400 {object} responses.ErrorResponse "Invalid token or ID"
+// @Failure 403 {object} responses.ErrorResponse "Access forbidden"
+// @Failure 404 {object} responses.ErrorResponse "Template not found"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /api/v2/interests-templates/{id} [delete]
+func DeleteInterestsTemplateV2(db *gorm.DB) fiber.Handler {
+	return deleteResourceHandler(db, "interests_template")
+}
@@ -1,12 +1,178 @@
 package middlewares
 
 import (
+	"strconv"
+	"strings"
+	"time"
+
+	dbmodels "gera-ai/internal/models/database"
+	"gera-ai/internal/models/responses"
+	"gera-ai/internal/utils/jwtUtils"
+	"gera-ai/internal/utils/rbac"
+	"gera-ai/internal/utils/token"
+
 	jwtware "github.com/gofiber/contrib/jwt"
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
-func AuthMiddleware(secret string) fiber.Handler {
+// OrganizationHeader - заголовок, в котором клиент передает активную организацию
+const OrganizationHeader = "X-Organization-ID"
+
+// AuthMiddleware проверяет JWT и его сессию, отклоняет заблокированных пользователей и, если передан
+// заголовок X-Organization-ID, кладет активную организацию и роль в ней в контекст запроса
+func AuthMiddleware(secret string, db *gorm.DB) fiber.Handler {
 	return jwtware.New(jwtware.Config{
 		SigningKey: jwtware.SigningKey{Key: []byte(secret)},
+		SuccessHandler: func(c *fiber.Ctx) error {
+			userID, err := jwtUtils.ExtractUserID(c)
+			if err != nil {
+				return c.Status(400).JSON(responses.ErrorResponse{
+					Status: "invalid token",
+					Error:  err.Error(),
+				})
+			}
+
+			// Токен завершенной сессии перестает работать сразу, не дожидаясь истечения
+			sessionID, err := jwtUtils.ExtractSessionID(c)
+			if err != nil {
+				return c.Status(401).JSON(responses.ErrorResponse{
+					Status: "session expired",
+					Error:  err.Error(),
+				})
+			}
+			var active int64
+			err = db.Model(&dbmodels.Session{}).
+				Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+				Count(&active).Error
+			if err != nil {
+				return c.Status(500).JSON(responses.ErrorResponse{
+					Status: "internal server error",
+					Error:  err.Error(),
+				})
+			}
+			if active == 0 {
+				return c.Status(401).JSON(responses.ErrorResponse{
+					Status: "session expired",
+					Error:  "the session was revoked, log in again",
+				})
+			}
+
+			return authorize(c, db, userID)
+		},
 	})
 }
+
+// ScopedAuthMiddleware работает как AuthMiddleware, но дополнительно принимает персональные
+// токены, у которых есть все области доступа scopes
+func ScopedAuthMiddleware(secret string, db *gorm.DB, scopes ...string) fiber.Handler {
+	jwtAuth := AuthMiddleware(secret, db)
+	return func(c *fiber.Ctx) error {
+		raw, found := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		if !found || !strings.HasPrefix(raw, dbmodels.AccessTokenPrefix) {
+			return jwtAuth(c)
+		}
+
+		var accessToken dbmodels.AccessToken
+		err := db.Preload("User").Where("token_hash = ?", token.Hash(raw)).First(&accessToken).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+		if err == gorm.ErrRecordNotFound || accessToken.User.ID == 0 {
+			return c.Status(401).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  "the access token is invalid or revoked",
+			})
+		}
+		if accessToken.ExpiresAt != nil && time.Now().After(*accessToken.ExpiresAt) {
+			return c.Status(401).JSON(responses.ErrorResponse{
+				Status: "token expired",
+				Error:  "the access token has expired",
+			})
+		}
+		for _, scope := range scopes {
+			if !rbac.HasScope(accessToken.Scopes, scope) {
+				return c.Status(403).JSON(responses.ErrorResponse{
+					Status: "insufficient scope",
+					Error:  "the access token does not have the " + scope + " scope",
+				})
+			}
+		}
+
+		// UpdateColumn не трогает updated_at, чтобы он показывал изменение самого токена.
+		// Модель без загруженного пользователя, иначе gorm сохраняет и его
+		err = db.Model(&dbmodels.AccessToken{}).Where("id = ?", accessToken.ID).UpdateColumn("last_used_at", time.Now()).Error
+		if err != nil {
+			return c.Status(500).JSON(responses.ErrorResponse{
+				Status: "internal server error",
+				Error:  err.Error(),
+			})
+		}
+
+		jwtUtils.SetAccessToken(c, accessToken.UserID, accessToken.User.Role, accessToken.ID, accessToken.Scopes)
+		return authorize(c, db, accessToken.UserID)
+	}
+}
+
+// authorize отклоняет заблокированных пользователей, кладет в контекст текущую роль пользователя
+// и, если передан заголовок X-Organization-ID, активную организацию и роль в ней
+func authorize(c *fiber.Ctx, db *gorm.DB, userID uint) error {
+	// Блокировка и смена роли действуют сразу, не дожидаясь истечения токена
+	var users []dbmodels.User
+	err := db.Select("id", "role", "blocked_at").Where("id = ?", userID).Limit(1).Find(&users).Error
+	if err != nil {
+		return c.Status(500).JSON(responses.ErrorResponse{
+			Status: "internal server error",
+			Error:  err.Error(),
+		})
+	}
+	if len(users) == 0 {
+		return c.Status(401).JSON(responses.ErrorResponse{
+			Status: "invalid token",
+			Error:  "the user no longer exists",
+		})
+	}
+	if users[0].BlockedAt != nil {
+		return c.Status(403).JSON(responses.ErrorResponse{
+			Status: "account blocked",
+			Error:  "your account is blocked",
+		})
+	}
+	jwtUtils.SetRole(c, users[0].Role)
+
+	header := c.Get(OrganizationHeader)
+	if header == "" {
+		return c.Next()
+	}
+
+	organizationID, err := strconv.ParseUint(header, 10, 64)
+	if err != nil || organizationID == 0 {
+		return c.Status(400).JSON(responses.ErrorResponse{
+			Status: "invalid organization",
+			Error:  OrganizationHeader + " must be a positive integer",
+		})
+	}
+
+	var roles []string
+	err = db.Model(&dbmodels.OrganizationMember{}).
+		Where("organization_id = ? AND user_id = ?", organizationID, userID).
+		Pluck("role", &roles).Error
+	if err != nil {
+		return c.Status(500).JSON(responses.ErrorResponse{
+			Status: "internal server error",
+			Error:  err.Error(),
+		})
+	}
+	if len(roles) == 0 {
+		return c.Status(403).JSON(responses.ErrorResponse{
+			Status: "forbidden",
+			Error:  "you are not a member of this organization",
+		})
+	}
+
+	jwtUtils.SetOrganization(c, uint(organizationID), roles[0])
+	return c.Next()
+}
@@ -0,0 +1,187 @@
+package middlewares
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	dbmodels "gera-ai/internal/models/database"
+	"gera-ai/internal/utils/jwtUtils"
+	"gera-ai/internal/utils/rbac"
+	"gera-ai/internal/utils/token"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const testAccessToken = dbmodels.AccessTokenPrefix + "test-token"
+
+// timeNear - аргумент запроса, который отличается от want не больше чем на минуту
+type timeNear struct {
+	want time.Time
+}
+
+func (arg timeNear) Match(value driver.Value) bool {
+	got, ok := value.(time.Time)
+	if !ok {
+		return false
+	}
+	diff := got.Sub(arg.want)
+	return diff > -time.Minute && diff < time.Minute
+}
+
+// scopedApp возвращает приложение с маршрутом, которому нужны history:read и tasks:write.
+// Обработчик отвечает ID пользователя и областями токена из контекста
+func scopedApp(t *testing.T) (*fiber.App, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		sqlDB.Close()
+	})
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	app.Get("/", ScopedAuthMiddleware("secret", db, rbac.ScopeHistoryRead, rbac.ScopeTasksWrite), func(c *fiber.Ctx) error {
+		userID, err := jwtUtils.ExtractUserID(c)
+		if err != nil {
+			return err
+		}
+		role, err := jwtUtils.ExtractRole(c)
+		if err != nil {
+			return err
+		}
+		scopes, _ := jwtUtils.ExtractScopes(c)
+		return c.SendString(fmt.Sprintf("%s %d %s", role, userID, scopes))
+	})
+	return app, mock
+}
+
+func get(t *testing.T, app *fiber.App, authorization string) (int, string) {
+	t.Helper()
+	request := httptest.NewRequest("GET", "/", nil)
+	request.Header.Set(fiber.HeaderAuthorization, authorization)
+	response, err := app.Test(request, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return response.StatusCode, string(body)
+}
+
+// expectAccessToken ожидает поиск токена по хешу вместе с пользователем
+func expectAccessToken(mock sqlmock.Sqlmock, scopes string, expiresAt *time.Time) {
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "access_tokens" WHERE token_hash = $1`)).
+		WithArgs(token.Hash(testAccessToken), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "token_hash", "scopes", "expires_at"}).
+			AddRow(9, 5, token.Hash(testAccessToken), scopes, expiresAt))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE "users"."id" = $1`)).
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "login", "role"}).
+			AddRow(5, "teacher", dbmodels.UserRoleTeacher))
+}
+
+func TestScopedAuthAccessToken(t *testing.T) {
+	app, mock := scopedApp(t)
+
+	expectAccessToken(mock, "tasks:write history:read", nil)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "access_tokens" SET "last_used_at"=$1 WHERE id = $2 AND "access_tokens"."deleted_at" IS NULL`)).
+		WithArgs(timeNear{time.Now()}, 9).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	// Роль берется из базы, а не из токена
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id","role","blocked_at" FROM "users" WHERE id = $1`)).
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "role", "blocked_at"}).AddRow(5, dbmodels.UserRoleModerator, nil))
+
+	status, body := get(t, app, "Bearer "+testAccessToken)
+	if want := dbmodels.UserRoleModerator + " 5 tasks:write history:read"; status != 200 || body != want {
+		t.Fatalf("GET = %d %q, want 200 %q", status, body, want)
+	}
+}
+
+func TestScopedAuthAccessTokenRejected(t *testing.T) {
+	expired := time.Now().Add(-time.Minute)
+	tests := []struct {
+		name       string
+		expect     func(mock sqlmock.Sqlmock)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "unknown token",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "access_tokens" WHERE token_hash = $1`)).
+					WithArgs(token.Hash(testAccessToken), 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			wantStatus: 401,
+			wantBody:   "the access token is invalid or revoked",
+		},
+		{
+			name: "expired",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectAccessToken(mock, "tasks:write history:read", &expired)
+			},
+			wantStatus: 401,
+			wantBody:   "the access token has expired",
+		},
+		{
+			name: "missing one of the scopes",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectAccessToken(mock, "history:read generate", nil)
+			},
+			wantStatus: 403,
+			wantBody:   "the access token does not have the tasks:write scope",
+		},
+		{
+			name: "scope prefix is not a scope",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectAccessToken(mock, "history:readwrite tasks:write", nil)
+			},
+			wantStatus: 403,
+			wantBody:   "the access token does not have the history:read scope",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, mock := scopedApp(t)
+			tt.expect(mock)
+
+			// last_used_at не обновляется для отклоненного токена: лишний запрос провалит ожидания
+			status, body := get(t, app, "Bearer "+testAccessToken)
+			if status != tt.wantStatus || !regexp.MustCompile(regexp.QuoteMeta(tt.wantBody)).MatchString(body) {
+				t.Fatalf("GET = %d %s, want %d %q", status, body, tt.wantStatus, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestScopedAuthWithoutPrefixUsesJWT(t *testing.T) {
+	app, _ := scopedApp(t)
+
+	// Без префикса gat_ токен проверяется как JWT и в таблицу токенов не попадает
+	status, _ := get(t, app, "Bearer "+token.Hash(testAccessToken))
+	if status != 401 {
+		t.Fatalf("GET = %d, want 401", status)
+	}
+}
@@ -0,0 +1,24 @@
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Deprecated помечает устаревший маршрут: заголовок Deprecation (RFC 9745) сообщает, с какого
+// момента маршрут устарел, Sunset (RFC 8594) - когда он может перестать работать, а Link
+// указывает на маршрут, который его заменяет. Сам маршрут продолжает работать как прежде
+func Deprecated(deprecatedAt, sunsetAt time.Time, successor string) fiber.Handler {
+	deprecation := "@" + strconv.FormatInt(deprecatedAt.Unix(), 10)
+	sunset := sunsetAt.UTC().Format(http.TimeFormat)
+	link := "<" + successor + `>; rel="successor-version"`
+	return func(c *fiber.Ctx) error {
+		c.Set("Deprecation", deprecation)
+		c.Set("Sunset", sunset)
+		c.Set(fiber.HeaderLink, link)
+		return c.Next()
+	}
+}
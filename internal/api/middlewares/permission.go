@@ -0,0 +1,31 @@
+package middlewares
+
+import (
+	"gera-ai/internal/models/responses"
+	"gera-ai/internal/utils/jwtUtils"
+	"gera-ai/internal/utils/rbac"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequirePermission пропускает запрос, только если текущая роль пользователя имеет право permission.
+// Ставится после AuthMiddleware, который загружает роль из базы данных
+func RequirePermission(permission string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, err := jwtUtils.ExtractRole(c)
+		if err != nil {
+			return c.Status(400).JSON(responses.ErrorResponse{
+				Status: "invalid token",
+				Error:  err.Error(),
+			})
+		}
+
+		if !rbac.Has(role, permission) {
+			return c.Status(403).JSON(responses.ErrorResponse{
+				Status: "forbidden",
+				Error:  "your role does not allow " + permission,
+			})
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"gera-ai/internal/api/handlers"
+	"gera-ai/internal/api/middlewares"
+	"gera-ai/internal/config"
+	"gera-ai/internal/utils/rbac"
+	"gera-ai/internal/utils/taskGenerator"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func AdminRouter(app fiber.Router, db *gorm.DB, tg *taskGenerator.TaskGenerator) {
+	jwt := middlewares.AuthMiddleware(config.Config.JWTSecret, db)
+	admin := app.Group("/admin", jwt)
+
+	users := middlewares.RequirePermission(rbac.PermissionManageUsers)
+	admin.Get("/users", users, handlers.GetUsers(db))
+	admin.Put("/users/block", users, handlers.BlockUser(db))
+	admin.Put("/users/unblock", users, handlers.UnblockUser(db))
+	admin.Put("/users/role", users, handlers.ChangeUserRole(db))
+	admin.Get("/audit", users, handlers.GetAuditEvents(db))
+
+	admin.Get("/usage", middlewares.RequirePermission(rbac.PermissionViewUsage), handlers.GetUsage(db))
+
+	prompts := middlewares.RequirePermission(rbac.PermissionManagePrompts)
+	admin.Get("/prompts", prompts, handlers.GetPrompts(db, tg))
+	admin.Put("/prompts", prompts, handlers.EditPrompt(db, tg))
+	admin.Delete("/prompts/reset", prompts, handlers.ResetPrompt(db, tg))
+}
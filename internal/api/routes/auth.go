@@ -2,12 +2,40 @@ package routes
 
 import (
 	"gera-ai/internal/api/handlers"
+	"gera-ai/internal/api/middlewares"
+	"gera-ai/internal/config"
+	"gera-ai/internal/utils/mailer"
+	"gera-ai/internal/utils/oauth"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
-func AuthRouter(app fiber.Router, db *gorm.DB) {
+func AuthRouter(app fiber.Router, db *gorm.DB, providers oauth.Providers, mail mailer.Mailer) {
 	app.Post("/auth/register", handlers.Register(db))
 
 	app.Post("/auth/login", handlers.Login(db))
+
+	app.Post("/auth/refresh", handlers.RefreshSession(db))
+
+	app.Post("/auth/password/reset/request", handlers.RequestPasswordReset(db, mail))
+	app.Post("/auth/password/reset/confirm", handlers.ConfirmPasswordReset(db))
+
+	jwt := middlewares.AuthMiddleware(config.Config.JWTSecret, db)
+	app.Post("/auth/logout", jwt, handlers.Logout(db))
+	app.Get("/auth/sessions", jwt, handlers.GetSessions(db))
+	app.Delete("/auth/sessions/revoke", jwt, handlers.RevokeSession(db))
+	app.Put("/auth/password/change", jwt, handlers.ChangePassword(db))
+
+	// персональные токены для скриптов и интеграций
+	app.Post("/auth/tokens/new", jwt, handlers.CreateAccessToken(db))
+	app.Get("/auth/tokens", jwt, handlers.GetAccessTokens(db))
+	app.Delete("/auth/tokens/revoke", jwt, handlers.RevokeAccessToken(db))
+
+	// вход через Яндекс ID и другие OpenID Connect провайдеры
+	app.Get("/auth/oauth/providers", handlers.GetOAuthProviders(providers))
+	app.Get("/auth/oauth/:provider/start", handlers.StartOAuth(db, providers))
+	app.Get("/auth/oauth/:provider/callback", handlers.OAuthCallback(db, providers))
+	app.Post("/auth/oauth/:provider/link", jwt, handlers.LinkOAuth(db, providers))
+	app.Get("/auth/identities", jwt, handlers.GetIdentities(db))
+	app.Delete("/auth/identities/unlink", jwt, handlers.UnlinkIdentity(db))
 }
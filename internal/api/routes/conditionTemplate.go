@@ -4,16 +4,20 @@ import (
 	"gera-ai/internal/api/handlers"
 	"gera-ai/internal/api/middlewares"
 	"gera-ai/internal/config"
+	"gera-ai/internal/utils/rbac"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
 func ConditionTemplateRouter(app fiber.Router, db *gorm.DB) {
-	jwt := middlewares.AuthMiddleware(config.Config.JWTSecret)
-	app.Post("/template/condition/new", jwt, handlers.CreateConditionTemplate(db))
-	app.Get("/template/condition/get/:id", jwt, handlers.GetConditionTemplate(db))
-	app.Put("/template/condition/edit", jwt, handlers.EditConditionTemplate(db))
-	app.Delete("/template/condition/delete", jwt, handlers.DeleteConditionTemplate(db))
+	readTasks := middlewares.ScopedAuthMiddleware(config.Config.JWTSecret, db, rbac.ScopeTasksRead)
+	writeTasks := middlewares.ScopedAuthMiddleware(config.Config.JWTSecret, db, rbac.ScopeTasksWrite)
+	deprecated := middlewares.Deprecated(config.Config.V1Deprecation.DeprecatedAt, config.Config.V1Deprecation.SunsetAt, "/api/v2/condition-templates")
+	app.Post("/template/condition/new", deprecated, writeTasks, handlers.CreateConditionTemplate(db))
+	app.Get("/template/condition/get/:id", deprecated, readTasks, handlers.GetConditionTemplate(db))
+	app.Put("/template/condition/edit", deprecated, writeTasks, handlers.EditConditionTemplate(db))
+	app.Delete("/template/condition/delete", deprecated, writeTasks, handlers.DeleteConditionTemplate(db))
+	app.Post("/template/condition/batch", deprecated, writeTasks, handlers.BatchConditionTemplates(db))
 
-	app.Get("/template/condition/all", jwt, handlers.GetAllConditionTemplates(db))
+	app.Get("/template/condition/all", deprecated, readTasks, handlers.GetAllConditionTemplates(db))
 }
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"gera-ai/internal/api/handlers"
+	"gera-ai/internal/api/middlewares"
+	"gera-ai/internal/config"
+	"gera-ai/internal/utils/rbac"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func ExportRouter(app fiber.Router, db *gorm.DB) {
+	readTasks := middlewares.ScopedAuthMiddleware(config.Config.JWTSecret, db, rbac.ScopeTasksRead)
+	readHistory := middlewares.ScopedAuthMiddleware(config.Config.JWTSecret, db, rbac.ScopeHistoryRead)
+	app.Get("/export/task/:id", readTasks, handlers.ExportTask(db))
+	app.Post("/export/tasks", readTasks, handlers.ExportTasks(db))
+	app.Post("/export/generations", readHistory, handlers.ExportGenerations(db))
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"gera-ai/internal/api/handlers"
+	"gera-ai/internal/api/middlewares"
+	"gera-ai/internal/config"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func FeedbackRouter(app fiber.Router, db *gorm.DB) {
+	jwt := middlewares.AuthMiddleware(config.Config.JWTSecret, db)
+	app.Post("/feedback/new", jwt, handlers.CreateFeedback(db))
+	app.Get("/feedback/generation/:id", jwt, handlers.GetGenerationFeedback(db))
+	app.Delete("/feedback/delete", jwt, handlers.DeleteFeedback(db))
+
+	app.Get("/feedback/stats", jwt, handlers.GetFeedbackStats(db))
+}
@@ -4,15 +4,16 @@ import (
 	"gera-ai/internal/api/handlers"
 	"gera-ai/internal/api/middlewares"
 	"gera-ai/internal/config"
+	"gera-ai/internal/utils/rbac"
 	"gera-ai/internal/utils/taskGenerator"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
 func AIGeneratorRouter(app fiber.Router, db *gorm.DB, tg *taskGenerator.TaskGenerator) {
-	jwt := middlewares.AuthMiddleware(config.Config.JWTSecret)
+	generate := middlewares.ScopedAuthMiddleware(config.Config.JWTSecret, db, rbac.ScopeGenerate)
 
-	app.Post("/generate/interests", jwt, handlers.GenerateTaskByInterest(db, tg))
-	app.Post("/generate/nointerests", jwt, handlers.GenerateTaskByNoInterest(db, tg))
-	app.Post("/generate/answer", jwt, handlers.GenerateAnswer(db, tg))
+	app.Post("/generate/interests", generate, handlers.GenerateTaskByInterest(db, tg))
+	app.Post("/generate/nointerests", generate, handlers.GenerateTaskByNoInterest(db, tg))
+	app.Post("/generate/answer", generate, handlers.GenerateAnswer(db, tg))
 }
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"gera-ai/internal/api/handlers"
+	"gera-ai/internal/api/middlewares"
+	"gera-ai/internal/config"
+	"gera-ai/internal/utils/rbac"
+	"gera-ai/internal/utils/taskGenerator"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func HistoryRouter(app fiber.Router, db *gorm.DB, tg *taskGenerator.TaskGenerator) {
+	readHistory := middlewares.ScopedAuthMiddleware(config.Config.JWTSecret, db, rbac.ScopeHistoryRead)
+	writeHistory := middlewares.ScopedAuthMiddleware(config.Config.JWTSecret, db, rbac.ScopeHistoryWrite)
+	// Сохранение варианта читает историю и создает задачу
+	promote := middlewares.ScopedAuthMiddleware(config.Config.JWTSecret, db, rbac.ScopeHistoryRead, rbac.ScopeTasksWrite)
+	app.Get("/history/get/:id", readHistory, handlers.GetGenerationHistory(db))
+	app.Delete("/history/delete", writeHistory, handlers.DeleteGenerationHistory(db))
+	app.Post("/history/promote", promote, handlers.PromoteGeneration(db, tg))
+
+	app.Get("/history/all", readHistory, handlers.GetAllGenerationHistory(db))
+}
@@ -4,16 +4,20 @@ import (
 	"gera-ai/internal/api/handlers"
 	"gera-ai/internal/api/middlewares"
 	"gera-ai/internal/config"
+	"gera-ai/internal/utils/rbac"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
 func InterestsTemplateRouter(app fiber.Router, db *gorm.DB) {
-	jwt := middlewares.AuthMiddleware(config.Config.JWTSecret)
-	app.Post("/template/interests/new", jwt, handlers.CreateInterestsTemplate(db))
-	app.Get("/template/interests/get/:id", jwt, handlers.GetInterestsTemplate(db))
-	app.Put("/template/interests/edit", jwt, handlers.EditInterestsTemplate(db))
-	app.Delete("/template/interests/delete", jwt, handlers.DeleteInterestsTemplate(db))
+	readTasks := middlewares.ScopedAuthMiddleware(config.Config.JWTSecret, db, rbac.ScopeTasksRead)
+	writeTasks := middlewares.ScopedAuthMiddleware(config.Config.JWTSecret, db, rbac.ScopeTasksWrite)
+	deprecated := middlewares.Deprecated(config.Config.V1Deprecation.DeprecatedAt, config.Config.V1Deprecation.SunsetAt, "/api/v2/interests-templates")
+	app.Post("/template/interests/new", deprecated, writeTasks, handlers.CreateInterestsTemplate(db))
+	app.Get("/template/interests/get/:id", deprecated, readTasks, handlers.GetInterestsTemplate(db))
+	app.Put("/template/interests/edit", deprecated, writeTasks, handlers.EditInterestsTemplate(db))
+	app.Delete("/template/interests/delete", deprecated, writeTasks, handlers.DeleteInterestsTemplate(db))
+	app.Post("/template/interests/batch", deprecated, writeTasks, handlers.BatchInterestsTemplates(db))
 
-	app.Get("/template/interests/all", jwt, handlers.GetAllInterestsTemplates(db))
+	app.Get("/template/interests/all", deprecated, readTasks, handlers.GetAllInterestsTemplates(db))
 }
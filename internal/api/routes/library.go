@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"gera-ai/internal/api/handlers"
+	"gera-ai/internal/api/middlewares"
+	"gera-ai/internal/config"
+	"gera-ai/internal/utils/rbac"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func LibraryRouter(app fiber.Router, db *gorm.DB) {
+	jwt := middlewares.AuthMiddleware(config.Config.JWTSecret, db)
+	app.Post("/library/publish", jwt, handlers.PublishToLibrary(db))
+	app.Put("/library/withdraw", jwt, handlers.WithdrawPublication(db))
+	app.Get("/library/get/:id", jwt, handlers.GetPublication(db))
+	app.Post("/library/clone", jwt, handlers.ClonePublication(db))
+	app.Post("/library/report", jwt, handlers.ReportPublication(db))
+
+	app.Get("/library/all", jwt, handlers.BrowseLibrary(db))
+
+	moderator := middlewares.RequirePermission(rbac.PermissionModerateLibrary)
+	app.Get("/library/moderation", jwt, moderator, handlers.GetModerationQueue(db))
+	app.Put("/library/moderate", jwt, moderator, handlers.ModeratePublication(db))
+}
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"gera-ai/internal/api/handlers"
+	"gera-ai/internal/api/middlewares"
+	"gera-ai/internal/config"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func OrganizationRouter(app fiber.Router, db *gorm.DB) {
+	jwt := middlewares.AuthMiddleware(config.Config.JWTSecret, db)
+	app.Post("/org/new", jwt, handlers.CreateOrganization(db))
+	app.Get("/org/all", jwt, handlers.GetOrganizations(db))
+	app.Get("/org/get/:id", jwt, handlers.GetOrganization(db))
+	app.Put("/org/settings", jwt, handlers.EditOrganizationSettings(db))
+	app.Put("/org/move", jwt, handlers.MoveToOrganization(db))
+
+	app.Post("/org/member/add", jwt, handlers.AddOrganizationMember(db))
+	app.Put("/org/member/role", jwt, handlers.ChangeOrganizationMemberRole(db))
+	app.Delete("/org/member/remove", jwt, handlers.RemoveOrganizationMember(db))
+}
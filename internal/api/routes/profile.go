@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"gera-ai/internal/api/handlers"
+	"gera-ai/internal/api/middlewares"
+	"gera-ai/internal/config"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func ProfileRouter(app fiber.Router, db *gorm.DB) {
+	jwt := middlewares.AuthMiddleware(config.Config.JWTSecret, db)
+	app.Get("/me", jwt, handlers.GetProfile(db))
+	app.Put("/me", jwt, handlers.UpdateProfile(db))
+	app.Delete("/me", jwt, handlers.DeleteAccount(db))
+
+	// выгрузка личных данных, архив скачивается по подписанной ссылке без токена
+	app.Post("/me/export", jwt, handlers.RequestDataExport(db))
+	app.Get("/me/exports", jwt, handlers.GetDataExports(db))
+	app.Get("/me/export/download/:id", handlers.DownloadDataExport(db))
+}
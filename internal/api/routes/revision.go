@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"gera-ai/internal/api/handlers"
+	"gera-ai/internal/api/middlewares"
+	"gera-ai/internal/config"
+	"gera-ai/internal/utils/rbac"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func RevisionRouter(app fiber.Router, db *gorm.DB) {
+	readTasks := middlewares.ScopedAuthMiddleware(config.Config.JWTSecret, db, rbac.ScopeTasksRead)
+	writeTasks := middlewares.ScopedAuthMiddleware(config.Config.JWTSecret, db, rbac.ScopeTasksWrite)
+	app.Get("/revision/all/:type/:id", readTasks, handlers.GetRevisions(db))
+	app.Get("/revision/diff/:type/:id", readTasks, handlers.DiffRevisions(db))
+	app.Post("/revision/restore", writeTasks, handlers.RestoreRevision(db))
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"gera-ai/internal/api/handlers"
+	"gera-ai/internal/api/middlewares"
+	"gera-ai/internal/config"
+	"gera-ai/internal/utils/rbac"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func SearchRouter(app fiber.Router, db *gorm.DB) {
+	readTasks := middlewares.ScopedAuthMiddleware(config.Config.JWTSecret, db, rbac.ScopeTasksRead)
+	app.Get("/search", readTasks, handlers.Search(db))
+}
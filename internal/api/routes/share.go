@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"gera-ai/internal/api/handlers"
+	"gera-ai/internal/api/middlewares"
+	"gera-ai/internal/config"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func ShareRouter(app fiber.Router, db *gorm.DB) {
+	jwt := middlewares.AuthMiddleware(config.Config.JWTSecret, db)
+	app.Post("/share/grant", jwt, handlers.GrantShare(db))
+	app.Delete("/share/revoke", jwt, handlers.RevokeShare(db))
+	app.Get("/share/all/:type/:id", jwt, handlers.GetShares(db))
+	app.Get("/share/received", jwt, handlers.GetSharedWithMe(db))
+
+	app.Post("/share/link/new", jwt, handlers.CreateShareLink(db))
+	app.Delete("/share/link/delete", jwt, handlers.DeleteShareLink(db))
+	// ссылка открывается без авторизации
+	app.Get("/share/link/:token", handlers.GetSharedResource(db))
+}
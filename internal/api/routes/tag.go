@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"gera-ai/internal/api/handlers"
+	"gera-ai/internal/api/middlewares"
+	"gera-ai/internal/config"
+	"gera-ai/internal/utils/rbac"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func TagRouter(app fiber.Router, db *gorm.DB) {
+	readTasks := middlewares.ScopedAuthMiddleware(config.Config.JWTSecret, db, rbac.ScopeTasksRead)
+	writeTasks := middlewares.ScopedAuthMiddleware(config.Config.JWTSecret, db, rbac.ScopeTasksWrite)
+	app.Get("/tags/dictionary", readTasks, handlers.GetClassificationDictionary(db))
+	app.Put("/tags/set", writeTasks, handlers.SetClassification(db))
+	app.Get("/tags/facets", readTasks, handlers.GetTagFacets(db))
+}
@@ -4,16 +4,24 @@ import (
 	"gera-ai/internal/api/handlers"
 	"gera-ai/internal/api/middlewares"
 	"gera-ai/internal/config"
+	"gera-ai/internal/utils/rbac"
+	"gera-ai/internal/utils/taskGenerator"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
-func TaskRouter(app fiber.Router, db *gorm.DB) {
-	jwt := middlewares.AuthMiddleware(config.Config.JWTSecret)
-	app.Post("/task/new", jwt, handlers.CreateTask(db))
-	app.Get("/task/get/:id", jwt, handlers.GetTask(db))
-	app.Put("/task/edit", jwt, handlers.EditTask(db))
-	app.Delete("/task/delete", jwt, handlers.DeleteTask(db))
+func TaskRouter(app fiber.Router, db *gorm.DB, tg *taskGenerator.TaskGenerator) {
+	readTasks := middlewares.ScopedAuthMiddleware(config.Config.JWTSecret, db, rbac.ScopeTasksRead)
+	writeTasks := middlewares.ScopedAuthMiddleware(config.Config.JWTSecret, db, rbac.ScopeTasksWrite)
+	classify := middlewares.ScopedAuthMiddleware(config.Config.JWTSecret, db, rbac.ScopeGenerate, rbac.ScopeTasksWrite)
+	deprecated := middlewares.Deprecated(config.Config.V1Deprecation.DeprecatedAt, config.Config.V1Deprecation.SunsetAt, "/api/v2/tasks")
+	app.Post("/task/new", deprecated, writeTasks, handlers.CreateTask(db, tg))
+	app.Get("/task/get/:id", deprecated, readTasks, handlers.GetTask(db))
+	app.Put("/task/edit", deprecated, writeTasks, handlers.EditTask(db))
+	app.Delete("/task/delete", deprecated, writeTasks, handlers.DeleteTask(db))
+	app.Post("/task/batch", deprecated, writeTasks, handlers.BatchTasks(db))
+	app.Post("/task/classify/:id", classify, handlers.ClassifyTask(db, tg))
+	app.Put("/task/classification", writeTasks, handlers.EditTaskClassification(db))
 
-	app.Get("/task/all", jwt, handlers.GetAllTasks(db))
+	app.Get("/task/all", deprecated, readTasks, handlers.GetAllTasks(db))
 }
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"gera-ai/internal/api/handlers"
+	"gera-ai/internal/api/middlewares"
+	"gera-ai/internal/config"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func TrashRouter(app fiber.Router, db *gorm.DB) {
+	jwt := middlewares.AuthMiddleware(config.Config.JWTSecret, db)
+	app.Get("/trash/all", jwt, handlers.GetTrash(db))
+	app.Post("/trash/restore", jwt, handlers.RestoreFromTrash(db))
+	app.Delete("/trash/purge", jwt, handlers.PurgeFromTrash(db))
+}
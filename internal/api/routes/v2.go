@@ -0,0 +1,36 @@
+package routes
+
+import (
+	"gera-ai/internal/api/handlers"
+	"gera-ai/internal/api/middlewares"
+	"gera-ai/internal/config"
+	"gera-ai/internal/utils/rbac"
+	"gera-ai/internal/utils/taskGenerator"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func V2Router(app fiber.Router, db *gorm.DB, tg *taskGenerator.TaskGenerator) {
+	readTasks := middlewares.ScopedAuthMiddleware(config.Config.JWTSecret, db, rbac.ScopeTasksRead)
+	writeTasks := middlewares.ScopedAuthMiddleware(config.Config.JWTSecret, db, rbac.ScopeTasksWrite)
+	app.Get("/tasks", readTasks, handlers.GetAllTasks(db))
+	app.Post("/tasks", writeTasks, handlers.CreateTaskV2(db, tg))
+	app.Post("/tasks/batch", writeTasks, handlers.BatchTasks(db))
+	app.Get("/tasks/:id", readTasks, handlers.GetTask(db))
+	app.Patch("/tasks/:id", writeTasks, handlers.PatchTask(db))
+	app.Delete("/tasks/:id", writeTasks, handlers.DeleteTaskV2(db))
+
+	app.Get("/condition-templates", readTasks, handlers.GetAllConditionTemplates(db))
+	app.Post("/condition-templates", writeTasks, handlers.CreateConditionTemplateV2(db))
+	app.Post("/condition-templates/batch", writeTasks, handlers.BatchConditionTemplates(db))
+	app.Get("/condition-templates/:id", readTasks, handlers.GetConditionTemplate(db))
+	app.Patch("/condition-templates/:id", writeTasks, handlers.PatchConditionTemplate(db))
+	app.Delete("/condition-templates/:id", writeTasks, handlers.DeleteConditionTemplateV2(db))
+
+	app.Get("/interests-templates", readTasks, handlers.GetAllInterestsTemplates(db))
+	app.Post("/interests-templates", writeTasks, handlers.CreateInterestsTemplateV2(db))
+	app.Post("/interests-templates/batch", writeTasks, handlers.BatchInterestsTemplates(db))
+	app.Get("/interests-templates/:id", readTasks, handlers.GetInterestsTemplate(db))
+	app.Patch("/interests-templates/:id", writeTasks, handlers.PatchInterestsTemplate(db))
+	app.Delete("/interests-templates/:id", writeTasks, handlers.DeleteInterestsTemplateV2(db))
+}
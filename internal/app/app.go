@@ -1,10 +1,16 @@
 package app
 
 import (
+	"gera-ai/internal/api/handlers"
 	"gera-ai/internal/api/routes"
 	"gera-ai/internal/config"
+	"gera-ai/internal/dataexport"
+	"gera-ai/internal/migrations"
 	dbmodels "gera-ai/internal/models/database"
+	"gera-ai/internal/trash"
 	"gera-ai/internal/utils/database"
+	"gera-ai/internal/utils/mailer"
+	"gera-ai/internal/utils/oauth"
 	"gera-ai/internal/utils/taskGenerator"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -31,24 +37,58 @@ func NewGeraApp() *GeraApp {
 	// migrate db models
 	migrateErr := db.AutoMigrate(
 		dbmodels.User{},
+		dbmodels.Tag{},
+		dbmodels.Subject{},
+		dbmodels.GradeLevel{},
 		dbmodels.Task{},
 		dbmodels.InterestsTemplate{},
 		dbmodels.ConditionTemplate{},
-		dbmodels.GenerationByInterestsHistory{},
-		dbmodels.GenerationByNoInterestsHistory{},
-		dbmodels.GenerationAnswersHistory{},
+		dbmodels.Generation{},
+		dbmodels.GenerationFeedback{},
+		dbmodels.Revision{},
+		dbmodels.Share{},
+		dbmodels.ShareLink{},
+		dbmodels.Publication{},
+		dbmodels.PublicationReport{},
+		dbmodels.Organization{},
+		dbmodels.OrganizationMember{},
+		dbmodels.Prompt{},
+		dbmodels.Session{},
+		dbmodels.RefreshToken{},
+		dbmodels.ExternalIdentity{},
+		dbmodels.OAuthState{},
+		dbmodels.PasswordResetToken{},
+		dbmodels.LoginFailure{},
+		dbmodels.AuditEvent{},
+		dbmodels.AccessToken{},
+		dbmodels.DataExport{},
 	)
 	if migrateErr != nil {
 		log.Fatalf("failed to migrate database: %v", migrateErr.Error())
 	}
+	if err := migrations.Run(db); err != nil {
+		log.Fatalf("failed to migrate data: %v", err)
+	}
+	if err := migrations.PromoteAdmins(db, config.Config.AdminLogins); err != nil {
+		log.Fatalf("failed to promote admins: %v", err)
+	}
 
 	tg, err := taskGenerator.NewTaskGenerator(config.Config.ApiKey, config.Config.ProxyURL)
 	if err != nil {
 		log.Fatalf("Failed to create task generator: %v", err)
 	}
-	tg = tg
+	if err := handlers.LoadPrompts(db, tg); err != nil {
+		log.Fatalf("failed to load prompts: %v", err)
+	}
+	mail, err := mailer.New(config.Config.Mail)
+	if err != nil {
+		log.Fatalf("failed to create mailer: %v", err)
+	}
+	if config.Config.Proxy.Header != "" && len(config.Config.Proxy.TrustedProxies) == 0 {
+		log.Printf("TRUSTED_PROXY_HEADER is set without TRUSTED_PROXIES, client addresses are taken from connections")
+	}
 	// init new fiber app and use swagger
-	app := fiber.New()
+	app := fiber.New(fiberConfig(config.Config.Proxy))
 
 	app.Use(cors.New())
 	app.Use(logger.New())
@@ -58,27 +98,55 @@ func NewGeraApp() *GeraApp {
 	app.Get("/swagger/*", swagger.HandlerDefault)
 	routes.SwaggerRouter(api)
 	routes.PingRouter(api)
-	routes.AuthRouter(api, db)
+	routes.AuthRouter(api, db, oauth.NewProviders(config.Config.OAuthProviders), mail)
+	routes.ProfileRouter(api, db)
 	routes.ConditionTemplateRouter(api, db)
 	routes.InterestsTemplateRouter(api, db)
-	routes.TaskRouter(api, db)
+	routes.TaskRouter(api, db, tg)
 	routes.AIGeneratorRouter(api, db, tg)
+	routes.ExportRouter(api, db)
+	routes.HistoryRouter(api, db, tg)
+	routes.FeedbackRouter(api, db)
+	routes.SearchRouter(api, db)
+	routes.TagRouter(api, db)
+	routes.RevisionRouter(api, db)
+	routes.TrashRouter(api, db)
+	routes.ShareRouter(api, db)
+	routes.LibraryRouter(api, db)
+	routes.OrganizationRouter(api, db)
+	routes.AdminRouter(api, db, tg)
+	routes.V2Router(api.Group("/v2"), db, tg)
+
+	trash.StartPurger(db, config.Config.TrashRetention, config.Config.TrashPurgeInterval)
+	dataexport.StartWorker(db, config.Config.DataExport.Dir, config.Config.DataExport.Retention, config.Config.DataExport.Interval)
 	return &GeraApp{
 		Fiber: app,
 		Db:    db,
 	}
 }
 
+// fiberConfig настраивает определение адреса клиента. Заголовок прокси принимается
+// только от доверенных адресов, иначе клиент мог бы подставить в него любой адрес
+func fiberConfig(proxy config.ProxyConfig) fiber.Config {
+	if proxy.Header == "" {
+		return fiber.Config{}
+	}
+	return fiber.Config{
+		ProxyHeader:             proxy.Header,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          proxy.TrustedProxies,
+		EnableIPValidation:      true,
+	}
+}
+
 func Start(app *GeraApp) {
 	if err := app.Fiber.Listen(":8080"); err != nil {
 		panic("failed to listen: " + err.Error())
 	}
 }
 
-//TODO реализовать авторизацию через яндекс ID
 // TODO Реализовать шаблоны вариантов
 // TODO проверить соответсвие валидации и бд
-// TODO реализовать получение истории генераций
 // TODO Убрать все коменты
 // TODO почему-то бд спамит что-то про root
 // TODO переписать эндпоинты в сваггере
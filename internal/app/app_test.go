@@ -0,0 +1,58 @@
+package app
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"gera-ai/internal/config"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// clientIP возвращает адрес клиента, который видит приложение с такими настройками прокси
+func clientIP(t *testing.T, proxy config.ProxyConfig, header string) string {
+	t.Helper()
+	app := fiber.New(fiberConfig(proxy))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString(c.IP())
+	})
+
+	request := httptest.NewRequest("GET", "/", nil)
+	if header != "" {
+		request.Header.Set("X-Real-IP", header)
+	}
+	response, err := app.Test(request, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestFiberConfigClientIP(t *testing.T) {
+	// Тестовые запросы fiber приходят с адреса 0.0.0.0
+	tests := []struct {
+		name   string
+		proxy  config.ProxyConfig
+		header string
+		want   string
+	}{
+		{"no proxy ignores header", config.ProxyConfig{}, "203.0.113.7", "0.0.0.0"},
+		{"trusted proxy", config.ProxyConfig{Header: "X-Real-IP", TrustedProxies: []string{"0.0.0.0"}}, "203.0.113.7", "203.0.113.7"},
+		{"trusted subnet", config.ProxyConfig{Header: "X-Real-IP", TrustedProxies: []string{"0.0.0.0/8"}}, "203.0.113.7", "203.0.113.7"},
+		{"untrusted proxy", config.ProxyConfig{Header: "X-Real-IP", TrustedProxies: []string{"10.0.0.1"}}, "203.0.113.7", "0.0.0.0"},
+		{"no trusted proxies", config.ProxyConfig{Header: "X-Real-IP"}, "203.0.113.7", "0.0.0.0"},
+		{"invalid header", config.ProxyConfig{Header: "X-Real-IP", TrustedProxies: []string{"0.0.0.0"}}, "not an address", "0.0.0.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := clientIP(t, tt.proxy, tt.header); got != tt.want {
+				t.Fatalf("IP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
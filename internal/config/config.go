@@ -4,6 +4,10 @@ import (
 	"fmt"
 	"gera-ai/internal/utils/dbURL"
 	"gera-ai/internal/utils/env"
+	"gera-ai/internal/utils/mailer"
+	"gera-ai/internal/utils/oauth"
+	"gera-ai/internal/utils/token"
+	"strings"
 	"time"
 )
 
@@ -15,8 +19,69 @@ type AppConfig struct {
 	DBConnectionString string
 	JWTSecret          string
 	JWTExpiration      time.Duration
+	RefreshExpiration  time.Duration
 	ApiKey             string
 	ProxyURL           string
+	TrashRetention     time.Duration
+	TrashPurgeInterval time.Duration
+	AdminLogins        []string
+	OAuthProviders     []oauth.Config
+	Mail               mailer.Config
+	PasswordResetURL   string
+	PasswordResetTTL   time.Duration
+	LoginGuard         LoginGuardConfig
+	// ReauthWindow - сколько после входа пользователь без пароля может подтверждать опасные действия
+	ReauthWindow time.Duration
+	DataExport   DataExportConfig
+	// BatchMaxItems - сколько операций можно передать в одном пакетном запросе
+	BatchMaxItems int
+	// V1Deprecation - даты отказа от маршрутов /api, у которых есть замена в /api/v2
+	V1Deprecation DeprecationConfig
+	Proxy         ProxyConfig
+}
+
+// ProxyConfig - обратный прокси перед приложением. От адреса клиента зависят
+// счетчики неудачных входов, поэтому за прокси его нужно брать из заголовка
+type ProxyConfig struct {
+	// Header - заголовок, в который прокси записывает адрес клиента, например X-Real-IP.
+	// Пустой - используется адрес соединения
+	Header string
+	// TrustedProxies - адреса и подсети прокси. Заголовок принимается только от них
+	TrustedProxies []string
+}
+
+// DeprecationConfig - даты из заголовков устаревшего маршрута
+type DeprecationConfig struct {
+	// DeprecatedAt - с какого момента маршрут устарел, SunsetAt - после какого он может перестать работать
+	DeprecatedAt time.Time
+	SunsetAt     time.Time
+}
+
+// DataExportConfig - выгрузка личных данных пользователя
+type DataExportConfig struct {
+	// Dir - каталог, где хранятся готовые архивы
+	Dir string
+	// Retention - сколько хранится готовый архив, LinkTTL - сколько действует ссылка на него
+	Retention time.Duration
+	LinkTTL   time.Duration
+	// Interval - как часто фоновая задача ищет новые выгрузки и удаляет старые архивы
+	Interval time.Duration
+	// SigningKey - ключ подписи ссылок на скачивание. Ссылки работают без входа,
+	// поэтому они не подписываются ключом JWT
+	SigningKey string
+}
+
+// LoginGuardConfig - защита входа от перебора паролей
+type LoginGuardConfig struct {
+	// DelayAfter - после стольких неудач подряд каждая следующая попытка ждет все дольше
+	DelayAfter int
+	MaxDelay   time.Duration
+	// AccountLimit и IPLimit - после стольких неудач логин или адрес блокируется на LockoutDuration
+	AccountLimit    int
+	IPLimit         int
+	LockoutDuration time.Duration
+	// Window - через столько времени без неудач счетчик обнуляется
+	Window time.Duration
 }
 
 func InitConfig() {
@@ -27,10 +92,84 @@ func InitConfig() {
 			env.GetEnv("POSTGRES_PASSWORD", ""),
 			env.GetEnv("POSTGRES_DB", ""),
 			env.GetEnv("POSTGRES_PORT", "")),
-		JWTSecret:     env.GetEnv("JWT_SECRET", ""),
-		JWTExpiration: time.Hour * 24 * 30,
-		ApiKey:        env.GetEnv("OPENAI_API_KEY", ""),
-		ProxyURL:      env.GetEnv("PROXY_URL", ""),
+		JWTSecret: env.GetEnv("JWT_SECRET", ""),
+		// access токен живет недолго, сессия продлевается refresh токеном
+		JWTExpiration:     env.GetEnvDuration("ACCESS_TOKEN_TTL", time.Minute*15),
+		RefreshExpiration: time.Hour * 24 * time.Duration(env.GetEnvInt("REFRESH_TOKEN_TTL_DAYS", 30)),
+		ApiKey:            env.GetEnv("OPENAI_API_KEY", ""),
+		ProxyURL:          env.GetEnv("PROXY_URL", ""),
+		// удаленные сущности хранятся в корзине TRASH_RETENTION_DAYS дней
+		TrashRetention:     time.Hour * 24 * time.Duration(env.GetEnvInt("TRASH_RETENTION_DAYS", 30)),
+		TrashPurgeInterval: env.GetEnvDuration("TRASH_PURGE_INTERVAL", time.Hour),
+		// пользователи из ADMIN_LOGINS получают роль admin при запуске
+		AdminLogins: env.GetEnvList("ADMIN_LOGINS"),
+		// провайдеры входа из OAUTH_PROVIDERS, например yandex,keycloak
+		OAuthProviders: oauthProviders(env.GetEnvList("OAUTH_PROVIDERS")),
+		// по умолчанию письма пишутся в лог, для локального перехватчика MAIL_BACKEND=smtp
+		Mail: mailer.Config{
+			Backend:      env.GetEnv("MAIL_BACKEND", "log"),
+			From:         env.GetEnv("MAIL_FROM", "noreply@gera-ai.local"),
+			SMTPHost:     env.GetEnv("SMTP_HOST", ""),
+			SMTPPort:     env.GetEnvInt("SMTP_PORT", 25),
+			SMTPUsername: env.GetEnv("SMTP_USERNAME", ""),
+			SMTPPassword: env.GetEnv("SMTP_PASSWORD", ""),
+			Dir:          env.GetEnv("MAIL_DIR", "mail"),
+		},
+		// к адресу из письма добавляется токен сброса пароля
+		PasswordResetURL: env.GetEnv("PASSWORD_RESET_URL", "http://localhost:3000/reset-password?token="),
+		PasswordResetTTL: env.GetEnvDuration("PASSWORD_RESET_TTL", time.Hour),
+		ReauthWindow:     env.GetEnvDuration("REAUTH_WINDOW", time.Minute*10),
+		BatchMaxItems:    env.GetEnvInt("BATCH_MAX_ITEMS", 100),
+		V1Deprecation: DeprecationConfig{
+			DeprecatedAt: env.GetEnvDate("API_V1_DEPRECATED_AT", time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)),
+			SunsetAt:     env.GetEnvDate("API_V1_SUNSET", time.Date(2027, time.April, 19, 0, 0, 0, 0, time.UTC)),
+		},
+		// за прокси адрес клиента берется из TRUSTED_PROXY_HEADER, если запрос пришел с адреса из TRUSTED_PROXIES
+		Proxy: ProxyConfig{
+			Header:         env.GetEnv("TRUSTED_PROXY_HEADER", ""),
+			TrustedProxies: env.GetEnvList("TRUSTED_PROXIES"),
+		},
+		DataExport: DataExportConfig{
+			Dir:       env.GetEnv("DATA_EXPORT_DIR", "exports"),
+			Retention: time.Hour * 24 * time.Duration(env.GetEnvInt("DATA_EXPORT_RETENTION_DAYS", 7)),
+			LinkTTL:   env.GetEnvDuration("DATA_EXPORT_LINK_TTL", time.Hour*24),
+			Interval:  env.GetEnvDuration("DATA_EXPORT_INTERVAL", time.Second*10),
+			// без DATA_EXPORT_SIGNING_KEY ключ выводится из JWT_SECRET
+			SigningKey: env.GetEnv("DATA_EXPORT_SIGNING_KEY", ""),
+		},
+		LoginGuard: LoginGuardConfig{
+			DelayAfter:      env.GetEnvInt("LOGIN_DELAY_AFTER", 3),
+			MaxDelay:        env.GetEnvDuration("LOGIN_MAX_DELAY", time.Minute),
+			AccountLimit:    env.GetEnvInt("LOGIN_ACCOUNT_LIMIT", 10),
+			IPLimit:         env.GetEnvInt("LOGIN_IP_LIMIT", 50),
+			LockoutDuration: env.GetEnvDuration("LOGIN_LOCKOUT_DURATION", time.Minute*15),
+			Window:          env.GetEnvDuration("LOGIN_FAILURE_WINDOW", time.Minute*15),
+		},
+	}
+	if Config.DataExport.SigningKey == "" {
+		Config.DataExport.SigningKey = token.DeriveKey(Config.JWTSecret, "gera-ai data export download link")
 	}
 	fmt.Println(Config.DBConnectionString)
 }
+
+// oauthProviders читает настройки провайдеров из переменных OAUTH_<NAME>_*.
+// Для OpenID Connect достаточно OAUTH_<NAME>_ISSUER, остальные адреса берутся из discovery
+func oauthProviders(names []string) []oauth.Config {
+	providers := make([]oauth.Config, 0, len(names))
+	for _, name := range names {
+		prefix := "OAUTH_" + strings.ToUpper(name) + "_"
+		providers = append(providers, oauth.Config{
+			Name:         strings.ToLower(name),
+			ClientID:     env.GetEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: env.GetEnv(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:  env.GetEnv(prefix+"REDIRECT_URL", ""),
+			Issuer:       env.GetEnv(prefix+"ISSUER", ""),
+			AuthURL:      env.GetEnv(prefix+"AUTH_URL", ""),
+			TokenURL:     env.GetEnv(prefix+"TOKEN_URL", ""),
+			UserInfoURL:  env.GetEnv(prefix+"USERINFO_URL", ""),
+			JWKSURL:      env.GetEnv(prefix+"JWKS_URL", ""),
+			Scopes:       strings.Fields(env.GetEnv(prefix+"SCOPES", "")),
+		})
+	}
+	return providers
+}
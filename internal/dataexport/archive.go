@@ -0,0 +1,432 @@
+package dataexport
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	dbmodels "gera-ai/internal/models/database"
+
+	"gorm.io/gorm"
+)
+
+// Записи архива. Поля совпадают с колонками CSV, удаленные в корзину записи тоже выгружаются
+
+type identityRecord struct {
+	Provider  string    `json:"provider"`
+	Email     string    `json:"email"`
+	Login     string    `json:"login"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type sessionRecord struct {
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+type accessTokenRecord struct {
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+type userRecord struct {
+	ID           uint                `json:"id"`
+	Login        string              `json:"login"`
+	Username     string              `json:"username"`
+	Email        *string             `json:"email"`
+	Role         string              `json:"role"`
+	Language     string              `json:"language"`
+	Style        string              `json:"style"`
+	CreatedAt    time.Time           `json:"created_at"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+	Identities   []identityRecord    `json:"identities"`
+	Sessions     []sessionRecord     `json:"sessions"`
+	AccessTokens []accessTokenRecord `json:"access_tokens"`
+}
+
+type taskRecord struct {
+	ID                   uint            `json:"id"`
+	Title                string          `json:"title"`
+	Condition            string          `json:"condition"`
+	Answer               string          `json:"answer"`
+	Topic                string          `json:"topic"`
+	Formulas             json.RawMessage `json:"formulas"`
+	Difficulty           *int            `json:"difficulty"`
+	ClassificationSource string          `json:"classification_source"`
+	Subjects             []string        `json:"subjects"`
+	Grades               []int           `json:"grades"`
+	Tags                 []string        `json:"tags"`
+	OrganizationID       *uint           `json:"organization_id"`
+	CreatedAt            time.Time       `json:"created_at"`
+	UpdatedAt            time.Time       `json:"updated_at"`
+	DeletedAt            *time.Time      `json:"deleted_at"`
+}
+
+type conditionTemplateRecord struct {
+	ID             uint       `json:"id"`
+	Title          string     `json:"title"`
+	Condition      string     `json:"condition"`
+	Subjects       []string   `json:"subjects"`
+	Grades         []int      `json:"grades"`
+	Tags           []string   `json:"tags"`
+	OrganizationID *uint      `json:"organization_id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	DeletedAt      *time.Time `json:"deleted_at"`
+}
+
+type interestsTemplateRecord struct {
+	ID             uint            `json:"id"`
+	Title          string          `json:"title"`
+	Interests      json.RawMessage `json:"interests"`
+	Subjects       []string        `json:"subjects"`
+	Grades         []int           `json:"grades"`
+	Tags           []string        `json:"tags"`
+	OrganizationID *uint           `json:"organization_id"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	DeletedAt      *time.Time      `json:"deleted_at"`
+}
+
+type generationRecord struct {
+	ID             uint            `json:"id"`
+	Type           string          `json:"type"`
+	Condition      string          `json:"condition"`
+	Interests      json.RawMessage `json:"interests"`
+	Result         string          `json:"result"`
+	AIModel        string          `json:"ai_model"`
+	PromptVersion  string          `json:"prompt_version"`
+	TaskID         *uint           `json:"task_id"`
+	OrganizationID *uint           `json:"organization_id"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeletedAt      *time.Time      `json:"deleted_at"`
+}
+
+// Write собирает ZIP архив со всеми данными пользователя: каждый набор лежит в JSON и в CSV
+func Write(db *gorm.DB, userID uint, w io.Writer) error {
+	archive := zip.NewWriter(w)
+
+	var user dbmodels.User
+	if err := db.Unscoped().First(&user, userID).Error; err != nil {
+		return err
+	}
+	userData, err := loadUser(db, user)
+	if err != nil {
+		return err
+	}
+	err = writeTable(archive, "user", []userRecord{userData},
+		[]string{"id", "login", "username", "email", "role", "language", "style", "created_at", "updated_at"},
+		func(u userRecord) []string {
+			email := ""
+			if u.Email != nil {
+				email = *u.Email
+			}
+			return []string{formatUint(u.ID), u.Login, u.Username, email, u.Role, u.Language, u.Style,
+				formatTime(&u.CreatedAt), formatTime(&u.UpdatedAt)}
+		})
+	if err != nil {
+		return err
+	}
+
+	var tasks []dbmodels.Task
+	err = db.Unscoped().Preload("Tags").Preload("Subjects").Preload("Grades").
+		Where("author_id = ?", userID).Order("id").Find(&tasks).Error
+	if err != nil {
+		return err
+	}
+	taskRecords := make([]taskRecord, len(tasks))
+	for i, task := range tasks {
+		taskRecords[i] = taskRecord{
+			ID:                   task.ID,
+			Title:                task.Title,
+			Condition:            task.Condition,
+			Answer:               task.Answer,
+			Topic:                task.Topic,
+			Formulas:             task.Formulas,
+			Difficulty:           task.Difficulty,
+			ClassificationSource: task.ClassificationSource,
+			Subjects:             subjectCodes(task.Subjects),
+			Grades:               grades(task.Grades),
+			Tags:                 tagNames(task.Tags),
+			OrganizationID:       task.OrganizationID,
+			CreatedAt:            task.CreatedAt,
+			UpdatedAt:            task.UpdatedAt,
+			DeletedAt:            deletedAt(task.DeletedAt),
+		}
+	}
+	err = writeTable(archive, "tasks", taskRecords,
+		[]string{"id", "title", "condition", "answer", "topic", "formulas", "difficulty", "classification_source",
+			"subjects", "grades", "tags", "organization_id", "created_at", "updated_at", "deleted_at"},
+		func(t taskRecord) []string {
+			difficulty := ""
+			if t.Difficulty != nil {
+				difficulty = strconv.Itoa(*t.Difficulty)
+			}
+			return []string{formatUint(t.ID), t.Title, t.Condition, t.Answer, t.Topic, string(t.Formulas), difficulty,
+				t.ClassificationSource, strings.Join(t.Subjects, ", "), joinInts(t.Grades), strings.Join(t.Tags, ", "),
+				formatOptionalUint(t.OrganizationID), formatTime(&t.CreatedAt), formatTime(&t.UpdatedAt), formatTime(t.DeletedAt)}
+		})
+	if err != nil {
+		return err
+	}
+
+	var conditionTemplates []dbmodels.ConditionTemplate
+	err = db.Unscoped().Preload("Tags").Preload("Subjects").Preload("Grades").
+		Where("author_id = ?", userID).Order("id").Find(&conditionTemplates).Error
+	if err != nil {
+		return err
+	}
+	conditionRecords := make([]conditionTemplateRecord, len(conditionTemplates))
+	for i, template := range conditionTemplates {
+		conditionRecords[i] = conditionTemplateRecord{
+			ID:             template.ID,
+			Title:          template.Title,
+			Condition:      template.Condition,
+			Subjects:       subjectCodes(template.Subjects),
+			Grades:         grades(template.Grades),
+			Tags:           tagNames(template.Tags),
+			OrganizationID: template.OrganizationID,
+			CreatedAt:      template.CreatedAt,
+			UpdatedAt:      template.UpdatedAt,
+			DeletedAt:      deletedAt(template.DeletedAt),
+		}
+	}
+	err = writeTable(archive, "condition_templates", conditionRecords,
+		[]string{"id", "title", "condition", "subjects", "grades", "tags", "organization_id", "created_at", "updated_at", "deleted_at"},
+		func(t conditionTemplateRecord) []string {
+			return []string{formatUint(t.ID), t.Title, t.Condition, strings.Join(t.Subjects, ", "), joinInts(t.Grades),
+				strings.Join(t.Tags, ", "), formatOptionalUint(t.OrganizationID),
+				formatTime(&t.CreatedAt), formatTime(&t.UpdatedAt), formatTime(t.DeletedAt)}
+		})
+	if err != nil {
+		return err
+	}
+
+	var interestsTemplates []dbmodels.InterestsTemplate
+	err = db.Unscoped().Preload("Tags").Preload("Subjects").Preload("Grades").
+		Where("author_id = ?", userID).Order("id").Find(&interestsTemplates).Error
+	if err != nil {
+		return err
+	}
+	interestsRecords := make([]interestsTemplateRecord, len(interestsTemplates))
+	for i, template := range interestsTemplates {
+		interestsRecords[i] = interestsTemplateRecord{
+			ID:             template.ID,
+			Title:          template.Title,
+			Interests:      template.Interests,
+			Subjects:       subjectCodes(template.Subjects),
+			Grades:         grades(template.Grades),
+			Tags:           tagNames(template.Tags),
+			OrganizationID: template.OrganizationID,
+			CreatedAt:      template.CreatedAt,
+			UpdatedAt:      template.UpdatedAt,
+			DeletedAt:      deletedAt(template.DeletedAt),
+		}
+	}
+	err = writeTable(archive, "interests_templates", interestsRecords,
+		[]string{"id", "title", "interests", "subjects", "grades", "tags", "organization_id", "created_at", "updated_at", "deleted_at"},
+		func(t interestsTemplateRecord) []string {
+			return []string{formatUint(t.ID), t.Title, string(t.Interests), strings.Join(t.Subjects, ", "), joinInts(t.Grades),
+				strings.Join(t.Tags, ", "), formatOptionalUint(t.OrganizationID),
+				formatTime(&t.CreatedAt), formatTime(&t.UpdatedAt), formatTime(t.DeletedAt)}
+		})
+	if err != nil {
+		return err
+	}
+
+	var generations []dbmodels.Generation
+	if err := db.Unscoped().Where("user_id = ?", userID).Order("id").Find(&generations).Error; err != nil {
+		return err
+	}
+	generationRecords := make([]generationRecord, len(generations))
+	for i, generation := range generations {
+		generationRecords[i] = generationRecord{
+			ID:             generation.ID,
+			Type:           generation.Type,
+			Condition:      generation.Condition,
+			Interests:      generation.Interests,
+			Result:         generation.Result,
+			AIModel:        generation.AIModel,
+			PromptVersion:  generation.PromptVersion,
+			TaskID:         generation.TaskID,
+			OrganizationID: generation.OrganizationID,
+			CreatedAt:      generation.CreatedAt,
+			DeletedAt:      deletedAt(generation.DeletedAt),
+		}
+	}
+	err = writeTable(archive, "generations", generationRecords,
+		[]string{"id", "type", "condition", "interests", "result", "ai_model", "prompt_version", "task_id",
+			"organization_id", "created_at", "deleted_at"},
+		func(g generationRecord) []string {
+			return []string{formatUint(g.ID), g.Type, g.Condition, string(g.Interests), g.Result, g.AIModel,
+				g.PromptVersion, formatOptionalUint(g.TaskID), formatOptionalUint(g.OrganizationID),
+				formatTime(&g.CreatedAt), formatTime(g.DeletedAt)}
+		})
+	if err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+// loadUser собирает учетную запись вместе со входами через провайдеров, сессиями и токенами.
+// Хеши паролей и токенов в выгрузку не попадают
+func loadUser(db *gorm.DB, user dbmodels.User) (userRecord, error) {
+	record := userRecord{
+		ID:           user.ID,
+		Login:        user.Login,
+		Username:     user.Username,
+		Email:        user.Email,
+		Role:         user.Role,
+		Language:     user.Language,
+		Style:        user.Style,
+		CreatedAt:    user.CreatedAt,
+		UpdatedAt:    user.UpdatedAt,
+		Identities:   []identityRecord{},
+		Sessions:     []sessionRecord{},
+		AccessTokens: []accessTokenRecord{},
+	}
+
+	var identities []dbmodels.ExternalIdentity
+	if err := db.Where("user_id = ?", user.ID).Order("id").Find(&identities).Error; err != nil {
+		return record, err
+	}
+	for _, identity := range identities {
+		record.Identities = append(record.Identities, identityRecord{
+			Provider:  identity.Provider,
+			Email:     identity.Email,
+			Login:     identity.Login,
+			CreatedAt: identity.CreatedAt,
+		})
+	}
+
+	var sessions []dbmodels.Session
+	if err := db.Where("user_id = ?", user.ID).Order("id").Find(&sessions).Error; err != nil {
+		return record, err
+	}
+	for _, session := range sessions {
+		record.Sessions = append(record.Sessions, sessionRecord{
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+			RevokedAt:  session.RevokedAt,
+		})
+	}
+
+	var accessTokens []dbmodels.AccessToken
+	if err := db.Where("user_id = ?", user.ID).Order("id").Find(&accessTokens).Error; err != nil {
+		return record, err
+	}
+	for _, accessToken := range accessTokens {
+		record.AccessTokens = append(record.AccessTokens, accessTokenRecord{
+			Name:       accessToken.Name,
+			Scopes:     strings.Fields(accessToken.Scopes),
+			CreatedAt:  accessToken.CreatedAt,
+			LastUsedAt: accessToken.LastUsedAt,
+			ExpiresAt:  accessToken.ExpiresAt,
+		})
+	}
+	return record, nil
+}
+
+// writeTable кладет в архив name.json и name.csv. CSV начинается с BOM, чтобы Excel открыл кириллицу
+func writeTable[T any](archive *zip.Writer, name string, records []T, header []string, row func(T) []string) error {
+	file, err := archive.Create(name + ".json")
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(records); err != nil {
+		return err
+	}
+
+	file, err = archive.Create(name + ".csv")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(file, "\ufeff"); err != nil {
+		return err
+	}
+	writer := csv.NewWriter(file)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := writer.Write(row(record)); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func subjectCodes(subjects []dbmodels.Subject) []string {
+	codes := make([]string, len(subjects))
+	for i, subject := range subjects {
+		codes[i] = subject.Code
+	}
+	return codes
+}
+
+func grades(levels []dbmodels.GradeLevel) []int {
+	values := make([]int, len(levels))
+	for i, level := range levels {
+		values[i] = level.Grade
+	}
+	return values
+}
+
+func tagNames(tags []dbmodels.Tag) []string {
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	return names
+}
+
+func deletedAt(value gorm.DeletedAt) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Time
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = strconv.Itoa(value)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatUint(value uint) string {
+	return strconv.FormatUint(uint64(value), 10)
+}
+
+func formatOptionalUint(value *uint) string {
+	if value == nil {
+		return ""
+	}
+	return formatUint(*value)
+}
+
+func formatTime(value *time.Time) string {
+	if value == nil {
+		return ""
+	}
+	return value.Format(time.RFC3339)
+}
@@ -0,0 +1,148 @@
+package dataexport
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	dbmodels "gera-ai/internal/models/database"
+	"gera-ai/internal/utils/token"
+
+	"gorm.io/gorm"
+)
+
+// DownloadPath - адрес скачивания архива без подписи
+const DownloadPath = "/api/me/export/download/"
+
+// SignedURL возвращает ссылку на архив, которая работает без входа до expires
+func SignedURL(secret string, id uint, expires time.Time) string {
+	unix := strconv.FormatInt(expires.Unix(), 10)
+	signature := token.Sign(secret, signedMessage(id, unix))
+	return fmt.Sprintf("%s%d?expires=%s&signature=%s", DownloadPath, id, unix, signature)
+}
+
+// VerifySignature проверяет подпись и срок ссылки из SignedURL
+func VerifySignature(secret string, id uint, expires, signature string) bool {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > unix {
+		return false
+	}
+	return token.Verify(secret, signedMessage(id, expires), signature)
+}
+
+func signedMessage(id uint, expires string) string {
+	return fmt.Sprintf("data-export:%d:%s", id, expires)
+}
+
+// Process собирает архив для выгрузки. Выгрузку забирает только один обработчик:
+// статус меняется условным обновлением pending -> processing
+func Process(db *gorm.DB, dir string, retention time.Duration, export dbmodels.DataExport) error {
+	result := db.Model(&export).
+		Where("status = ?", dbmodels.DataExportPending).
+		Update("status", dbmodels.DataExportProcessing)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+
+	fileName := fmt.Sprintf("export-%d.zip", export.ID)
+	size, err := writeFile(db, export.UserID, filepath.Join(dir, fileName))
+	if err != nil {
+		return db.Model(&export).Updates(map[string]interface{}{
+			"status": dbmodels.DataExportFailed,
+			"error":  err.Error(),
+		}).Error
+	}
+
+	now := time.Now()
+	return db.Model(&export).Updates(map[string]interface{}{
+		"status":       dbmodels.DataExportReady,
+		"file_name":    fileName,
+		"size":         size,
+		"completed_at": now,
+		"expires_at":   now.Add(retention),
+	}).Error
+}
+
+// writeFile пишет архив во временный файл и переименовывает его, чтобы недописанный архив нельзя было скачать
+func writeFile(db *gorm.DB, userID uint, path string) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return 0, err
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), ".export-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(file.Name())
+
+	if err := Write(db, userID, file); err != nil {
+		file.Close()
+		return 0, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return 0, err
+	}
+	if err := file.Close(); err != nil {
+		return 0, err
+	}
+	return info.Size(), os.Rename(file.Name(), path)
+}
+
+// Cleanup удаляет архивы, срок хранения которых истек
+func Cleanup(db *gorm.DB, dir string) (int, error) {
+	var exports []dbmodels.DataExport
+	err := db.Where("status = ? AND expires_at < ?", dbmodels.DataExportReady, time.Now()).Find(&exports).Error
+	if err != nil {
+		return 0, err
+	}
+
+	for _, export := range exports {
+		if err := os.Remove(filepath.Join(dir, export.FileName)); err != nil && !os.IsNotExist(err) {
+			return 0, err
+		}
+		if err := db.Model(&export).Update("status", dbmodels.DataExportExpired).Error; err != nil {
+			return 0, err
+		}
+	}
+	return len(exports), nil
+}
+
+// StartWorker раз в interval собирает ожидающие выгрузки и удаляет старые архивы.
+// Выгрузки, прерванные перезапуском сервера, возвращаются в очередь при старте
+func StartWorker(db *gorm.DB, dir string, retention, interval time.Duration) {
+	err := db.Model(&dbmodels.DataExport{}).
+		Where("status = ?", dbmodels.DataExportProcessing).
+		Update("status", dbmodels.DataExportPending).Error
+	if err != nil {
+		log.Printf("failed to requeue data exports: %v", err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for ; true; <-ticker.C {
+			var exports []dbmodels.DataExport
+			if err := db.Where("status = ?", dbmodels.DataExportPending).Order("id").Find(&exports).Error; err != nil {
+				log.Printf("failed to load data exports: %v", err)
+				continue
+			}
+			for _, export := range exports {
+				if err := Process(db, dir, retention, export); err != nil {
+					log.Printf("data export %d failed: %v", export.ID, err)
+				}
+			}
+
+			removed, err := Cleanup(db, dir)
+			if err != nil {
+				log.Printf("data export cleanup failed: %v", err)
+			} else if removed > 0 {
+				log.Printf("data export cleanup removed %d archives", removed)
+			}
+		}
+	}()
+}
@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	dbmodels "gera-ai/internal/models/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var subjects = []dbmodels.Subject{
+	{Code: "math", Name: "Математика"},
+	{Code: "algebra", Name: "Алгебра"},
+	{Code: "geometry", Name: "Геометрия"},
+	{Code: "physics", Name: "Физика"},
+	{Code: "chemistry", Name: "Химия"},
+	{Code: "biology", Name: "Биология"},
+	{Code: "informatics", Name: "Информатика"},
+	{Code: "geography", Name: "География"},
+	{Code: "economics", Name: "Экономика"},
+	{Code: "other", Name: "Другое"},
+}
+
+const maxGrade = 11
+
+// seedClassification заполняет справочники предметов и классов
+func seedClassification(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&subjects).Error; err != nil {
+			return err
+		}
+
+		grades := make([]dbmodels.GradeLevel, 0, maxGrade)
+		for grade := 1; grade <= maxGrade; grade++ {
+			grades = append(grades, dbmodels.GradeLevel{Grade: grade})
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&grades).Error
+	})
+}
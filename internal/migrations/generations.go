@@ -0,0 +1,70 @@
+package migrations
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+)
+
+// Старые таблицы истории генераций, которые переносятся в generations.
+// Модель и версия промпта не сохранялись, но до появления generations
+// они не менялись, поэтому подставляются значения того времени
+var legacyGenerationTables = []struct {
+	table     string
+	genType   string
+	interests string
+	result    string
+}{
+	{table: "generation_by_interests_histories", genType: "interests", interests: "interests", result: "task_text"},
+	{table: "generation_by_no_interests_histories", genType: "nointerests", interests: "NULL::json", result: "task_text"},
+	{table: "generation_answers_histories", genType: "answer", interests: "NULL::json", result: "answer"},
+}
+
+const (
+	legacyAIModel       = "gpt-3.5-turbo"
+	legacyPromptVersion = "v1"
+	legacyParameters    = `{"max_tokens":100}`
+)
+
+// mergeGenerationHistory переносит записи из трех таблиц истории в generations и удаляет старые таблицы.
+// Прежний id сохраняется в legacy_id, чтобы старые ссылки на историю можно было найти.
+// Таблица удаляется, только если перенесены все ее записи
+func mergeGenerationHistory(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, legacy := range legacyGenerationTables {
+			if !tx.Migrator().HasTable(legacy.table) {
+				continue
+			}
+
+			insert := fmt.Sprintf(
+				`INSERT INTO generations
+					(created_at, updated_at, deleted_at, user_id, type, condition, interests, result, ai_model, prompt_version, parameters, legacy_id)
+				SELECT created_at, updated_at, deleted_at, user_id, ?, condition, %s, %s, ?, ?, ?::json, id
+				FROM %s
+				ORDER BY id`,
+				legacy.interests, legacy.result, legacy.table,
+			)
+			if err := tx.Exec(insert, legacy.genType, legacyAIModel, legacyPromptVersion, legacyParameters).Error; err != nil {
+				return err
+			}
+
+			var legacyCount, migratedCount int64
+			if err := tx.Table(legacy.table).Count(&legacyCount).Error; err != nil {
+				return err
+			}
+			err := tx.Table("generations").
+				Where("type = ? AND legacy_id IS NOT NULL", legacy.genType).
+				Count(&migratedCount).Error
+			if err != nil {
+				return err
+			}
+			if migratedCount != legacyCount {
+				return fmt.Errorf("%s: moved %d of %d records", legacy.table, migratedCount, legacyCount)
+			}
+
+			if err := tx.Migrator().DropTable(legacy.table); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
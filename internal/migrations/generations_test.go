@@ -0,0 +1,104 @@
+package migrations
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, mock
+}
+
+func expectHasTable(mock sqlmock.Sqlmock, table string, exists bool) {
+	count := 0
+	if exists {
+		count = 1
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM information_schema.tables")).
+		WithArgs(table, "BASE TABLE").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+}
+
+func expectMove(mock sqlmock.Sqlmock, table, genType string, legacyCount, migratedCount int) {
+	mock.ExpectExec(`INSERT INTO generations\s+\(.*, legacy_id\)\s+SELECT .*, id\s+FROM `+table).
+		WithArgs(genType, legacyAIModel, legacyPromptVersion, legacyParameters).
+		WillReturnResult(sqlmock.NewResult(0, int64(migratedCount)))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "` + table + `"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(legacyCount))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "generations" WHERE type = $1 AND legacy_id IS NOT NULL`)).
+		WithArgs(genType).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(migratedCount))
+}
+
+func TestMergeGenerationHistoryKeepsLegacyIDs(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectBegin()
+	expectHasTable(mock, "generation_by_interests_histories", true)
+	expectMove(mock, "generation_by_interests_histories", "interests", 3, 3)
+	mock.ExpectExec(regexp.QuoteMeta(`DROP TABLE IF EXISTS "generation_by_interests_histories" CASCADE`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	expectHasTable(mock, "generation_by_no_interests_histories", false)
+	expectHasTable(mock, "generation_answers_histories", true)
+	expectMove(mock, "generation_answers_histories", "answer", 0, 0)
+	mock.ExpectExec(regexp.QuoteMeta(`DROP TABLE IF EXISTS "generation_answers_histories" CASCADE`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	if err := mergeGenerationHistory(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMergeGenerationHistoryKeepsTableOnMismatch(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	// Перенесено меньше записей, чем было: таблица не удаляется, перенос откатывается
+	mock.ExpectBegin()
+	expectHasTable(mock, "generation_by_interests_histories", true)
+	expectMove(mock, "generation_by_interests_histories", "interests", 5, 4)
+	mock.ExpectRollback()
+
+	err := mergeGenerationHistory(db)
+	if err == nil || err.Error() != "generation_by_interests_histories: moved 4 of 5 records" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMergeGenerationHistoryRollsBackOnInsertError(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectBegin()
+	expectHasTable(mock, "generation_by_interests_histories", true)
+	mock.ExpectExec("INSERT INTO generations").WillReturnError(errors.New("insert failed"))
+	mock.ExpectRollback()
+
+	if err := mergeGenerationHistory(db); err == nil {
+		t.Fatal("expected an error")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
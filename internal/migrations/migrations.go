@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+)
+
+// migration - шаг переноса данных, который нельзя выразить через AutoMigrate.
+// Каждый шаг должен быть идемпотентным, т.к. выполняется при каждом запуске
+type migration struct {
+	name string
+	run  func(db *gorm.DB) error
+}
+
+var migrations = []migration{
+	{name: "merge generation history tables", run: mergeGenerationHistory},
+	{name: "add full-text search vectors", run: addSearchVectors},
+	{name: "seed subjects and grades", run: seedClassification},
+	{name: "move admin flag to user roles", run: migrateAdminFlag},
+}
+
+// Run выполняет все шаги миграции данных по порядку
+func Run(db *gorm.DB) error {
+	for _, m := range migrations {
+		if err := m.run(db); err != nil {
+			return fmt.Errorf("migration %q failed: %w", m.name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	dbmodels "gera-ai/internal/models/database"
+	"gorm.io/gorm"
+)
+
+// migrateAdminFlag переносит флаг is_admin модераторов библиотеки в роль admin
+func migrateAdminFlag(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("UPDATE users SET role = ? WHERE role IS NULL OR role = ''", dbmodels.UserRoleTeacher).Error; err != nil {
+			return err
+		}
+		if !tx.Migrator().HasColumn(&dbmodels.User{}, "is_admin") {
+			return nil
+		}
+		if err := tx.Exec("UPDATE users SET role = ? WHERE is_admin", dbmodels.UserRoleAdmin).Error; err != nil {
+			return err
+		}
+		return tx.Migrator().DropColumn(&dbmodels.User{}, "is_admin")
+	})
+}
+
+// PromoteAdmins назначает роль admin пользователям из конфигурации,
+// чтобы на новой базе было кому раздавать роли
+func PromoteAdmins(db *gorm.DB, logins []string) error {
+	if len(logins) == 0 {
+		return nil
+	}
+	return db.Model(&dbmodels.User{}).
+		Where("login IN ?", logins).
+		Update("role", dbmodels.UserRoleAdmin).Error
+}
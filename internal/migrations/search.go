@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+)
+
+// Таблицы с полнотекстовым поиском. В search_vector попадает текст на русском и английском,
+// заголовок весит больше, чем условие
+var searchTables = []struct {
+	table string
+	title string
+	body  string
+}{
+	{table: "tasks", title: "title", body: "condition"},
+	{table: "condition_templates", title: "title", body: "condition"},
+	{table: "interests_templates", title: "title", body: "interests::text"},
+}
+
+// addSearchVectors добавляет генерируемые колонки search_vector и GIN индексы по ним
+func addSearchVectors(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, search := range searchTables {
+			addColumn := fmt.Sprintf(
+				`ALTER TABLE %[1]s ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+					setweight(to_tsvector('russian', coalesce(%[2]s, '')), 'A') ||
+					setweight(to_tsvector('english', coalesce(%[2]s, '')), 'A') ||
+					setweight(to_tsvector('russian', coalesce(%[3]s, '')), 'B') ||
+					setweight(to_tsvector('english', coalesce(%[3]s, '')), 'B')
+				) STORED`,
+				search.table, search.title, search.body,
+			)
+			if err := tx.Exec(addColumn).Error; err != nil {
+				return err
+			}
+
+			addIndex := fmt.Sprintf(
+				`CREATE INDEX IF NOT EXISTS idx_%[1]s_search_vector ON %[1]s USING GIN (search_vector)`,
+				search.table,
+			)
+			if err := tx.Exec(addIndex).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,31 @@
+package database
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// AccessTokenPrefix - начало персонального токена, по нему middleware отличает токен от JWT
+const AccessTokenPrefix = "gat_"
+
+// AccessToken - персональный токен для скриптов и интеграций.
+// Хранится только SHA-256 от токена, сам токен показывается один раз при создании
+type AccessToken struct {
+	gorm.Model
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	UserID    uint   `gorm:"index"`
+	User      User   `gorm:"foreignKey:UserID;references:id"`
+	Name      string `gorm:"type:varchar(100)"`
+	TokenHash string `gorm:"type:varchar(64);unique"`
+	// Hint - первые символы токена, чтобы пользователь мог узнать его в списке
+	Hint string `gorm:"type:varchar(12)"`
+	// Scopes - области доступа через пробел, например "tasks:read generate"
+	Scopes string `gorm:"type:varchar(300)"`
+	// ExpiresAt - nil, если токен бессрочный
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
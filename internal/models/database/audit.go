@@ -0,0 +1,32 @@
+package database
+
+import (
+	"time"
+)
+
+// Типы событий аудита
+const (
+	AuditLoginLockout   = "login_lockout"
+	AuditAccountDeleted = "account_deleted"
+)
+
+// AuditEvent - событие безопасности, которое должно остаться в истории
+type AuditEvent struct {
+	ID   uint   `gorm:"primaryKey;autoIncrement"`
+	Type string `gorm:"type:varchar(50);index"`
+	// UserID - пользователь, к которому относится событие, если он известен
+	UserID    *uint     `gorm:"index"`
+	IP        string    `gorm:"type:varchar(45)"`
+	Details   string    `gorm:"type:text"`
+	CreatedAt time.Time `gorm:"index"`
+}
+
+// LoginFailure - счетчик неудачных входов по логину или IP адресу.
+// Key имеет вид login:<логин> или ip:<адрес>
+type LoginFailure struct {
+	ID            uint   `gorm:"primaryKey;autoIncrement"`
+	Key           string `gorm:"type:varchar(100);unique"`
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+}
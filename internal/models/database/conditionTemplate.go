@@ -12,6 +12,15 @@ type ConditionTemplate struct {
 	Author    User   `gorm:"foreignKey:AuthorID;references:id"`
 	Title     string `gorm:"type:varchar(100)"`
 	Condition string `gorm:"type:varchar(2000)"`
+	// LibrarySourceID - публикация библиотеки, из которой склонирована запись, Attribution - ее авторство и лицензия
+	LibrarySourceID *uint
+	Attribution     string       `gorm:"type:varchar(300)"`
+	Tags            []Tag        `gorm:"many2many:condition_template_tags"`
+	Subjects        []Subject    `gorm:"many2many:condition_template_subjects"`
+	Grades          []GradeLevel `gorm:"many2many:condition_template_grades"`
+	// OrganizationID - организация, в рабочем пространстве которой лежит шаблон
+	OrganizationID *uint `gorm:"index"`
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	DeletedAt gorm.DeletedAt `gorm:"index"`
@@ -0,0 +1,36 @@
+package database
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// Состояния выгрузки личных данных
+const (
+	DataExportPending    = "pending"
+	DataExportProcessing = "processing"
+	DataExportReady      = "ready"
+	DataExportFailed     = "failed"
+	// DataExportExpired - архив удален по истечении срока хранения
+	DataExportExpired = "expired"
+)
+
+// DataExport - задание на выгрузку всех данных пользователя в ZIP архив.
+// Архив собирается в фоне и хранится в каталоге выгрузок до ExpiresAt
+type DataExport struct {
+	gorm.Model
+	ID     uint   `gorm:"primaryKey;autoIncrement"`
+	UserID uint   `gorm:"index"`
+	User   User   `gorm:"foreignKey:UserID;references:id"`
+	Status string `gorm:"type:varchar(20);index"`
+	Error  string `gorm:"type:varchar(500)"`
+	// FileName - имя архива в каталоге выгрузок
+	FileName    string `gorm:"type:varchar(100)"`
+	Size        int64
+	CompletedAt *time.Time
+	ExpiresAt   *time.Time
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"encoding/json"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Типы генераций
+const (
+	GenerationTypeInterests   = "interests"
+	GenerationTypeNoInterests = "nointerests"
+	GenerationTypeAnswer      = "answer"
+	// GenerationTypeClassification - классификация задачи. Не показывается как вариант задачи,
+	// но расходует лимит организации, как и другие обращения к модели
+	GenerationTypeClassification = "classification"
+)
+
+type Generation struct {
+	gorm.Model
+	ID     uint   `gorm:"primaryKey;autoIncrement"`
+	UserID uint   `gorm:"index"`
+	User   User   `gorm:"foreignKey:UserID;references:id"`
+	Type   string `gorm:"type:varchar(20);index"`
+	// OrganizationID - организация, на лимит которой записана генерация
+	OrganizationID *uint `gorm:"index"`
+
+	TaskID              *uint              `gorm:"index"`
+	Task                *Task              `gorm:"foreignKey:TaskID;references:id"`
+	ConditionTemplateID *uint              `gorm:"index"`
+	ConditionTemplate   *ConditionTemplate `gorm:"foreignKey:ConditionTemplateID;references:id"`
+	InterestsTemplateID *uint              `gorm:"index"`
+	InterestsTemplate   *InterestsTemplate `gorm:"foreignKey:InterestsTemplateID;references:id"`
+	// ParentGenerationID связывает разбор задачи с вариантом, для которого он сделан
+	ParentGenerationID *uint       `gorm:"index"`
+	ParentGeneration   *Generation `gorm:"foreignKey:ParentGenerationID;references:id"`
+
+	Condition string          `gorm:"type:varchar(2000)"`
+	Interests json.RawMessage `gorm:"type:json"`
+	Result    string          `gorm:"type:varchar(3000)"`
+
+	// LegacyID - id записи в старой таблице истории, из которой перенесена генерация.
+	// Таблицу определяет тип генерации: у каждого типа была своя
+	LegacyID *uint `gorm:"index"`
+
+	AIModel       string          `gorm:"type:varchar(50)"`
+	PromptVersion string          `gorm:"type:varchar(20);index"`
+	Parameters    json.RawMessage `gorm:"type:json"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
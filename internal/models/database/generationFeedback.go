@@ -0,0 +1,34 @@
+package database
+
+import (
+	"encoding/json"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Метки проблем, которые учитель может поставить генерации
+const (
+	FeedbackTagChangedNumbers = "changed_numbers"
+	FeedbackTagInappropriate  = "inappropriate"
+	FeedbackTagTooLong        = "too_long"
+	FeedbackTagBoring         = "boring"
+)
+
+// GenerationFeedback - оценка генерации учителем. У пользователя одна оценка на генерацию
+type GenerationFeedback struct {
+	gorm.Model
+	ID           uint       `gorm:"primaryKey;autoIncrement"`
+	GenerationID uint       `gorm:"uniqueIndex:idx_feedback_generation_user"`
+	Generation   Generation `gorm:"foreignKey:GenerationID;references:id"`
+	UserID       uint       `gorm:"uniqueIndex:idx_feedback_generation_user"`
+	User         User       `gorm:"foreignKey:UserID;references:id"`
+
+	Rating   *int
+	ThumbsUp *bool
+	Comment  string          `gorm:"type:varchar(1000)"`
+	Tags     json.RawMessage `gorm:"type:json"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
@@ -0,0 +1,7 @@
+package database
+
+// GradeLevel - класс (год обучения) из фиксированного справочника
+type GradeLevel struct {
+	ID    uint `gorm:"primaryKey;autoIncrement"`
+	Grade int  `gorm:"unique"`
+}
@@ -0,0 +1,38 @@
+package database
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// ExternalIdentity - учетная запись пользователя у внешнего провайдера входа
+type ExternalIdentity struct {
+	gorm.Model
+	ID       uint   `gorm:"primaryKey;autoIncrement"`
+	UserID   uint   `gorm:"index"`
+	User     User   `gorm:"foreignKey:UserID;references:id"`
+	Provider string `gorm:"type:varchar(30);uniqueIndex:idx_identity_provider_subject"`
+	Subject  string `gorm:"type:varchar(255);uniqueIndex:idx_identity_provider_subject"`
+	Email    string `gorm:"type:varchar(255)"`
+	Login    string `gorm:"type:varchar(100)"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+// OAuthState - начатый вход через провайдера. Хранится хеш state и PKCE verifier до callback
+type OAuthState struct {
+	ID           uint   `gorm:"primaryKey;autoIncrement"`
+	StateHash    string `gorm:"type:varchar(64);unique"`
+	Provider     string `gorm:"type:varchar(30)"`
+	CodeVerifier string `gorm:"type:varchar(100)"`
+	// BindingHash - хеш значения из cookie браузера, который начал вход. Callback из другого браузера отклоняется
+	BindingHash string `gorm:"type:varchar(64)"`
+	// Nonce передается OpenID Connect провайдеру и должен вернуться в ID токене
+	Nonce string `gorm:"type:varchar(64)"`
+	// LinkUserID - пользователь, к которому привязывается учетная запись, nil - обычный вход
+	LinkUserID *uint
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+}
@@ -13,6 +13,12 @@ type InterestsTemplate struct {
 	Author    User            `gorm:"foreignKey:AuthorID;references:id"`
 	Title     string          `gorm:"type:varchar(100)"`
 	Interests json.RawMessage `gorm:"type:json"`
+	Tags      []Tag           `gorm:"many2many:interests_template_tags"`
+	Subjects  []Subject       `gorm:"many2many:interests_template_subjects"`
+	Grades    []GradeLevel    `gorm:"many2many:interests_template_grades"`
+	// OrganizationID - организация, в рабочем пространстве которой лежит шаблон
+	OrganizationID *uint `gorm:"index"`
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	DeletedAt gorm.DeletedAt `gorm:"index"`
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// Роли участников организации
+const (
+	OrganizationRoleOwner   = "owner"
+	OrganizationRoleAdmin   = "admin"
+	OrganizationRoleTeacher = "teacher"
+)
+
+// Organization - школа с общим рабочим пространством
+type Organization struct {
+	gorm.Model
+	ID      uint   `gorm:"primaryKey;autoIncrement"`
+	Name    string `gorm:"type:varchar(100)"`
+	OwnerID uint
+	Owner   User `gorm:"foreignKey:OwnerID;references:id"`
+
+	// Настройки организации
+	DefaultLanguage string `gorm:"type:varchar(10);default:ru"`
+	// MonthlyGenerationLimit - сколько генераций участники могут сделать за месяц, 0 - без ограничений
+	MonthlyGenerationLimit int
+
+	Members []OrganizationMember
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+// OrganizationMember - участие пользователя в организации
+type OrganizationMember struct {
+	gorm.Model
+	ID             uint         `gorm:"primaryKey;autoIncrement"`
+	OrganizationID uint         `gorm:"uniqueIndex:idx_member_organization_user"`
+	Organization   Organization `gorm:"foreignKey:OrganizationID;references:id"`
+	UserID         uint         `gorm:"uniqueIndex:idx_member_organization_user;index"`
+	User           User         `gorm:"foreignKey:UserID;references:id"`
+	Role           string       `gorm:"type:varchar(10)"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
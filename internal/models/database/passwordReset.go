@@ -0,0 +1,21 @@
+package database
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// PasswordResetToken - одноразовый токен сброса пароля из письма. Хранится только SHA-256 от токена
+type PasswordResetToken struct {
+	gorm.Model
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	UserID    uint   `gorm:"index"`
+	User      User   `gorm:"foreignKey:UserID;references:id"`
+	TokenHash string `gorm:"type:varchar(64);unique"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
@@ -0,0 +1,22 @@
+package database
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// Prompt - промпт генератора, измененный администратором.
+// Удаленная запись означает возврат к промпту по умолчанию, номер правки при этом сохраняется
+type Prompt struct {
+	gorm.Model
+	ID          uint   `gorm:"primaryKey;autoIncrement"`
+	Key         string `gorm:"type:varchar(30);uniqueIndex"`
+	Text        string `gorm:"type:text"`
+	Revision    int
+	UpdatedByID uint
+	UpdatedBy   User `gorm:"foreignKey:UpdatedByID;references:id"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
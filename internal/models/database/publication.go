@@ -0,0 +1,79 @@
+package database
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// Лицензии, под которыми можно опубликовать задачу в библиотеке
+const (
+	LicenseCCBY   = "cc-by"
+	LicenseCCBYSA = "cc-by-sa"
+	LicenseCCBYNC = "cc-by-nc"
+	LicenseCC0    = "cc0"
+)
+
+// Состояния публикации
+const (
+	PublicationStatusPublished = "published"
+	// PublicationStatusWithdrawn - автор снял публикацию
+	PublicationStatusWithdrawn = "withdrawn"
+	// PublicationStatusUnpublished - публикацию снял модератор
+	PublicationStatusUnpublished = "unpublished"
+)
+
+// Publication - снимок задачи или шаблона условия в общей библиотеке.
+// Содержимое копируется при публикации, поэтому правки исходника не меняют библиотеку
+type Publication struct {
+	gorm.Model
+	ID           uint   `gorm:"primaryKey;autoIncrement"`
+	AuthorID     uint   `gorm:"index"`
+	Author       User   `gorm:"foreignKey:AuthorID;references:id"`
+	ResourceType string `gorm:"type:varchar(30);index:idx_publication_source"`
+	SourceID     uint   `gorm:"index:idx_publication_source"`
+
+	Title       string `gorm:"type:varchar(100)"`
+	Condition   string `gorm:"type:varchar(2000)"`
+	Answer      string `gorm:"type:varchar(100)"`
+	Description string `gorm:"type:varchar(1000)"`
+	License     string `gorm:"type:varchar(20)"`
+
+	Subjects []Subject    `gorm:"many2many:publication_subjects"`
+	Grades   []GradeLevel `gorm:"many2many:publication_grades"`
+
+	Status         string `gorm:"type:varchar(20);index"`
+	CloneCount     int
+	ReportCount    int
+	ModeratedByID  *uint
+	ModerationNote string `gorm:"type:varchar(500)"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+// Причины жалобы на публикацию
+const (
+	ReportReasonSpam        = "spam"
+	ReportReasonAbusive     = "abusive"
+	ReportReasonCopyright   = "copyright"
+	ReportReasonWrongAnswer = "wrong_answer"
+	ReportReasonOther       = "other"
+)
+
+// PublicationReport - жалоба пользователя на публикацию. Одна жалоба от пользователя на публикацию
+type PublicationReport struct {
+	gorm.Model
+	ID            uint        `gorm:"primaryKey;autoIncrement"`
+	PublicationID uint        `gorm:"uniqueIndex:idx_report_publication_user"`
+	Publication   Publication `gorm:"foreignKey:PublicationID;references:id"`
+	UserID        uint        `gorm:"uniqueIndex:idx_report_publication_user"`
+	User          User        `gorm:"foreignKey:UserID;references:id"`
+	Reason        string      `gorm:"type:varchar(20)"`
+	Comment       string      `gorm:"type:varchar(1000)"`
+	Resolved      bool        `gorm:"index"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
@@ -0,0 +1,28 @@
+package database
+
+import "time"
+
+// Типы сущностей, для которых хранится история правок
+const (
+	RevisionResourceTask              = "task"
+	RevisionResourceConditionTemplate = "condition_template"
+)
+
+// Revision - неизменяемый снимок задачи или шаблона условия после правки.
+// Ревизии не редактируются и не удаляются мягко, поэтому gorm.Model не используется
+type Revision struct {
+	ID           uint   `gorm:"primaryKey;autoIncrement"`
+	ResourceType string `gorm:"type:varchar(30);uniqueIndex:idx_revision_resource_number"`
+	ResourceID   uint   `gorm:"uniqueIndex:idx_revision_resource_number"`
+	Number       int    `gorm:"uniqueIndex:idx_revision_resource_number"`
+	AuthorID     uint
+	Author       User   `gorm:"foreignKey:AuthorID;references:id"`
+	Title        string `gorm:"type:varchar(100)"`
+	Condition    string `gorm:"type:varchar(2000)"`
+	Answer       string `gorm:"type:varchar(100)"`
+
+	// RestoredFrom - номер ревизии, из которой восстановлен этот снимок
+	RestoredFrom *int
+
+	CreatedAt time.Time
+}
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// Session - вход пользователя с одного устройства. Живет, пока обновляется refresh токен
+type Session struct {
+	gorm.Model
+	ID         uint   `gorm:"primaryKey;autoIncrement"`
+	UserID     uint   `gorm:"index"`
+	User       User   `gorm:"foreignKey:UserID;references:id"`
+	UserAgent  string `gorm:"type:varchar(300)"`
+	IP         string `gorm:"type:varchar(45)"`
+	LastUsedAt time.Time
+	ExpiresAt  time.Time
+	// RevokedAt - время выхода или отзыва сессии, nil - сессия активна
+	RevokedAt *time.Time
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+// RefreshToken - refresh токен сессии. Хранится только SHA-256 от токена.
+// При обновлении токен помечается использованным и заменяется новым,
+// повторное использование означает утечку и отзывает всю сессию
+type RefreshToken struct {
+	gorm.Model
+	ID        uint    `gorm:"primaryKey;autoIncrement"`
+	SessionID uint    `gorm:"index"`
+	Session   Session `gorm:"foreignKey:SessionID;references:id"`
+	TokenHash string  `gorm:"type:varchar(64);unique"`
+	UsedAt    *time.Time
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
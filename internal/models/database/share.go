@@ -0,0 +1,45 @@
+package database
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// Роли доступа к чужой задаче или шаблону
+const (
+	ShareRoleViewer = "viewer"
+	ShareRoleEditor = "editor"
+)
+
+// Share - доступ пользователя к задаче или шаблону другого автора
+type Share struct {
+	gorm.Model
+	ID           uint   `gorm:"primaryKey;autoIncrement"`
+	ResourceType string `gorm:"type:varchar(30);uniqueIndex:idx_share_resource_user"`
+	ResourceID   uint   `gorm:"uniqueIndex:idx_share_resource_user"`
+	UserID       uint   `gorm:"uniqueIndex:idx_share_resource_user;index"`
+	User         User   `gorm:"foreignKey:UserID;references:id"`
+	Role         string `gorm:"type:varchar(10)"`
+	GrantedByID  uint
+	GrantedBy    User `gorm:"foreignKey:GrantedByID;references:id"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+// ShareLink - ссылка только для чтения. Хранится только SHA-256 от токена
+type ShareLink struct {
+	gorm.Model
+	ID           uint   `gorm:"primaryKey;autoIncrement"`
+	ResourceType string `gorm:"type:varchar(30);index:idx_share_link_resource"`
+	ResourceID   uint   `gorm:"index:idx_share_link_resource"`
+	TokenHash    string `gorm:"type:varchar(64);unique"`
+	CreatedByID  uint
+	CreatedBy    User `gorm:"foreignKey:CreatedByID;references:id"`
+	ExpiresAt    *time.Time
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
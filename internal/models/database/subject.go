@@ -0,0 +1,8 @@
+package database
+
+// Subject - предмет из фиксированного справочника
+type Subject struct {
+	ID   uint   `gorm:"primaryKey;autoIncrement"`
+	Code string `gorm:"type:varchar(30);unique"`
+	Name string `gorm:"type:varchar(50)"`
+}
@@ -0,0 +1,19 @@
+package database
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// Tag - свободная метка пользователя. Имена уникальны в пределах автора
+type Tag struct {
+	gorm.Model
+	ID       uint   `gorm:"primaryKey;autoIncrement"`
+	AuthorID uint   `gorm:"uniqueIndex:idx_tag_author_name"`
+	Author   User   `gorm:"foreignKey:AuthorID;references:id"`
+	Name     string `gorm:"type:varchar(50);uniqueIndex:idx_tag_author_name"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
@@ -1,10 +1,16 @@
 package database
 
 import (
+	"encoding/json"
 	"gorm.io/gorm"
 	"time"
 )
 
+const (
+	ClassificationSourceAI      = "ai"
+	ClassificationSourceTeacher = "teacher"
+)
+
 type Task struct {
 	gorm.Model
 	ID        uint `gorm:"primaryKey;autoIncrement"`
@@ -14,6 +20,27 @@ type Task struct {
 	Condition string `gorm:"type:varchar(2000)"`
 	Answer    string `gorm:"type:varchar(100)"`
 
+	// OrganizationID - организация, в рабочем пространстве которой лежит задача
+	OrganizationID *uint `gorm:"index"`
+
+	// SourceGenerationID указывает на генерацию, из которой создана задача
+	SourceGenerationID   *uint           `gorm:"index"`
+	GenerationParameters json.RawMessage `gorm:"type:json"`
+
+	// Классификация задачи: заполняется моделью и может быть исправлена учителем
+	Topic                string          `gorm:"type:varchar(100)"`
+	Formulas             json.RawMessage `gorm:"type:json"`
+	Difficulty           *int
+	ClassificationSource string `gorm:"type:varchar(20)"`
+
+	// LibrarySourceID - публикация библиотеки, из которой склонирована запись, Attribution - ее авторство и лицензия
+	LibrarySourceID *uint
+	Attribution     string `gorm:"type:varchar(300)"`
+
+	Tags     []Tag        `gorm:"many2many:task_tags"`
+	Subjects []Subject    `gorm:"many2many:task_subjects"`
+	Grades   []GradeLevel `gorm:"many2many:task_grades"`
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	DeletedAt gorm.DeletedAt `gorm:"index"`
@@ -5,12 +5,37 @@ import (
 	"time"
 )
 
+// Роли пользователей
+const (
+	UserRoleTeacher   = "teacher"
+	UserRoleModerator = "moderator"
+	UserRoleAdmin     = "admin"
+)
+
+// Стили сюжета, которые пользователь выбирает по умолчанию
+const (
+	UserStyleNeutral = "neutral"
+	UserStylePlayful = "playful"
+	UserStyleFormal  = "formal"
+)
+
 type User struct {
 	gorm.Model
 	ID           uint   `gorm:"primaryKey;autoIncrement"`
 	Login        string `gorm:"type:varchar(20);unique"`
 	PasswordHash string
 	Username     string `gorm:"type:varchar(35)"`
+	Role         string `gorm:"type:varchar(20);default:teacher;index"`
+	// Email нужен для сброса пароля, у разных пользователей не повторяется
+	Email *string `gorm:"type:varchar(255);uniqueIndex"`
+
+	// Настройки по умолчанию для генерации
+	Language string `gorm:"type:varchar(10);default:ru"`
+	Style    string `gorm:"type:varchar(20);default:neutral"`
+
+	// BlockedAt - время блокировки администратором, nil - пользователь активен
+	BlockedAt   *time.Time
+	BlockReason string `gorm:"type:varchar(300)"`
 
 	CreatedAt time.Time
 	UpdatedAt time.Time
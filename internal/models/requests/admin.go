@@ -0,0 +1,38 @@
+package requests
+
+type GetUsers struct {
+	Role string `validate:"omitempty,oneof=teacher moderator admin"`
+	Page Page
+}
+
+type BlockUser struct {
+	ID     uint   `validate:"required"`
+	Reason string `validate:"max=300"`
+}
+
+type UnblockUser struct {
+	ID uint `validate:"required"`
+}
+
+type ChangeUserRole struct {
+	ID   uint   `validate:"required"`
+	Role string `validate:"required,oneof=teacher moderator admin"`
+}
+
+type GetUsage struct {
+	Days int `validate:"min=1,max=365"`
+}
+
+type EditPrompt struct {
+	Key  string `validate:"required,oneof=interests nointerests answer classification"`
+	Text string `validate:"required,max=10000"`
+}
+
+type ResetPrompt struct {
+	Key string `validate:"required,oneof=interests nointerests answer classification"`
+}
+
+type GetAuditEvents struct {
+	Type string `validate:"max=50"`
+	Page Page
+}
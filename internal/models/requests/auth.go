@@ -9,4 +9,55 @@ type Register struct {
 	Login    string `validate:"required,min=5,max=20,alphanum"`
 	Username string `validate:"required,max=35"`
 	Password string `validate:"required,min=8"`
+	Email    string `validate:"omitempty,email,max=255"`
+}
+
+type RefreshToken struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type Logout struct {
+	// All завершает все сессии пользователя, а не только текущую
+	All bool
+}
+
+type RevokeSession struct {
+	ID uint `validate:"required"`
+}
+
+type OAuthCallback struct {
+	Provider string `validate:"required"`
+	Code     string `validate:"required"`
+	State    string `validate:"required"`
+}
+
+type UnlinkIdentity struct {
+	ID uint `validate:"required"`
+}
+
+type ChangePassword struct {
+	// OldPassword не нужен, если пароль еще не задан (вход только через провайдера)
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password" validate:"required,min=8"`
+}
+
+type RequestPasswordReset struct {
+	// Login - логин или email пользователя
+	Login string `validate:"required,max=255"`
+}
+
+type ConfirmPasswordReset struct {
+	Token       string `validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=8"`
+}
+
+type CreateAccessToken struct {
+	Name   string   `validate:"required,max=100"`
+	Scopes []string `validate:"required,min=1,dive,oneof=tasks:read tasks:write generate history:read history:write"`
+	// ExpiresInDays - срок действия токена, 0 - бессрочный
+	ExpiresInDays int `json:"expires_in_days" validate:"min=0,max=365"`
+}
+
+type RevokeAccessToken struct {
+	ID uint `validate:"required"`
 }
@@ -0,0 +1,25 @@
+package requests
+
+const (
+	BatchModeAtomic     = "atomic"
+	BatchModeBestEffort = "best_effort"
+)
+
+// BatchItem - одна операция пакета. Какие поля нужны, зависит от Op и типа записей:
+// create и update принимают те же поля, что и обычные запросы создания и редактирования
+type BatchItem struct {
+	Op        string `validate:"required,oneof=create update delete move"`
+	ID        uint   `validate:"required_unless=Op create"`
+	Title     string
+	Condition string
+	Answer    string
+	Interests []string
+	// OrganizationID = 0 в операции move возвращает запись в личное пространство
+	OrganizationID uint `json:"organization_id"`
+}
+
+type Batch struct {
+	// Mode: atomic применяет все операции или ни одной, best_effort - каждую по отдельности
+	Mode  string      `validate:"required,oneof=atomic best_effort"`
+	Items []BatchItem `validate:"required,min=1,dive"`
+}
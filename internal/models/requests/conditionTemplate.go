@@ -15,6 +15,12 @@ type EditConditionTemplate struct {
 	Condition string `validate:"required,max=2000"`
 }
 
+// PatchConditionTemplate - частичное изменение шаблона условия, nil-поля не меняются
+type PatchConditionTemplate struct {
+	Title     *string `validate:"omitempty,min=3,max=100"`
+	Condition *string `validate:"omitempty,min=1,max=2000"`
+}
+
 type DeleteConditionTemplate struct {
 	ID uint `validate:"required"`
 }
@@ -0,0 +1,19 @@
+package requests
+
+type ExportTask struct {
+	ID     uint   `validate:"required"`
+	Format string `validate:"required,oneof=moodle gift qti"`
+}
+
+type ExportTasks struct {
+	Format string `validate:"required,oneof=moodle gift qti"`
+	IDs    []uint `validate:"required,min=1,max=100"`
+}
+
+type ExportGenerations struct {
+	Format        string `validate:"required,oneof=moodle gift qti"`
+	Title         string `validate:"max=100"`
+	Answer        string `validate:"max=100"`
+	TaskID        uint   `json:"task_id"`
+	GenerationIDs []uint `json:"generation_ids" validate:"required,min=1,max=100"`
+}
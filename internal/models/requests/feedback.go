@@ -0,0 +1,21 @@
+package requests
+
+type CreateFeedback struct {
+	GenerationID uint     `json:"generation_id" validate:"required"`
+	Rating       *int     `validate:"omitempty,min=1,max=5"`
+	Thumb        string   `validate:"omitempty,oneof=up down"`
+	Comment      string   `validate:"max=1000"`
+	Tags         []string `validate:"max=4,unique,dive,oneof=changed_numbers inappropriate too_long boring"`
+}
+
+type GetGenerationFeedback struct {
+	GenerationID uint `validate:"required"`
+}
+
+type DeleteFeedback struct {
+	ID uint `validate:"required"`
+}
+
+type GetFeedbackStats struct {
+	GroupBy string `validate:"required,oneof=prompt_version model"`
+}
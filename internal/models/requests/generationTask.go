@@ -1,14 +1,21 @@
 package requests
 
 type GenerateByInterests struct {
-	Condition string   `validate:"required,max=2000"`
-	Interests []string `validate:"required,min=0,max=20,dive,max=100"`
+	Condition           string   `validate:"required,max=2000"`
+	Interests           []string `validate:"required,min=0,max=20,dive,max=100"`
+	TaskID              *uint    `json:"task_id"`
+	ConditionTemplateID *uint    `json:"condition_template_id"`
+	InterestsTemplateID *uint    `json:"interests_template_id"`
 }
 
 type GenerateByNoInterests struct {
-	Condition string `validate:"required,max=2000"`
+	Condition           string `validate:"required,max=2000"`
+	TaskID              *uint  `json:"task_id"`
+	ConditionTemplateID *uint  `json:"condition_template_id"`
 }
 
 type GenerateAnswer struct {
-	Condition string `validate:"required,max=2000"`
+	Condition    string `validate:"required,max=2000"`
+	TaskID       *uint  `json:"task_id"`
+	GenerationID *uint  `json:"generation_id"`
 }
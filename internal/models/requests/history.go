@@ -0,0 +1,28 @@
+package requests
+
+import "time"
+
+type GetAllGenerationHistory struct {
+	Types  []string `validate:"dive,oneof=interests nointerests answer classification"`
+	From   *time.Time
+	To     *time.Time
+	Search string `validate:"max=200"`
+	Page   Page
+}
+
+type GetGenerationHistory struct {
+	ID uint `validate:"required"`
+	// LegacyType - тип генерации, если ID - id из старой таблицы истории
+	LegacyType string `validate:"omitempty,oneof=interests nointerests answer"`
+}
+
+type DeleteGenerationHistory struct {
+	ID uint `validate:"required"`
+}
+
+type PromoteGeneration struct {
+	ID       uint   `validate:"required"`
+	Title    string `validate:"omitempty,min=3,max=100"`
+	Answer   string `validate:"max=100"`
+	Classify bool
+}
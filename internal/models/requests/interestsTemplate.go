@@ -15,6 +15,12 @@ type EditInterestsTemplate struct {
 	Interests []string `validate:"required,dive,max=100"`
 }
 
+// PatchInterestsTemplate - частичное изменение шаблона интересов, nil-поля не меняются
+type PatchInterestsTemplate struct {
+	Title     *string   `validate:"omitempty,min=3,max=100"`
+	Interests *[]string `validate:"omitempty,min=1,dive,max=100"`
+}
+
 type DeleteInterestsTemplate struct {
 	ID uint `validate:"required"`
 }
@@ -0,0 +1,38 @@
+package requests
+
+type PublishToLibrary struct {
+	ResourceType string `json:"resource_type" validate:"required,oneof=task condition_template"`
+	ID           uint   `validate:"required"`
+	Description  string `validate:"max=1000"`
+	License      string `validate:"required,oneof=cc-by cc-by-sa cc-by-nc cc0"`
+}
+
+type WithdrawPublication struct {
+	ID uint `validate:"required"`
+}
+
+type BrowseLibrary struct {
+	Types  []string `validate:"dive,oneof=task condition_template"`
+	Filter ClassificationFilter
+	Page   Page
+}
+
+type GetPublication struct {
+	ID uint `validate:"required"`
+}
+
+type ClonePublication struct {
+	ID uint `validate:"required"`
+}
+
+type ReportPublication struct {
+	ID      uint   `validate:"required"`
+	Reason  string `validate:"required,oneof=spam abusive copyright wrong_answer other"`
+	Comment string `validate:"max=1000"`
+}
+
+type ModeratePublication struct {
+	ID     uint   `validate:"required"`
+	Status string `validate:"required,oneof=published unpublished"`
+	Note   string `validate:"max=500"`
+}
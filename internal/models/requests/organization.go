@@ -0,0 +1,40 @@
+package requests
+
+type CreateOrganization struct {
+	Name string `validate:"required,min=3,max=100"`
+}
+
+type GetOrganization struct {
+	ID uint `validate:"required"`
+}
+
+type EditOrganizationSettings struct {
+	ID                     uint   `validate:"required"`
+	Name                   string `validate:"required,min=3,max=100"`
+	DefaultLanguage        string `json:"default_language" validate:"required,oneof=ru en"`
+	MonthlyGenerationLimit int    `json:"monthly_generation_limit" validate:"min=0,max=1000000"`
+}
+
+type AddOrganizationMember struct {
+	ID    uint   `validate:"required"`
+	Login string `validate:"required,min=3,max=20"`
+	Role  string `validate:"required,oneof=admin teacher"`
+}
+
+type ChangeOrganizationMemberRole struct {
+	ID     uint   `validate:"required"`
+	UserID uint   `json:"user_id" validate:"required"`
+	Role   string `validate:"required,oneof=admin teacher"`
+}
+
+type RemoveOrganizationMember struct {
+	ID     uint `validate:"required"`
+	UserID uint `json:"user_id" validate:"required"`
+}
+
+type MoveToOrganization struct {
+	ResourceType string `json:"resource_type" validate:"required,oneof=task condition_template interests_template"`
+	ID           uint   `validate:"required"`
+	// OrganizationID = 0 возвращает запись в личное пространство
+	OrganizationID uint `json:"organization_id"`
+}
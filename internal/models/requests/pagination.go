@@ -0,0 +1,10 @@
+package requests
+
+// Page - параметры страницы списка. Offset оставлен для старых клиентов
+// и учитывается, только если курсор не передан
+type Page struct {
+	Cursor       string
+	Limit        int `validate:"min=1,max=100"`
+	IncludeTotal bool
+	Offset       int `validate:"min=0"`
+}
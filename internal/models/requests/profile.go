@@ -0,0 +1,14 @@
+package requests
+
+// UpdateProfile - изменяются только переданные поля. Пустой Email удаляет адрес
+type UpdateProfile struct {
+	Username *string `validate:"omitempty,min=1,max=35"`
+	Email    *string `validate:"omitempty,max=255,eq=|email"`
+	Language *string `validate:"omitempty,oneof=ru en"`
+	Style    *string `validate:"omitempty,oneof=neutral playful formal"`
+}
+
+type DeleteAccount struct {
+	// Password не нужен, если пароль не задан: тогда требуется недавний вход
+	Password string
+}
@@ -0,0 +1,19 @@
+package requests
+
+type GetRevisions struct {
+	ResourceType string `validate:"required,oneof=task condition_template"`
+	ID           uint   `validate:"required"`
+}
+
+type DiffRevisions struct {
+	ResourceType string `validate:"required,oneof=task condition_template"`
+	ID           uint   `validate:"required"`
+	From         int    `validate:"required,min=1"`
+	To           int    `validate:"required,min=1"`
+}
+
+type RestoreRevision struct {
+	ResourceType string `json:"resource_type" validate:"required,oneof=task condition_template"`
+	ID           uint   `validate:"required"`
+	Number       int    `validate:"required,min=1"`
+}
@@ -0,0 +1,7 @@
+package requests
+
+type Search struct {
+	Query string   `validate:"required,min=2,max=200"`
+	Types []string `validate:"dive,oneof=task condition_template interests_template"`
+	Limit int      `validate:"min=1,max=100"`
+}
@@ -0,0 +1,29 @@
+package requests
+
+type GrantShare struct {
+	ResourceType string `json:"resource_type" validate:"required,oneof=task condition_template interests_template"`
+	ID           uint   `validate:"required"`
+	Login        string `validate:"required,min=3,max=20"`
+	Role         string `validate:"required,oneof=viewer editor"`
+}
+
+type RevokeShare struct {
+	ResourceType string `json:"resource_type" validate:"required,oneof=task condition_template interests_template"`
+	ID           uint   `validate:"required"`
+	UserID       uint   `json:"user_id" validate:"required"`
+}
+
+type GetShares struct {
+	ResourceType string `validate:"required,oneof=task condition_template interests_template"`
+	ID           uint   `validate:"required"`
+}
+
+type CreateShareLink struct {
+	ResourceType  string `json:"resource_type" validate:"required,oneof=task condition_template interests_template"`
+	ID            uint   `validate:"required"`
+	ExpiresInDays int    `json:"expires_in_days" validate:"min=0,max=365"`
+}
+
+type DeleteShareLink struct {
+	ID uint `validate:"required"`
+}
@@ -0,0 +1,20 @@
+package requests
+
+type ClassificationFilter struct {
+	Tags     []string `validate:"max=10,dive,min=1,max=50"`
+	Subjects []string `validate:"max=10,dive,min=1,max=30"`
+	Grades   []int    `validate:"max=11,dive,min=1,max=11"`
+}
+
+type SetClassification struct {
+	ResourceType string   `json:"resource_type" validate:"required,oneof=task condition_template interests_template"`
+	ID           uint     `validate:"required"`
+	Tags         []string `validate:"max=20,dive,min=1,max=50"`
+	Subjects     []string `validate:"max=5,dive,min=1,max=30"`
+	Grades       []int    `validate:"max=11,dive,min=1,max=11"`
+}
+
+type GetTagFacets struct {
+	ResourceType string `validate:"required,oneof=task condition_template interests_template"`
+	Filter       ClassificationFilter
+}
@@ -4,6 +4,7 @@ type CreateTask struct {
 	Title     string `validate:"required,min=3,max=100"`
 	Condition string `validate:"required,max=2000"`
 	Answer    string `validate:"required,max=100"`
+	Classify  bool
 }
 
 type GetTask struct {
@@ -17,6 +18,25 @@ type EditTask struct {
 	Answer    string `validate:"required,max=100"`
 }
 
+// PatchTask - частичное изменение задачи, nil-поля не меняются
+type PatchTask struct {
+	Title     *string `validate:"omitempty,min=3,max=100"`
+	Condition *string `validate:"omitempty,min=1,max=2000"`
+	Answer    *string `validate:"omitempty,min=1,max=100"`
+}
+
 type DeleteTask struct {
 	ID uint `validate:"required"`
 }
+
+type ClassifyTask struct {
+	ID uint `validate:"required"`
+}
+
+type EditTaskClassification struct {
+	ID         uint     `validate:"required"`
+	Subject    string   `validate:"max=50"`
+	Topic      string   `validate:"max=100"`
+	Formulas   []string `validate:"max=20,dive,max=200"`
+	Difficulty *int     `validate:"omitempty,min=1,max=5"`
+}
@@ -0,0 +1,15 @@
+package requests
+
+type GetTrash struct {
+	Types []string `validate:"dive,oneof=task condition_template interests_template history"`
+}
+
+type RestoreFromTrash struct {
+	ResourceType string `json:"resource_type" validate:"required,oneof=task condition_template interests_template history"`
+	ID           uint   `validate:"required"`
+}
+
+type PurgeFromTrash struct {
+	ResourceType string `json:"resource_type" validate:"required,oneof=task condition_template interests_template history"`
+	ID           uint   `validate:"required"`
+}
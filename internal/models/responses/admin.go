@@ -0,0 +1,77 @@
+package responses
+
+import "time"
+
+type AdminUserDTO struct {
+	ID          uint       `json:"id"`
+	Login       string     `json:"login"`
+	Username    string     `json:"username"`
+	Role        string     `json:"role"`
+	BlockedAt   *time.Time `json:"blocked_at"`
+	BlockReason string     `json:"block_reason"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type GetUsersDTO struct {
+	Users []AdminUserDTO `json:"users"`
+	Total int64          `json:"total"`
+	Page  PageDTO        `json:"page"`
+}
+
+type AdminUserStatusDTO struct {
+	Status string       `json:"status"`
+	User   AdminUserDTO `json:"user"`
+}
+
+// UserUsageDTO - количество генераций пользователя за период
+type UserUsageDTO struct {
+	UserID      uint   `json:"user_id"`
+	Login       string `json:"login"`
+	Generations int64  `json:"generations"`
+}
+
+// UsageDTO - статистика использования сервиса с момента Since
+type UsageDTO struct {
+	Since              time.Time        `json:"since"`
+	Users              int64            `json:"users"`
+	NewUsers           int64            `json:"new_users"`
+	BlockedUsers       int64            `json:"blocked_users"`
+	Tasks              int64            `json:"tasks"`
+	Generations        int64            `json:"generations"`
+	GenerationsByType  map[string]int64 `json:"generations_by_type"`
+	GenerationsByModel map[string]int64 `json:"generations_by_model"`
+	TopUsers           []UserUsageDTO   `json:"top_users"`
+}
+
+type PromptDTO struct {
+	Key         string     `json:"key"`
+	Text        string     `json:"text"`
+	DefaultText string     `json:"default_text"`
+	Version     string     `json:"version"`
+	Customized  bool       `json:"customized"`
+	UpdatedAt   *time.Time `json:"updated_at"`
+}
+
+type GetPromptsDTO struct {
+	Prompts []PromptDTO `json:"prompts"`
+}
+
+type EditPromptDTO struct {
+	Status string    `json:"status"`
+	Prompt PromptDTO `json:"prompt"`
+}
+
+type AuditEventDTO struct {
+	ID        uint      `json:"id"`
+	Type      string    `json:"type"`
+	UserID    *uint     `json:"user_id"`
+	IP        string    `json:"ip"`
+	Details   string    `json:"details"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type GetAuditEventsDTO struct {
+	Events []AuditEventDTO `json:"events"`
+	Total  int64           `json:"total"`
+	Page   PageDTO         `json:"page"`
+}
@@ -1,6 +1,77 @@
 package responses
 
+import "time"
+
 type AuthDTO struct {
 	Status string `json:"status" example:"success"`
-	Token  string `json:"token" example:"your-jwt-token"`
+	// Token - access токен, передается в заголовке Authorization
+	Token        string    `json:"token" example:"your-jwt-token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	RefreshToken string    `json:"refresh_token" example:"your-refresh-token"`
+}
+
+type SessionDTO struct {
+	ID         uint      `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	Current    bool      `json:"current"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+type GetSessionsDTO struct {
+	Sessions []SessionDTO `json:"sessions"`
+	Page     PageDTO      `json:"page"`
+}
+
+type SessionStatusDTO struct {
+	Status string `json:"status"`
+}
+
+type OAuthProvidersDTO struct {
+	Providers []string `json:"providers"`
+}
+
+type OAuthStartDTO struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+type IdentityDTO struct {
+	ID        uint      `json:"id"`
+	Provider  string    `json:"provider"`
+	Email     string    `json:"email"`
+	Login     string    `json:"login"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type GetIdentitiesDTO struct {
+	Identities []IdentityDTO `json:"identities"`
+}
+
+type IdentityStatusDTO struct {
+	Status   string      `json:"status"`
+	Identity IdentityDTO `json:"identity"`
+}
+
+type AccessTokenDTO struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Hint       string     `json:"hint"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+type GetAccessTokensDTO struct {
+	Tokens []AccessTokenDTO `json:"tokens"`
+	Page   PageDTO          `json:"page"`
+}
+
+// CreateAccessTokenDTO - созданный токен. Token возвращается только в этом ответе
+type CreateAccessTokenDTO struct {
+	Status      string         `json:"status"`
+	Token       string         `json:"token"`
+	AccessToken AccessTokenDTO `json:"access_token"`
 }
@@ -0,0 +1,19 @@
+package responses
+
+// BatchItemResultDTO - результат одной операции пакета. Status - HTTP-код,
+// который вернул бы обычный запрос с этой операцией
+type BatchItemResultDTO struct {
+	Index  int    `json:"index"`
+	Op     string `json:"op"`
+	ID     uint   `json:"id,omitempty"`
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type BatchDTO struct {
+	Status    string               `json:"status"`
+	Mode      string               `json:"mode"`
+	Succeeded int                  `json:"succeeded"`
+	Failed    int                  `json:"failed"`
+	Results   []BatchItemResultDTO `json:"results"`
+}
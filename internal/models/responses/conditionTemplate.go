@@ -1,9 +1,11 @@
 package responses
 
 type ConditionTemplateDTO struct {
-	ID        uint   `json:"id"`
-	Title     string `json:"title"`
-	Condition string `json:"condition"`
+	ID          uint   `json:"id"`
+	Title       string `json:"title"`
+	Condition   string `json:"condition"`
+	Attribution string `json:"attribution,omitempty"`
+	*ClassificationDTO
 }
 
 type CreateConditionTemplateDTO struct {
@@ -26,4 +28,5 @@ type DeleteConditionTemplateDTO struct {
 
 type GetAllConditionTemplatesDTO struct {
 	TaskTemplates []ConditionTemplateDTO `json:"task_templates"`
+	Page          PageDTO                `json:"page"`
 }
@@ -0,0 +1,46 @@
+package responses
+
+import "time"
+
+type FeedbackDTO struct {
+	ID           uint      `json:"id"`
+	GenerationID uint      `json:"generation_id"`
+	UserID       uint      `json:"user_id"`
+	Rating       *int      `json:"rating,omitempty"`
+	Thumb        string    `json:"thumb,omitempty"`
+	Comment      string    `json:"comment,omitempty"`
+	Tags         []string  `json:"tags"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type CreateFeedbackDTO struct {
+	Status   string      `json:"status"`
+	Feedback FeedbackDTO `json:"feedback"`
+}
+
+type GetGenerationFeedbackDTO struct {
+	Feedback []FeedbackDTO `json:"feedback"`
+}
+
+type DeleteFeedbackDTO struct {
+	Status string `json:"status"`
+}
+
+// FeedbackStatsDTO - сводка оценок для одной версии промпта или модели внутри типа генерации.
+// Версии промптов нумеруются отдельно для каждого промпта, поэтому без типа их не сравнить
+type FeedbackStatsDTO struct {
+	Type          string           `json:"type"`
+	Group         string           `json:"group"`
+	Generations   int64            `json:"generations"`
+	Feedback      int64            `json:"feedback"`
+	Ratings       int64            `json:"ratings"`
+	AverageRating *float64         `json:"average_rating"`
+	ThumbsUp      int64            `json:"thumbs_up"`
+	ThumbsDown    int64            `json:"thumbs_down"`
+	Tags          map[string]int64 `json:"tags"`
+}
+
+type GetFeedbackStatsDTO struct {
+	GroupBy string             `json:"group_by"`
+	Stats   []FeedbackStatsDTO `json:"stats"`
+}
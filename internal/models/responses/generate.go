@@ -2,10 +2,12 @@ package responses
 
 type GeneratedTaskResponse struct {
 	Status        string `json:"status"`
+	GenerationID  uint   `json:"generation_id"`
 	GeneratedText string `json:"generated_text"`
 }
 
 type GeneratedAnswerResponse struct {
 	Status        string `json:"status"`
+	GenerationID  uint   `json:"generation_id"`
 	GeneratedText string `json:"generated_text"`
 }
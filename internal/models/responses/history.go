@@ -0,0 +1,32 @@
+package responses
+
+import "time"
+
+type GenerationHistoryDTO struct {
+	ID                  uint      `json:"id"`
+	Type                string    `json:"type"`
+	TaskID              *uint     `json:"task_id,omitempty"`
+	ConditionTemplateID *uint     `json:"condition_template_id,omitempty"`
+	InterestsTemplateID *uint     `json:"interests_template_id,omitempty"`
+	Condition           string    `json:"condition"`
+	Interests           []string  `json:"interests,omitempty"`
+	Text                string    `json:"text"`
+	Model               string    `json:"model"`
+	PromptVersion       string    `json:"prompt_version"`
+	CreatedAt           time.Time `json:"created_at"`
+	LegacyID            *uint     `json:"legacy_id,omitempty"`
+}
+
+type GetGenerationHistoryDTO struct {
+	History GenerationHistoryDTO `json:"history"`
+}
+
+type GetAllGenerationHistoryDTO struct {
+	History    []GenerationHistoryDTO `json:"history"`
+	NextCursor string                 `json:"next_cursor,omitempty"`
+	Page       PageDTO                `json:"page"`
+}
+
+type DeleteGenerationHistoryDTO struct {
+	Status string `json:"status"`
+}
@@ -4,6 +4,7 @@ type InterestsTemplateDTO struct {
 	ID        uint     `json:"id"`
 	Title     string   `json:"title"`
 	Interests []string `json:"interests"`
+	*ClassificationDTO
 }
 
 type CreateInterestsTemplateDTO struct {
@@ -26,4 +27,5 @@ type DeleteInterestsTemplateDTO struct {
 
 type GetAllInterestsTemplatesDTO struct {
 	TaskTemplates []InterestsTemplateDTO `json:"task_templates"`
+	Page          PageDTO                `json:"page"`
 }
@@ -0,0 +1,71 @@
+package responses
+
+import "time"
+
+// PublicationDTO - задача или шаблон условия в общей библиотеке
+type PublicationDTO struct {
+	ID           uint      `json:"id"`
+	ResourceType string    `json:"resource_type"`
+	AuthorID     uint      `json:"author_id"`
+	AuthorName   string    `json:"author_name"`
+	Title        string    `json:"title"`
+	Condition    string    `json:"condition"`
+	Answer       string    `json:"answer,omitempty"`
+	Description  string    `json:"description"`
+	License      string    `json:"license"`
+	Subjects     []string  `json:"subjects"`
+	Grades       []int     `json:"grades"`
+	Status       string    `json:"status"`
+	CloneCount   int       `json:"clone_count"`
+	PublishedAt  time.Time `json:"published_at"`
+}
+
+type PublishToLibraryDTO struct {
+	Status      string         `json:"status"`
+	Publication PublicationDTO `json:"publication"`
+}
+
+type WithdrawPublicationDTO struct {
+	Status string `json:"status"`
+}
+
+type BrowseLibraryDTO struct {
+	Publications []PublicationDTO `json:"publications"`
+	Page         PageDTO          `json:"page"`
+}
+
+type GetPublicationDTO struct {
+	Publication PublicationDTO `json:"publication"`
+}
+
+type ClonePublicationDTO struct {
+	Status       string `json:"status"`
+	ResourceType string `json:"resource_type"`
+	ID           uint   `json:"id"`
+	Attribution  string `json:"attribution"`
+}
+
+type ReportPublicationDTO struct {
+	Status string `json:"status"`
+}
+
+// PublicationReportDTO - жалоба на публикацию для модератора
+type PublicationReportDTO struct {
+	ID            uint      `json:"id"`
+	PublicationID uint      `json:"publication_id"`
+	UserID        uint      `json:"user_id"`
+	Reason        string    `json:"reason"`
+	Comment       string    `json:"comment"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type GetModerationQueueDTO struct {
+	Publications []PublicationDTO       `json:"publications"`
+	Reports      []PublicationReportDTO `json:"reports"`
+	Page         PageDTO                `json:"page"`
+}
+
+type ModeratePublicationDTO struct {
+	Status      string         `json:"status"`
+	Publication PublicationDTO `json:"publication"`
+}
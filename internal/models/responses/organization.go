@@ -0,0 +1,49 @@
+package responses
+
+import "time"
+
+// OrganizationDTO - организация и роль текущего пользователя в ней
+type OrganizationDTO struct {
+	ID                     uint   `json:"id"`
+	Name                   string `json:"name"`
+	Role                   string `json:"role"`
+	DefaultLanguage        string `json:"default_language"`
+	MonthlyGenerationLimit int    `json:"monthly_generation_limit"`
+}
+
+type OrganizationMemberDTO struct {
+	UserID   uint      `json:"user_id"`
+	Login    string    `json:"login"`
+	Username string    `json:"username"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+type CreateOrganizationDTO struct {
+	Status       string          `json:"status"`
+	Organization OrganizationDTO `json:"organization"`
+}
+
+type GetOrganizationsDTO struct {
+	Organizations []OrganizationDTO `json:"organizations"`
+	Page          PageDTO           `json:"page"`
+}
+
+type GetOrganizationDTO struct {
+	Organization    OrganizationDTO         `json:"organization"`
+	Members         []OrganizationMemberDTO `json:"members"`
+	GenerationsUsed int64                   `json:"generations_used"`
+}
+
+type EditOrganizationSettingsDTO struct {
+	Status       string          `json:"status"`
+	Organization OrganizationDTO `json:"organization"`
+}
+
+type OrganizationMemberStatusDTO struct {
+	Status string `json:"status"`
+}
+
+type MoveToOrganizationDTO struct {
+	Status string `json:"status"`
+}
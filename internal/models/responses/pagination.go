@@ -0,0 +1,9 @@
+package responses
+
+// PageDTO - метаданные страницы списка. Total заполняется, только если его запросили
+type PageDTO struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+	Total      *int64 `json:"total,omitempty"`
+}
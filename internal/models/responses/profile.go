@@ -0,0 +1,47 @@
+package responses
+
+import "time"
+
+type ProfileDTO struct {
+	ID          uint      `json:"id"`
+	Login       string    `json:"login"`
+	Username    string    `json:"username"`
+	Email       *string   `json:"email"`
+	Role        string    `json:"role"`
+	Language    string    `json:"language"`
+	Style       string    `json:"style"`
+	HasPassword bool      `json:"has_password"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type EditProfileDTO struct {
+	Status  string     `json:"status"`
+	Profile ProfileDTO `json:"profile"`
+}
+
+type DeleteAccountDTO struct {
+	Status string `json:"status"`
+}
+
+type DataExportDTO struct {
+	ID          uint       `json:"id"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	Size        int64      `json:"size"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	// DownloadURL - подписанная ссылка на архив, есть только у готовых выгрузок
+	DownloadURL   string     `json:"download_url,omitempty"`
+	LinkExpiresAt *time.Time `json:"link_expires_at,omitempty"`
+}
+
+type GetDataExportsDTO struct {
+	Exports []DataExportDTO `json:"exports"`
+	Page    PageDTO         `json:"page"`
+}
+
+type RequestDataExportDTO struct {
+	Status string        `json:"status"`
+	Export DataExportDTO `json:"export"`
+}
@@ -0,0 +1,37 @@
+package responses
+
+import (
+	"time"
+
+	"gera-ai/internal/utils/diff"
+)
+
+// RevisionDTO - снимок задачи или шаблона условия
+type RevisionDTO struct {
+	Number       int       `json:"number"`
+	AuthorID     uint      `json:"author_id"`
+	Title        string    `json:"title"`
+	Condition    string    `json:"condition"`
+	Answer       string    `json:"answer,omitempty"`
+	RestoredFrom *int      `json:"restored_from,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type GetRevisionsDTO struct {
+	Revisions []RevisionDTO `json:"revisions"`
+	Page      PageDTO       `json:"page"`
+}
+
+// RevisionDiffDTO - пословный diff полей между двумя ревизиями
+type RevisionDiffDTO struct {
+	From      int       `json:"from"`
+	To        int       `json:"to"`
+	Title     []diff.Op `json:"title"`
+	Condition []diff.Op `json:"condition"`
+	Answer    []diff.Op `json:"answer,omitempty"`
+}
+
+type RestoreRevisionDTO struct {
+	Status   string      `json:"status"`
+	Revision RevisionDTO `json:"revision"`
+}
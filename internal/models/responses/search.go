@@ -0,0 +1,14 @@
+package responses
+
+type SearchResultDTO struct {
+	Type           string  `json:"type"`
+	ID             uint    `json:"id"`
+	Title          string  `json:"title"`
+	Rank           float64 `json:"rank"`
+	TitleHighlight string  `json:"title_highlight"`
+	Snippet        string  `json:"snippet"`
+}
+
+type SearchDTO struct {
+	Results []SearchResultDTO `json:"results"`
+}
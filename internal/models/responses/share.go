@@ -0,0 +1,62 @@
+package responses
+
+import "time"
+
+// ShareDTO - доступ пользователя к задаче или шаблону
+type ShareDTO struct {
+	ResourceType string    `json:"resource_type"`
+	ResourceID   uint      `json:"resource_id"`
+	UserID       uint      `json:"user_id"`
+	Login        string    `json:"login"`
+	Role         string    `json:"role"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ShareLinkDTO - ссылка только для чтения. Токен возвращается только при создании
+type ShareLinkDTO struct {
+	ID        uint       `json:"id"`
+	Token     string     `json:"token,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type GrantShareDTO struct {
+	Status string   `json:"status"`
+	Share  ShareDTO `json:"share"`
+}
+
+type RevokeShareDTO struct {
+	Status string `json:"status"`
+}
+
+// GetSharesDTO - доступы и ссылки листаются независимо: links_page.next_cursor передается как links_cursor
+type GetSharesDTO struct {
+	Shares    []ShareDTO     `json:"shares"`
+	Page      PageDTO        `json:"page"`
+	Links     []ShareLinkDTO `json:"links"`
+	LinksPage PageDTO        `json:"links_page"`
+}
+
+type GetSharedWithMeDTO struct {
+	Shares []ShareDTO `json:"shares"`
+	Page   PageDTO    `json:"page"`
+}
+
+type CreateShareLinkDTO struct {
+	Status string       `json:"status"`
+	Link   ShareLinkDTO `json:"link"`
+}
+
+type DeleteShareLinkDTO struct {
+	Status string `json:"status"`
+}
+
+// SharedResourceDTO - задача или шаблон, открытые по ссылке
+type SharedResourceDTO struct {
+	ResourceType string   `json:"resource_type"`
+	ID           uint     `json:"id"`
+	Title        string   `json:"title"`
+	Condition    string   `json:"condition,omitempty"`
+	Answer       string   `json:"answer,omitempty"`
+	Interests    []string `json:"interests,omitempty"`
+}
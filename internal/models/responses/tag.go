@@ -0,0 +1,34 @@
+package responses
+
+// ClassificationDTO - метки, предметы и классы задачи или шаблона
+type ClassificationDTO struct {
+	Tags     []string `json:"tags"`
+	Subjects []string `json:"subjects"`
+	Grades   []int    `json:"grades"`
+}
+
+type SubjectDTO struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+type GetClassificationDictionaryDTO struct {
+	Subjects []SubjectDTO `json:"subjects"`
+	Grades   []int        `json:"grades"`
+}
+
+type SetClassificationDTO struct {
+	Status         string            `json:"status"`
+	Classification ClassificationDTO `json:"classification"`
+}
+
+type FacetValueDTO struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+type GetTagFacetsDTO struct {
+	Tags     []FacetValueDTO `json:"tags"`
+	Subjects []FacetValueDTO `json:"subjects"`
+	Grades   []FacetValueDTO `json:"grades"`
+}
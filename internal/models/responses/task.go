@@ -2,17 +2,30 @@ package responses
 
 // TaskDTO описывает основную информацию о задаче
 type TaskDTO struct {
-	ID        uint   `json:"id"`
-	AuthorID  uint   `json:"author_id"`
-	Title     string `json:"title"`
-	Condition string `json:"condition"`
-	Answer    string `json:"answer"`
+	ID                 uint   `json:"id"`
+	AuthorID           uint   `json:"author_id"`
+	Title              string `json:"title"`
+	Condition          string `json:"condition"`
+	Answer             string `json:"answer"`
+	SourceGenerationID *uint  `json:"source_generation_id,omitempty"`
+	Attribution        string `json:"attribution,omitempty"`
+	*ClassificationDTO
+	Classification *TaskClassificationDTO `json:"classification,omitempty"`
+}
+
+// TaskClassificationDTO описывает тему, формулы и сложность задачи
+type TaskClassificationDTO struct {
+	Topic      string   `json:"topic"`
+	Formulas   []string `json:"formulas"`
+	Difficulty *int     `json:"difficulty"`
+	Source     string   `json:"source"`
 }
 
 // CreateTaskResponseDTO описывает ответ на создание задачи
 type CreateTaskResponseDTO struct {
-	Status string  `json:"status"`
-	Task   TaskDTO `json:"task"`
+	Status              string  `json:"status"`
+	Task                TaskDTO `json:"task"`
+	ClassificationError string  `json:"classification_error,omitempty"`
 }
 
 // GetTaskResponseDTO описывает ответ на запрос задачи по ID
@@ -35,4 +48,11 @@ type DeleteTaskResponseDTO struct {
 // GetAllTasksResponseDTO описывает ответ на запрос всех задач
 type GetAllTasksResponseDTO struct {
 	Tasks []TaskDTO `json:"tasks"`
+	Page  PageDTO   `json:"page"`
+}
+
+// ClassifyTaskResponseDTO описывает ответ на классификацию задачи
+type ClassifyTaskResponseDTO struct {
+	Status string  `json:"status"`
+	Task   TaskDTO `json:"task"`
 }
@@ -0,0 +1,25 @@
+package responses
+
+import "time"
+
+// TrashItemDTO - удаленная сущность в корзине
+type TrashItemDTO struct {
+	ResourceType string    `json:"resource_type"`
+	ID           uint      `json:"id"`
+	Title        string    `json:"title"`
+	DeletedAt    time.Time `json:"deleted_at"`
+	PurgeAt      time.Time `json:"purge_at"`
+}
+
+type GetTrashDTO struct {
+	Items []TrashItemDTO `json:"items"`
+	Page  PageDTO        `json:"page"`
+}
+
+type RestoreFromTrashDTO struct {
+	Status string `json:"status"`
+}
+
+type PurgeFromTrashDTO struct {
+	Status string `json:"status"`
+}
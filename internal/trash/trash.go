@@ -0,0 +1,158 @@
+package trash
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	dbmodels "gera-ai/internal/models/database"
+
+	"gorm.io/gorm"
+)
+
+// Resource описывает сущность, которая после мягкого удаления попадает в корзину
+type Resource struct {
+	Table string
+	// OwnerColumn - колонка с ID владельца
+	OwnerColumn string
+	// TitleColumn - выражение, которое показывается в списке корзины
+	TitleColumn string
+	Model       func() interface{}
+	// detach убирает ссылки на удаляемые записи, чтобы их можно было удалить окончательно
+	detach func(tx *gorm.DB, ids []uint) error
+}
+
+var Resources = map[string]Resource{
+	"task": {
+		Table: "tasks", OwnerColumn: "author_id", TitleColumn: "title",
+		Model: func() interface{} { return &dbmodels.Task{} },
+		detach: func(tx *gorm.DB, ids []uint) error {
+			return detachAll(tx, ids,
+				"UPDATE generations SET task_id = NULL WHERE task_id IN ?",
+				"DELETE FROM task_tags WHERE task_id IN ?",
+				"DELETE FROM task_subjects WHERE task_id IN ?",
+				"DELETE FROM task_grades WHERE task_id IN ?",
+				"DELETE FROM shares WHERE resource_type = 'task' AND resource_id IN ?",
+				"DELETE FROM share_links WHERE resource_type = 'task' AND resource_id IN ?",
+				"DELETE FROM revisions WHERE resource_type = 'task' AND resource_id IN ?",
+			)
+		},
+	},
+	"condition_template": {
+		Table: "condition_templates", OwnerColumn: "author_id", TitleColumn: "title",
+		Model: func() interface{} { return &dbmodels.ConditionTemplate{} },
+		detach: func(tx *gorm.DB, ids []uint) error {
+			return detachAll(tx, ids,
+				"UPDATE generations SET condition_template_id = NULL WHERE condition_template_id IN ?",
+				"DELETE FROM condition_template_tags WHERE condition_template_id IN ?",
+				"DELETE FROM condition_template_subjects WHERE condition_template_id IN ?",
+				"DELETE FROM condition_template_grades WHERE condition_template_id IN ?",
+				"DELETE FROM shares WHERE resource_type = 'condition_template' AND resource_id IN ?",
+				"DELETE FROM share_links WHERE resource_type = 'condition_template' AND resource_id IN ?",
+				"DELETE FROM revisions WHERE resource_type = 'condition_template' AND resource_id IN ?",
+			)
+		},
+	},
+	"interests_template": {
+		Table: "interests_templates", OwnerColumn: "author_id", TitleColumn: "title",
+		Model: func() interface{} { return &dbmodels.InterestsTemplate{} },
+		detach: func(tx *gorm.DB, ids []uint) error {
+			return detachAll(tx, ids,
+				"UPDATE generations SET interests_template_id = NULL WHERE interests_template_id IN ?",
+				"DELETE FROM interests_template_tags WHERE interests_template_id IN ?",
+				"DELETE FROM interests_template_subjects WHERE interests_template_id IN ?",
+				"DELETE FROM interests_template_grades WHERE interests_template_id IN ?",
+				"DELETE FROM shares WHERE resource_type = 'interests_template' AND resource_id IN ?",
+				"DELETE FROM share_links WHERE resource_type = 'interests_template' AND resource_id IN ?",
+			)
+		},
+	},
+	"history": {
+		Table: "generations", OwnerColumn: "user_id", TitleColumn: "LEFT(result, 100)",
+		Model: func() interface{} { return &dbmodels.Generation{} },
+		detach: func(tx *gorm.DB, ids []uint) error {
+			return detachAll(tx, ids,
+				"UPDATE generations SET parent_generation_id = NULL WHERE parent_generation_id IN ?",
+				"UPDATE tasks SET source_generation_id = NULL WHERE source_generation_id IN ?",
+				"DELETE FROM generation_feedbacks WHERE generation_id IN ?",
+			)
+		},
+	},
+}
+
+// ResourceTypes - порядок, в котором корзина обходит сущности
+var ResourceTypes = []string{"task", "condition_template", "interests_template", "history"}
+
+func detachAll(tx *gorm.DB, ids []uint, statements ...string) error {
+	for _, statement := range statements {
+		if err := tx.Exec(statement, ids).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Purge окончательно удаляет записи, которые уже находятся в корзине
+func Purge(db *gorm.DB, resourceType string, ids []uint) error {
+	resource, ok := Resources[resourceType]
+	if !ok {
+		return fmt.Errorf("unknown resource type %q", resourceType)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := resource.detach(tx, ids); err != nil {
+			return err
+		}
+		return tx.Unscoped().
+			Where("id IN ? AND deleted_at IS NOT NULL", ids).
+			Delete(resource.Model()).Error
+	})
+}
+
+// PurgeExpired удаляет все записи, которые лежат в корзине дольше retention.
+// Возвращает количество удаленных записей
+func PurgeExpired(db *gorm.DB, retention time.Duration) (int, error) {
+	cutoff := time.Now().Add(-retention)
+	purged := 0
+
+	for _, resourceType := range ResourceTypes {
+		resource := Resources[resourceType]
+
+		var ids []uint
+		err := db.Table(resource.Table).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Pluck("id", &ids).Error
+		if err != nil {
+			return purged, err
+		}
+
+		if err := Purge(db, resourceType, ids); err != nil {
+			return purged, fmt.Errorf("failed to purge %s: %w", resourceType, err)
+		}
+		purged += len(ids)
+	}
+
+	return purged, nil
+}
+
+// StartPurger раз в interval удаляет из корзины записи старше retention
+func StartPurger(db *gorm.DB, retention, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for ; true; <-ticker.C {
+			purged, err := PurgeExpired(db, retention)
+			if err != nil {
+				log.Printf("trash purge failed: %v", err)
+				continue
+			}
+			if purged > 0 {
+				log.Printf("trash purge removed %d records", purged)
+			}
+		}
+	}()
+}
@@ -0,0 +1,71 @@
+package diff
+
+import "strings"
+
+const (
+	OpEqual  = "equal"
+	OpInsert = "insert"
+	OpDelete = "delete"
+)
+
+// Op - фрагмент diff: несколько подряд идущих слов с одной операцией
+type Op struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Words строит пословный diff двух текстов по наибольшей общей подпоследовательности.
+// Пробельные символы не сравниваются, слова во фрагменте разделяются одним пробелом
+func Words(old, new string) []Op {
+	a, b := strings.Fields(old), strings.Fields(new)
+
+	// lcs[i][j] - длина общей подпоследовательности a[i:] и b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	ops := []Op{}
+	add := func(opType, word string) {
+		if last := len(ops) - 1; last >= 0 && ops[last].Type == opType {
+			ops[last].Text += " " + word
+			return
+		}
+		ops = append(ops, Op{Type: opType, Text: word})
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			add(OpEqual, a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			add(OpDelete, a[i])
+			i++
+		default:
+			add(OpInsert, b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		add(OpDelete, a[i])
+	}
+	for ; j < len(b); j++ {
+		add(OpInsert, b[j])
+	}
+
+	return ops
+}
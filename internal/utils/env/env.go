@@ -1,6 +1,11 @@
 package env
 
-import "os"
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
 
 func GetEnv(key string, fallback string) string {
 	value := os.Getenv(key)
@@ -9,3 +14,41 @@ func GetEnv(key string, fallback string) string {
 	}
 	return value
 }
+
+// GetEnvInt читает целое число, при отсутствии или ошибке разбора возвращает fallback
+func GetEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// GetEnvDuration читает длительность в формате time.ParseDuration (например 1h30m)
+func GetEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// GetEnvList читает список значений через запятую, пустые элементы отбрасываются
+func GetEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// GetEnvDate читает дату в формате 2006-01-02 (UTC), при отсутствии или ошибке разбора возвращает fallback
+func GetEnvDate(key string, fallback time.Time) time.Time {
+	value, err := time.Parse(time.DateOnly, os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
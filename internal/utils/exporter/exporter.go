@@ -0,0 +1,81 @@
+package exporter
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatMoodle = "moodle"
+	FormatGIFT   = "gift"
+	FormatQTI    = "qti"
+)
+
+// Question описывает одну задачу, которую нужно выгрузить в LMS
+type Question struct {
+	ID     uint
+	Title  string
+	Text   string
+	Answer string
+}
+
+// File содержит готовый файл экспорта
+type File struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+var ErrUnknownFormat = errors.New("unknown export format")
+
+// decimalNumber - десятичная запись числа. strconv.ParseFloat понимает еще inf, nan и
+// шестнадцатеричные числа, которые LMS не примут в числовом вопросе
+var decimalNumber = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)([eE][+-]?\d+)?$`)
+
+// Export выгружает задачи в указанный формат
+func Export(format string, name string, questions []Question) (File, error) {
+	switch format {
+	case FormatMoodle:
+		data, err := ToMoodleXML(questions)
+		if err != nil {
+			return File{}, err
+		}
+		return File{Name: name + ".xml", ContentType: "application/xml", Data: data}, nil
+	case FormatGIFT:
+		return File{Name: name + ".gift.txt", ContentType: "text/plain; charset=utf-8", Data: ToGIFT(questions)}, nil
+	case FormatQTI:
+		data, err := ToQTIPackage(questions)
+		if err != nil {
+			return File{}, err
+		}
+		return File{Name: name + ".qti.zip", ContentType: "application/zip", Data: data}, nil
+	}
+	return File{}, ErrUnknownFormat
+}
+
+// ParseNumeric проверяет, является ли ответ числом, и возвращает его значение.
+// Десятичная запятая допускается, т.к. ответы пишутся по-русски. Числа вне диапазона float64 не считаются числовыми
+func ParseNumeric(answer string) (float64, bool) {
+	value := strings.ReplaceAll(strings.TrimSpace(answer), ",", ".")
+	if !decimalNumber.MatchString(value) {
+		return 0, false
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, false
+	}
+	return number, true
+}
+
+func formatNumber(number float64) string {
+	return strconv.FormatFloat(number, 'f', -1, 64)
+}
+
+func questionName(q Question) string {
+	if strings.TrimSpace(q.Title) != "" {
+		return q.Title
+	}
+	return "task-" + strconv.FormatUint(uint64(q.ID), 10)
+}
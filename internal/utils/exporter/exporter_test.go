@@ -0,0 +1,122 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "перезаписать golden-файлы в testdata")
+
+// goldenQuestions покрывают числовые ответы (в том числе с запятой), текстовые ответы,
+// значения, которые ParseFloat принял бы, но LMS - нет, и управляющие символы GIFT
+var goldenQuestions = []Question{
+	{ID: 1, Title: "Оксид серы", Text: "Сколько граммов SO2 нужно взять?", Answer: "60,8"},
+	{ID: 2, Title: "", Text: "Столица Франции?", Answer: " Париж "},
+	{ID: 3, Title: "Special: ~=#{}", Text: "a = b {c}\nnext line: #1 ~ 2\\3", Answer: "x=1:y"},
+	{ID: 4, Title: "Infinity", Text: "Предел 1/x при x -> 0+", Answer: "inf"},
+	{ID: 5, Title: "Not a number", Text: "0/0", Answer: "NaN"},
+	{ID: 6, Title: "Hex", Text: "0x10", Answer: "0x10"},
+	{ID: 7, Title: "Exponent", Text: "Avogadro", Answer: "6.022e23"},
+}
+
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden file: %v (run go test -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from golden file:\n--- got ---\n%s\n--- want ---\n%s", name, got, want)
+	}
+}
+
+func TestParseNumeric(t *testing.T) {
+	tests := []struct {
+		answer string
+		want   float64
+		ok     bool
+	}{
+		{"42", 42, true},
+		{" -3,5 ", -3.5, true},
+		{"+.5", 0.5, true},
+		{"1.", 1, true},
+		{"6.022e23", 6.022e23, true},
+		{"1E-3", 0.001, true},
+		{"", 0, false},
+		{"abc", 0, false},
+		{"1,2,3", 0, false},
+		{"inf", 0, false},
+		{"-Infinity", 0, false},
+		{"NaN", 0, false},
+		{"0x10", 0, false},
+		{"1_000", 0, false},
+		{"1e400", 0, false},
+		{"12 кг", 0, false},
+	}
+	for _, test := range tests {
+		got, ok := ParseNumeric(test.answer)
+		if ok != test.ok || got != test.want {
+			t.Errorf("ParseNumeric(%q) = %v, %v; want %v, %v", test.answer, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestToGIFT(t *testing.T) {
+	assertGolden(t, "questions.gift.txt", ToGIFT(goldenQuestions))
+}
+
+func TestToMoodleXML(t *testing.T) {
+	data, err := ToMoodleXML(goldenQuestions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "questions.moodle.xml", data)
+}
+
+func TestToQTIPackage(t *testing.T) {
+	data, err := ToQTIPackage(goldenQuestions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string][]byte{}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[file.Name] = body
+	}
+	if len(files) != len(goldenQuestions)+1 {
+		t.Fatalf("package has %d files, want %d", len(files), len(goldenQuestions)+1)
+	}
+
+	assertGolden(t, "qti/imsmanifest.xml", files["imsmanifest.xml"])
+	for _, name := range []string{"items/ITEM-1-1.xml", "items/ITEM-2-2.xml", "items/ITEM-4-4.xml"} {
+		body, found := files[name]
+		if !found {
+			t.Fatalf("package has no %s", name)
+		}
+		assertGolden(t, filepath.Join("qti", filepath.Base(name)), body)
+	}
+}
@@ -0,0 +1,44 @@
+package exporter
+
+import (
+	"strings"
+)
+
+// giftEscaper экранирует управляющие символы формата GIFT
+var giftEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`~`, `\~`,
+	`=`, `\=`,
+	`#`, `\#`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`:`, `\:`,
+	"\n", `\n`,
+)
+
+// ToGIFT выгружает задачи в формат Moodle GIFT.
+// Числовые ответы записываются как {#число}, остальные - как {=ответ}
+func ToGIFT(questions []Question) []byte {
+	var builder strings.Builder
+	for i, q := range questions {
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+
+		builder.WriteString("::")
+		builder.WriteString(giftEscaper.Replace(questionName(q)))
+		builder.WriteString("::[plain]")
+		builder.WriteString(giftEscaper.Replace(q.Text))
+
+		if number, ok := ParseNumeric(q.Answer); ok {
+			builder.WriteString(" {#")
+			builder.WriteString(formatNumber(number))
+			builder.WriteString("}\n")
+		} else {
+			builder.WriteString(" {=")
+			builder.WriteString(giftEscaper.Replace(strings.TrimSpace(q.Answer)))
+			builder.WriteString("}\n")
+		}
+	}
+	return []byte(builder.String())
+}
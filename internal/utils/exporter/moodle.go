@@ -0,0 +1,76 @@
+package exporter
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+type moodleQuiz struct {
+	XMLName   xml.Name         `xml:"quiz"`
+	Questions []moodleQuestion `xml:"question"`
+}
+
+type moodleText struct {
+	Text string `xml:"text"`
+}
+
+type moodleQuestionText struct {
+	Format string `xml:"format,attr"`
+	Text   string `xml:"text"`
+}
+
+type moodleAnswer struct {
+	Fraction  int     `xml:"fraction,attr"`
+	Format    string  `xml:"format,attr,omitempty"`
+	Text      string  `xml:"text"`
+	Tolerance *string `xml:"tolerance,omitempty"`
+}
+
+type moodleQuestion struct {
+	Type         string             `xml:"type,attr"`
+	Name         moodleText         `xml:"name"`
+	QuestionText moodleQuestionText `xml:"questiontext"`
+	DefaultGrade int                `xml:"defaultgrade"`
+	UseCase      *int               `xml:"usecase,omitempty"`
+	Answers      []moodleAnswer     `xml:"answer"`
+}
+
+// ToMoodleXML выгружает задачи в формат Moodle XML.
+// Числовые ответы становятся вопросами типа numerical, остальные - shortanswer
+func ToMoodleXML(questions []Question) ([]byte, error) {
+	quiz := moodleQuiz{}
+	for _, q := range questions {
+		question := moodleQuestion{
+			Name:         moodleText{Text: questionName(q)},
+			QuestionText: moodleQuestionText{Format: "plain_text", Text: q.Text},
+			DefaultGrade: 1,
+		}
+
+		if number, ok := ParseNumeric(q.Answer); ok {
+			tolerance := "0"
+			question.Type = "numerical"
+			question.Answers = []moodleAnswer{{
+				Fraction:  100,
+				Text:      formatNumber(number),
+				Tolerance: &tolerance,
+			}}
+		} else {
+			caseInsensitive := 0
+			question.Type = "shortanswer"
+			question.UseCase = &caseInsensitive
+			question.Answers = []moodleAnswer{{
+				Fraction: 100,
+				Format:   "plain_text",
+				Text:     strings.TrimSpace(q.Answer),
+			}}
+		}
+
+		quiz.Questions = append(quiz.Questions, question)
+	}
+
+	body, err := xml.MarshalIndent(quiz, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
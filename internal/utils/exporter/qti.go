@@ -0,0 +1,178 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+const (
+	qtiNamespace      = "http://www.imsglobal.org/xsd/imsqti_v2p1"
+	qtiSchemaLocation = "http://www.imsglobal.org/xsd/imsqti_v2p1 http://www.imsglobal.org/xsd/qti/qtiv2p1/imsqti_v2p1.xsd"
+	cpNamespace       = "http://www.imsglobal.org/xsd/imscp_v1p1"
+	matchCorrectURI   = "http://www.imsglobal.org/question/qti_v2p1/rptemplates/match_correct"
+)
+
+type qtiValue struct {
+	Value string `xml:"value"`
+}
+
+type qtiResponseDeclaration struct {
+	Identifier      string   `xml:"identifier,attr"`
+	Cardinality     string   `xml:"cardinality,attr"`
+	BaseType        string   `xml:"baseType,attr"`
+	CorrectResponse qtiValue `xml:"correctResponse"`
+}
+
+type qtiOutcomeDeclaration struct {
+	Identifier  string `xml:"identifier,attr"`
+	Cardinality string `xml:"cardinality,attr"`
+	BaseType    string `xml:"baseType,attr"`
+}
+
+type qtiTextEntry struct {
+	ResponseIdentifier string `xml:"responseIdentifier,attr"`
+	ExpectedLength     int    `xml:"expectedLength,attr"`
+}
+
+type qtiParagraph struct {
+	Text        string        `xml:",chardata"`
+	Interaction *qtiTextEntry `xml:"textEntryInteraction,omitempty"`
+}
+
+type qtiItemBody struct {
+	Paragraphs []qtiParagraph `xml:"p"`
+}
+
+type qtiResponseProcessing struct {
+	Template string `xml:"template,attr"`
+}
+
+type qtiAssessmentItem struct {
+	XMLName             xml.Name               `xml:"assessmentItem"`
+	Namespace           string                 `xml:"xmlns,attr"`
+	XSI                 string                 `xml:"xmlns:xsi,attr"`
+	SchemaLocation      string                 `xml:"xsi:schemaLocation,attr"`
+	Identifier          string                 `xml:"identifier,attr"`
+	Title               string                 `xml:"title,attr"`
+	Adaptive            bool                   `xml:"adaptive,attr"`
+	TimeDependent       bool                   `xml:"timeDependent,attr"`
+	ResponseDeclaration qtiResponseDeclaration `xml:"responseDeclaration"`
+	OutcomeDeclaration  qtiOutcomeDeclaration  `xml:"outcomeDeclaration"`
+	ItemBody            qtiItemBody            `xml:"itemBody"`
+	ResponseProcessing  qtiResponseProcessing  `xml:"responseProcessing"`
+}
+
+type cpFile struct {
+	Href string `xml:"href,attr"`
+}
+
+type cpResource struct {
+	Identifier string `xml:"identifier,attr"`
+	Type       string `xml:"type,attr"`
+	Href       string `xml:"href,attr"`
+	File       cpFile `xml:"file"`
+}
+
+type cpManifest struct {
+	XMLName       xml.Name     `xml:"manifest"`
+	Namespace     string       `xml:"xmlns,attr"`
+	Identifier    string       `xml:"identifier,attr"`
+	Organizations struct{}     `xml:"organizations"`
+	Resources     []cpResource `xml:"resources>resource"`
+}
+
+// ToQTIItem выгружает одну задачу в assessmentItem формата IMS QTI 2.1
+func ToQTIItem(identifier string, q Question) ([]byte, error) {
+	declaration := qtiResponseDeclaration{
+		Identifier:  "RESPONSE",
+		Cardinality: "single",
+	}
+	if number, ok := ParseNumeric(q.Answer); ok {
+		declaration.BaseType = "float"
+		declaration.CorrectResponse = qtiValue{Value: formatNumber(number)}
+	} else {
+		declaration.BaseType = "string"
+		declaration.CorrectResponse = qtiValue{Value: strings.TrimSpace(q.Answer)}
+	}
+
+	item := qtiAssessmentItem{
+		Namespace:           qtiNamespace,
+		XSI:                 "http://www.w3.org/2001/XMLSchema-instance",
+		SchemaLocation:      qtiSchemaLocation,
+		Identifier:          identifier,
+		Title:               questionName(q),
+		ResponseDeclaration: declaration,
+		OutcomeDeclaration: qtiOutcomeDeclaration{
+			Identifier:  "SCORE",
+			Cardinality: "single",
+			BaseType:    "float",
+		},
+		ItemBody: qtiItemBody{Paragraphs: []qtiParagraph{
+			{Text: q.Text},
+			{Interaction: &qtiTextEntry{ResponseIdentifier: "RESPONSE", ExpectedLength: 20}},
+		}},
+		ResponseProcessing: qtiResponseProcessing{Template: matchCorrectURI},
+	}
+
+	body, err := xml.MarshalIndent(item, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// ToQTIPackage собирает задачи в content package (zip с imsmanifest.xml), который импортируют LMS
+func ToQTIPackage(questions []Question) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	archive := zip.NewWriter(buffer)
+
+	manifest := cpManifest{
+		Namespace:  cpNamespace,
+		Identifier: "MANIFEST-GERA-AI",
+	}
+
+	for i, q := range questions {
+		identifier := fmt.Sprintf("ITEM-%d-%d", i+1, q.ID)
+		href := fmt.Sprintf("items/%s.xml", identifier)
+
+		item, err := ToQTIItem(identifier, q)
+		if err != nil {
+			return nil, err
+		}
+
+		writer, err := archive.Create(href)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := writer.Write(item); err != nil {
+			return nil, err
+		}
+
+		manifest.Resources = append(manifest.Resources, cpResource{
+			Identifier: identifier,
+			Type:       "imsqti_item_xmlv2p1",
+			Href:       href,
+			File:       cpFile{Href: href},
+		})
+	}
+
+	manifestBody, err := xml.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	writer, err := archive.Create("imsmanifest.xml")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(append([]byte(xml.Header), manifestBody...)); err != nil {
+		return nil, err
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
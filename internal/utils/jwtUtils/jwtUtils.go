@@ -2,9 +2,11 @@ package jwtUtils
 
 import (
 	"errors"
+	dbmodels "gera-ai/internal/models/database"
 	"gera-ai/internal/utils/parser"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
+	"strconv"
 )
 
 // ExtractUserID извлекает ID пользователя из JWT токена, если он валиден
@@ -35,3 +37,107 @@ func ExtractUserID(c *fiber.Ctx) (uint, error) {
 
 	return authorID, nil
 }
+
+const (
+	organizationIDKey   = "organization_id"
+	organizationRoleKey = "organization_role"
+	roleKey             = "role"
+)
+
+// SetOrganization сохраняет активную организацию пользователя в контексте запроса
+func SetOrganization(c *fiber.Ctx, organizationID uint, role string) {
+	c.Locals(organizationIDKey, organizationID)
+	c.Locals(organizationRoleKey, role)
+}
+
+// ExtractOrganization возвращает активную организацию и роль пользователя в ней.
+// ok == false, если запрос выполняется в личном пространстве
+func ExtractOrganization(c *fiber.Ctx) (organizationID uint, role string, ok bool) {
+	organizationID, ok = c.Locals(organizationIDKey).(uint)
+	if !ok {
+		return 0, "", false
+	}
+	role, _ = c.Locals(organizationRoleKey).(string)
+	return organizationID, role, true
+}
+
+// mapClaims возвращает клеймы проверенного JWT токена из контекста
+func mapClaims(c *fiber.Ctx) (jwt.MapClaims, error) {
+	jwtToken, ok := c.Locals("user").(*jwt.Token)
+	if !ok {
+		return nil, errors.New("invalid token format")
+	}
+
+	claims, ok := jwtToken.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid token claims")
+	}
+	return claims, nil
+}
+
+// SetRole сохраняет в контексте запроса текущую роль пользователя из базы данных
+func SetRole(c *fiber.Ctx, role string) {
+	c.Locals(roleKey, role)
+}
+
+// ExtractRole возвращает роль пользователя. Роль, загруженная из базы при авторизации,
+// важнее роли из JWT: понижение или блокировка действуют сразу, а не после истечения токена.
+// Токены, выпущенные до появления ролей, считаются токенами учителя
+func ExtractRole(c *fiber.Ctx) (string, error) {
+	if role, ok := c.Locals(roleKey).(string); ok && role != "" {
+		return role, nil
+	}
+
+	claims, err := mapClaims(c)
+	if err != nil {
+		return "", err
+	}
+
+	role, ok := claims["role"].(string)
+	if !ok || role == "" {
+		return dbmodels.UserRoleTeacher, nil
+	}
+	return role, nil
+}
+
+// ExtractSessionID извлекает ID сессии, для которой выпущен access токен
+func ExtractSessionID(c *fiber.Ctx) (uint, error) {
+	claims, err := mapClaims(c)
+	if err != nil {
+		return 0, err
+	}
+
+	sessionID, ok := claims["sid"].(string)
+	if !ok {
+		return 0, errors.New("missing or invalid session ID in token")
+	}
+	return parser.StringToUint(sessionID)
+}
+
+// SetAccessToken кладет в контекст клеймы пользователя, вошедшего по персональному токену,
+// чтобы обработчики читали их так же, как клеймы JWT. Сессии у такого запроса нет
+func SetAccessToken(c *fiber.Ctx, userID uint, role string, tokenID uint, scopes string) {
+	c.Locals("user", &jwt.Token{
+		Valid: true,
+		Claims: jwt.MapClaims{
+			"id":    strconv.FormatUint(uint64(userID), 10),
+			"role":  role,
+			"tid":   strconv.FormatUint(uint64(tokenID), 10),
+			"scope": scopes,
+		},
+	})
+}
+
+// ExtractScopes возвращает области доступа персонального токена через пробел.
+// ok = false, если запрос пришел после входа по паролю: такому входу разрешено все
+func ExtractScopes(c *fiber.Ctx) (scopes string, ok bool) {
+	claims, err := mapClaims(c)
+	if err != nil {
+		return "", false
+	}
+	if _, ok := claims["tid"]; !ok {
+		return "", false
+	}
+	scopes, _ = claims["scope"].(string)
+	return scopes, true
+}
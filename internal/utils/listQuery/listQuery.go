@@ -0,0 +1,200 @@
+package listQuery
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Kind - тип поля, от него зависят доступные фильтры
+type Kind int
+
+const (
+	// Text - строковое поле, фильтр <поле>_contains
+	Text Kind = iota
+	// Time - поле времени, фильтры <поле>_after и <поле>_before в формате RFC3339
+	Time
+	// Number - целое неотрицательное поле: счетчик или ID. Фильтруется только по точному значению
+	Number
+)
+
+// maxContainsLength - максимальная длина строки в фильтрах _contains и _prefix
+const maxContainsLength = 200
+
+// Field описывает поле, открытое клиенту. Column подставляется в SQL как есть,
+// поэтому задается только в Spec и никогда не берется из запроса
+type Field struct {
+	Column string
+	Kind   Kind
+}
+
+// Spec - белый список полей списка
+type Spec struct {
+	// Sort - поля для параметра sort, ключ - имя поля
+	Sort map[string]Field
+	// Filters - поля для фильтров, ключ - имя фильтра без суффикса: created для created_after
+	Filters map[string]Field
+	// Equal - поля для фильтров по точному значению, ключ - имя параметра: role для role=admin
+	Equal map[string]Field
+}
+
+// Sort - выбранная сортировка. Пустое Name означает сортировку списка по умолчанию
+type Sort struct {
+	Name   string
+	Column string
+	Kind   Kind
+	Desc   bool
+}
+
+// Key возвращает сортировку в том виде, в котором ее передают в параметре sort
+func (s Sort) Key() string {
+	if s.Desc {
+		return "-" + s.Name
+	}
+	return s.Name
+}
+
+type operator struct {
+	kind Kind
+	sql  string
+}
+
+// operators - суффиксы параметров-фильтров и соответствующие им условия
+var operators = map[string]operator{
+	"_after":    {kind: Time, sql: "%s > ?"},
+	"_before":   {kind: Time, sql: "%s < ?"},
+	"_contains": {kind: Text, sql: "%s ILIKE ?"},
+	"_prefix":   {kind: Text, sql: "%s ILIKE ?"},
+}
+
+type condition struct {
+	sql   string
+	value interface{}
+}
+
+// Query - разобранные параметры сортировки и фильтров списка
+type Query struct {
+	Sort       Sort
+	conditions []condition
+}
+
+// likeEscaper экранирует спецсимволы LIKE, чтобы _contains искал строку буквально
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// EscapeLike экранирует %, _ и \ в s, чтобы ее можно было искать в LIKE буквально
+func EscapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// Parse разбирает параметр sort, фильтры вида <поле>_after, <поле>_before, <поле>_contains
+// и <поле>_prefix и фильтры по точному значению из spec.Equal.
+// Поля, которых нет в spec, и фильтры не того типа отклоняются с ошибкой.
+// sort=title сортирует по возрастанию, sort=-title - по убыванию
+func Parse(params map[string]string, spec Spec) (Query, error) {
+	query := Query{}
+
+	if value := params["sort"]; value != "" {
+		name := strings.TrimPrefix(value, "-")
+		field, ok := spec.Sort[name]
+		if !ok {
+			return query, fmt.Errorf("sorting by %q is not supported", name)
+		}
+		query.Sort = Sort{
+			Name:   name,
+			Column: field.Column,
+			Kind:   field.Kind,
+			Desc:   strings.HasPrefix(value, "-"),
+		}
+	}
+
+	// Параметры перебираются по порядку, чтобы запрос не зависел от порядка обхода map
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if field, ok := spec.Equal[key]; ok {
+			value := params[key]
+			if value == "" {
+				continue
+			}
+			arg, err := parseValue(key, value, field.Kind)
+			if err != nil {
+				return query, err
+			}
+			query.conditions = append(query.conditions, condition{
+				sql:   field.Column + " = ?",
+				value: arg,
+			})
+			continue
+		}
+
+		for suffix, op := range operators {
+			name, found := strings.CutSuffix(key, suffix)
+			if !found {
+				continue
+			}
+			field, ok := spec.Filters[name]
+			if !ok || field.Kind != op.kind {
+				return query, fmt.Errorf("filter %q is not supported", key)
+			}
+
+			value := params[key]
+			if value == "" {
+				continue
+			}
+			arg, err := parseValue(key, value, op.kind)
+			if err != nil {
+				return query, err
+			}
+			if op.kind == Text {
+				arg = EscapeLike(arg.(string)) + "%"
+				if suffix == "_contains" {
+					arg = "%" + arg.(string)
+				}
+			}
+			query.conditions = append(query.conditions, condition{
+				sql:   fmt.Sprintf(op.sql, field.Column),
+				value: arg,
+			})
+		}
+	}
+	return query, nil
+}
+
+// parseValue приводит значение параметра key к типу поля
+func parseValue(key, value string, kind Kind) (interface{}, error) {
+	switch kind {
+	case Time:
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", key, err)
+		}
+		return parsed, nil
+	case Number:
+		parsed, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: must be a non-negative integer", key, value)
+		}
+		return parsed, nil
+	}
+	if len([]rune(value)) > maxContainsLength {
+		return nil, fmt.Errorf("%s is longer than %d characters", key, maxContainsLength)
+	}
+	return value, nil
+}
+
+// Apply добавляет фильтры к запросу. Сортировку применяет вызывающий код,
+// потому что от нее зависит курсор страницы
+func (query Query) Apply(db *gorm.DB) *gorm.DB {
+	for _, condition := range query.conditions {
+		db = db.Where(condition.sql, condition.value)
+	}
+	return db
+}
@@ -0,0 +1,104 @@
+package listQuery
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSpec = Spec{
+	Sort: map[string]Field{
+		"title":       {Column: "title", Kind: Text},
+		"created_at":  {Column: "created_at", Kind: Time},
+		"clone_count": {Column: "clone_count", Kind: Number},
+	},
+	Filters: map[string]Field{
+		"title":   {Column: "title", Kind: Text},
+		"created": {Column: "created_at", Kind: Time},
+	},
+	Equal: map[string]Field{
+		"role":    {Column: "role", Kind: Text},
+		"user_id": {Column: "user_id", Kind: Number},
+	},
+}
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		value string
+		want  Sort
+	}{
+		{"", Sort{}},
+		{"title", Sort{Name: "title", Column: "title", Kind: Text}},
+		{"-created_at", Sort{Name: "created_at", Column: "created_at", Kind: Time, Desc: true}},
+		{"-clone_count", Sort{Name: "clone_count", Column: "clone_count", Kind: Number, Desc: true}},
+	}
+	for _, tt := range tests {
+		query, err := Parse(map[string]string{"sort": tt.value}, testSpec)
+		if err != nil {
+			t.Fatalf("sort=%s: %v", tt.value, err)
+		}
+		if query.Sort != tt.want {
+			t.Errorf("sort=%s: got %+v, want %+v", tt.value, query.Sort, tt.want)
+		}
+		if query.Sort.Key() != tt.value {
+			t.Errorf("sort=%s: key %q", tt.value, query.Sort.Key())
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  map[string]string
+		wantErr string
+	}{
+		{"unknown sort field", map[string]string{"sort": "password_hash"}, `sorting by "password_hash" is not supported`},
+		{"unknown descending sort field", map[string]string{"sort": "-role"}, `sorting by "role" is not supported`},
+		{"unknown filter", map[string]string{"password_hash_contains": "a"}, `filter "password_hash_contains" is not supported`},
+		{"filter of another kind", map[string]string{"title_after": "2024-03-01T00:00:00Z"}, `filter "title_after" is not supported`},
+		{"text filter on time field", map[string]string{"created_contains": "2024"}, `filter "created_contains" is not supported`},
+		{"bad date", map[string]string{"created_after": "2024-03-01"}, "invalid created_after"},
+		{"date in another format", map[string]string{"created_before": "01.03.2024"}, "invalid created_before"},
+		{"bad number", map[string]string{"user_id": "5 OR 1=1"}, "invalid user_id"},
+		{"negative number", map[string]string{"user_id": "-1"}, "invalid user_id"},
+		{"too long text", map[string]string{"title_contains": strings.Repeat("я", maxContainsLength+1)}, "title_contains is longer than 200 characters"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.params, testSpec)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Parse error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseConditions(t *testing.T) {
+	params := map[string]string{
+		"title_contains": `50%_off\`,
+		"title_prefix":   "Дро",
+		"created_after":  "2024-03-01T00:00:00Z",
+		"role":           "admin",
+		"user_id":        "5",
+		// Пустые фильтры и параметры вне списка не мешают
+		"created_before": "",
+		"cursor":         "abc",
+		"limit":          "10",
+	}
+	query, err := Parse(params, testSpec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []condition{
+		{sql: "created_at > ?", value: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{sql: "role = ?", value: "admin"},
+		{sql: "title ILIKE ?", value: `%50\%\_off\\%`},
+		{sql: "title ILIKE ?", value: "Дро%"},
+		{sql: "user_id = ?", value: uint64(5)},
+	}
+	if !reflect.DeepEqual(query.conditions, want) {
+		t.Fatalf("conditions = %+v, want %+v", query.conditions, want)
+	}
+}
@@ -0,0 +1,111 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Message - письмо в виде обычного текста
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer отправляет письма пользователям
+type Mailer interface {
+	Send(message Message) error
+}
+
+// Config - настройки отправки писем. Backend: smtp, file или log
+type Config struct {
+	Backend      string
+	From         string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	Dir          string
+}
+
+// New создает отправителя по настройкам
+func New(config Config) (Mailer, error) {
+	switch config.Backend {
+	case "smtp":
+		if config.SMTPHost == "" {
+			return nil, fmt.Errorf("smtp host is not configured")
+		}
+		return &SMTPMailer{config: config}, nil
+	case "file":
+		if err := os.MkdirAll(config.Dir, 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create mail directory: %w", err)
+		}
+		return &FileMailer{from: config.From, dir: config.Dir}, nil
+	case "log", "":
+		return &LogMailer{}, nil
+	default:
+		return nil, fmt.Errorf("unknown mail backend %q", config.Backend)
+	}
+}
+
+// format собирает письмо в формате RFC 5322
+func format(from string, message Message) []byte {
+	var builder strings.Builder
+	builder.WriteString("From: " + from + "\r\n")
+	builder.WriteString("To: " + message.To + "\r\n")
+	// заголовки должны быть в ASCII, русская тема кодируется по RFC 2047
+	builder.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", message.Subject) + "\r\n")
+	builder.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	builder.WriteString("MIME-Version: 1.0\r\n")
+	builder.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	builder.WriteString("\r\n")
+	builder.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+	return []byte(builder.String())
+}
+
+// SMTPMailer отправляет письма через SMTP сервер. Без логина работает с локальными
+// перехватчиками писем вроде MailHog
+type SMTPMailer struct {
+	config Config
+}
+
+func (m *SMTPMailer) Send(message Message) error {
+	address := fmt.Sprintf("%s:%d", m.config.SMTPHost, m.config.SMTPPort)
+
+	var auth smtp.Auth
+	if m.config.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", m.config.SMTPUsername, m.config.SMTPPassword, m.config.SMTPHost)
+	}
+	if err := smtp.SendMail(address, auth, m.config.From, []string{message.To}, format(m.config.From, message)); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	return nil
+}
+
+// FileMailer сохраняет письма в .eml файлы, удобно для разработки
+type FileMailer struct {
+	from string
+	dir  string
+}
+
+func (m *FileMailer) Send(message Message) error {
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), strings.NewReplacer("@", "_at_", "/", "_").Replace(message.To))
+	if err := os.WriteFile(filepath.Join(m.dir, name), format(m.from, message), 0o640); err != nil {
+		return fmt.Errorf("failed to write mail: %w", err)
+	}
+	return nil
+}
+
+// LogMailer пишет письма в лог вместо отправки
+type LogMailer struct{}
+
+func (m *LogMailer) Send(message Message) error {
+	log.Printf("mail to %s: %s\n%s", message.To, message.Subject, message.Body)
+	return nil
+}
@@ -0,0 +1,368 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MicahParks/keyfunc/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Config - настройки провайдера. Адреса можно не указывать, если задан Issuer:
+// тогда они берутся из /.well-known/openid-configuration.
+// С Issuer провайдер считается OpenID Connect провайдером и обязан вернуть ID токен
+type Config struct {
+	Name         string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Issuer       string
+	AuthURL      string
+	TokenURL     string
+	UserInfoURL  string
+	JWKSURL      string
+	Scopes       []string
+}
+
+// Yandex - адреса Яндекс ID. Яндекс не публикует discovery документ,
+// а профиль отдает в своем формате, который разбирает UserInfo
+var Yandex = Config{
+	AuthURL:     "https://oauth.yandex.ru/authorize",
+	TokenURL:    "https://oauth.yandex.ru/token",
+	UserInfoURL: "https://login.yandex.ru/info?format=json",
+	Scopes:      []string{"login:info", "login:email"},
+}
+
+// Tokens - токены, которые провайдер выдал в обмен на код авторизации
+type Tokens struct {
+	AccessToken string
+	IDToken     string
+}
+
+// UserInfo - профиль пользователя у провайдера
+type UserInfo struct {
+	Subject string
+	Email   string
+	Login   string
+	Name    string
+}
+
+// Provider выполняет authorization code flow с PKCE для одного провайдера
+type Provider struct {
+	config Config
+	client *http.Client
+
+	// адреса из discovery загружаются при первом использовании,
+	// чтобы приложение запускалось, даже если провайдер недоступен
+	mu         sync.Mutex
+	discovered bool
+	keys       *keyfunc.JWKS
+}
+
+// NewProvider создает провайдера. Для имени yandex недостающие адреса и scopes берутся из Yandex
+func NewProvider(config Config) *Provider {
+	if config.Name == "yandex" {
+		if config.AuthURL == "" {
+			config.AuthURL = Yandex.AuthURL
+		}
+		if config.TokenURL == "" {
+			config.TokenURL = Yandex.TokenURL
+		}
+		if config.UserInfoURL == "" {
+			config.UserInfoURL = Yandex.UserInfoURL
+		}
+		if len(config.Scopes) == 0 {
+			config.Scopes = Yandex.Scopes
+		}
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "profile", "email"}
+	}
+
+	return &Provider{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name возвращает имя провайдера из конфигурации
+func (p *Provider) Name() string {
+	return p.config.Name
+}
+
+// discover загружает адреса провайдера из discovery документа, если они не заданы явно
+func (p *Provider) discover() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovered || p.config.Issuer == "" {
+		return nil
+	}
+
+	var document struct {
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		UserInfoEndpoint      string `json:"userinfo_endpoint"`
+		JWKSURI               string `json:"jwks_uri"`
+	}
+	discoveryURL := strings.TrimSuffix(p.config.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(discoveryURL, "", &document); err != nil {
+		return fmt.Errorf("failed to discover %s: %w", p.config.Name, err)
+	}
+
+	if p.config.AuthURL == "" {
+		p.config.AuthURL = document.AuthorizationEndpoint
+	}
+	if p.config.TokenURL == "" {
+		p.config.TokenURL = document.TokenEndpoint
+	}
+	if p.config.UserInfoURL == "" {
+		p.config.UserInfoURL = document.UserInfoEndpoint
+	}
+	if p.config.JWKSURL == "" {
+		p.config.JWKSURL = document.JWKSURI
+	}
+	// Издатель в документе должен совпадать с настроенным, в ID токене он записан так же, как в документе
+	if document.Issuer != "" {
+		if strings.TrimSuffix(document.Issuer, "/") != strings.TrimSuffix(p.config.Issuer, "/") {
+			return fmt.Errorf("failed to discover %s: issuer %s does not match", p.config.Name, document.Issuer)
+		}
+		p.config.Issuer = document.Issuer
+	}
+	p.discovered = true
+	return nil
+}
+
+// CodeChallenge возвращает S256 challenge для PKCE verifier
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// OpenID сообщает, что провайдер выдает ID токен, который нужно проверить при входе
+func (p *Provider) OpenID() bool {
+	return p.config.Issuer != ""
+}
+
+// AuthCodeURL возвращает адрес страницы входа провайдера. nonce передается только
+// OpenID Connect провайдерам и возвращается ими в ID токене
+func (p *Provider) AuthCodeURL(state, verifier, nonce string) (string, error) {
+	if err := p.discover(); err != nil {
+		return "", err
+	}
+	if p.config.AuthURL == "" {
+		return "", fmt.Errorf("authorization endpoint of %s is not configured", p.config.Name)
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {strings.Join(p.config.Scopes, " ")},
+		"state":                 {state},
+		"code_challenge":        {CodeChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+	if p.OpenID() {
+		query.Set("nonce", nonce)
+	}
+
+	separator := "?"
+	if strings.Contains(p.config.AuthURL, "?") {
+		separator = "&"
+	}
+	return p.config.AuthURL + separator + query.Encode(), nil
+}
+
+// Exchange меняет код авторизации на токены провайдера
+func (p *Provider) Exchange(code, verifier string) (Tokens, error) {
+	if err := p.discover(); err != nil {
+		return Tokens{}, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"client_id":     {p.config.ClientID},
+		"client_secret": {p.config.ClientSecret},
+		"code_verifier": {verifier},
+	}
+	response, err := p.client.PostForm(p.config.TokenURL, form)
+	if err != nil {
+		return Tokens{}, fmt.Errorf("failed to exchange code: %w", err)
+	}
+	defer response.Body.Close()
+
+	var body struct {
+		AccessToken      string `json:"access_token"`
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := decodeJSON(response, &body); err != nil {
+		return Tokens{}, fmt.Errorf("failed to exchange code: %w", err)
+	}
+	if body.Error != "" {
+		return Tokens{}, fmt.Errorf("provider rejected code: %s %s", body.Error, body.ErrorDescription)
+	}
+	if body.AccessToken == "" {
+		return Tokens{}, errors.New("provider returned no access token")
+	}
+	return Tokens{AccessToken: body.AccessToken, IDToken: body.IDToken}, nil
+}
+
+// signingKeys загружает ключи провайдера при первой проверке ID токена.
+// Ключ с незнакомым kid загружается заново, поэтому смена ключей у провайдера не ломает вход
+func (p *Provider) signingKeys() (*keyfunc.JWKS, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.keys != nil {
+		return p.keys, nil
+	}
+	if p.config.JWKSURL == "" {
+		return nil, fmt.Errorf("signing keys of %s are not configured", p.config.Name)
+	}
+	keys, err := keyfunc.Get(p.config.JWKSURL, keyfunc.Options{
+		Client:            p.client,
+		RefreshUnknownKID: true,
+		RefreshRateLimit:  time.Minute,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load signing keys of %s: %w", p.config.Name, err)
+	}
+	p.keys = keys
+	return keys, nil
+}
+
+// VerifyIDToken проверяет подпись ID токена, издателя, получателя, срок действия и nonce,
+// выданный при начале входа. Возвращает subject пользователя
+func (p *Provider) VerifyIDToken(idToken, nonce string) (string, error) {
+	if err := p.discover(); err != nil {
+		return "", err
+	}
+	if idToken == "" {
+		return "", errors.New("provider returned no ID token")
+	}
+	keys, err := p.signingKeys()
+	if err != nil {
+		return "", err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(idToken, claims, keys.Keyfunc,
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "PS256"}),
+		jwt.WithIssuer(p.config.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return "", fmt.Errorf("invalid ID token: %w", err)
+	}
+	if claimNonce, _ := claims["nonce"].(string); nonce == "" || claimNonce != nonce {
+		return "", errors.New("invalid ID token: nonce does not match")
+	}
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return "", errors.New("invalid ID token: no subject")
+	}
+	return subject, nil
+}
+
+// UserInfo загружает профиль пользователя. Понимает стандартные claims OpenID Connect
+// и поля Яндекс ID (id, login, default_email, real_name)
+func (p *Provider) UserInfo(accessToken string) (UserInfo, error) {
+	if err := p.discover(); err != nil {
+		return UserInfo{}, err
+	}
+
+	var claims map[string]interface{}
+	if err := p.getJSON(p.config.UserInfoURL, accessToken, &claims); err != nil {
+		return UserInfo{}, fmt.Errorf("failed to load user info: %w", err)
+	}
+
+	info := UserInfo{
+		Subject: firstClaim(claims, "sub", "id"),
+		Email:   firstClaim(claims, "email", "default_email"),
+		Login:   firstClaim(claims, "preferred_username", "login"),
+		Name:    firstClaim(claims, "name", "real_name", "display_name"),
+	}
+	if info.Subject == "" {
+		return UserInfo{}, errors.New("user info has no subject")
+	}
+	return info, nil
+}
+
+func (p *Provider) getJSON(target, accessToken string, value interface{}) error {
+	request, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	if accessToken != "" {
+		// Яндекс принимает только схему OAuth, стандартные провайдеры - Bearer
+		scheme := "Bearer"
+		if p.config.Name == "yandex" {
+			scheme = "OAuth"
+		}
+		request.Header.Set("Authorization", scheme+" "+accessToken)
+	}
+	request.Header.Set("Accept", "application/json")
+
+	response, err := p.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	return decodeJSON(response, value)
+}
+
+func decodeJSON(response *http.Response, value interface{}) error {
+	body, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	// Ошибки token endpoint приходят с кодом 400 и JSON телом, их разбирает вызывающий
+	if response.StatusCode >= 300 && response.StatusCode != http.StatusBadRequest {
+		return fmt.Errorf("unexpected status %d: %s", response.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, value)
+}
+
+// firstClaim возвращает первый непустой claim из перечисленных. Числовые ID приводятся к строке
+func firstClaim(claims map[string]interface{}, names ...string) string {
+	for _, name := range names {
+		switch value := claims[name].(type) {
+		case string:
+			if value != "" {
+				return value
+			}
+		case float64:
+			return fmt.Sprintf("%.0f", value)
+		}
+	}
+	return ""
+}
+
+// Providers - настроенные провайдеры по имени
+type Providers map[string]*Provider
+
+// NewProviders создает провайдеров из конфигурации
+func NewProviders(configs []Config) Providers {
+	providers := make(Providers, len(configs))
+	for _, config := range configs {
+		providers[config.Name] = NewProvider(config)
+	}
+	return providers
+}
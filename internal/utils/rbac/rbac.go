@@ -0,0 +1,60 @@
+package rbac
+
+import (
+	"strings"
+
+	dbmodels "gera-ai/internal/models/database"
+)
+
+// Права, которые проверяются на уровне маршрутов
+const (
+	PermissionModerateLibrary = "library:moderate"
+	PermissionManageUsers     = "users:manage"
+	PermissionViewUsage       = "usage:view"
+	PermissionManagePrompts   = "prompts:manage"
+)
+
+// rolePermissions - права каждой роли. Учитель работает только со своими данными
+var rolePermissions = map[string][]string{
+	dbmodels.UserRoleTeacher:   {},
+	dbmodels.UserRoleModerator: {PermissionModerateLibrary},
+	dbmodels.UserRoleAdmin: {
+		PermissionModerateLibrary,
+		PermissionManageUsers,
+		PermissionViewUsage,
+		PermissionManagePrompts,
+	},
+}
+
+// Has проверяет, что у роли есть право. Неизвестная роль не имеет прав
+func Has(role, permission string) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Области доступа персональных токенов. Запрос с токеном проходит только на маршруты,
+// которые явно разрешают одну из его областей; вход по паролю разрешает все
+const (
+	ScopeTasksRead    = "tasks:read"
+	ScopeTasksWrite   = "tasks:write"
+	ScopeGenerate     = "generate"
+	ScopeHistoryRead  = "history:read"
+	ScopeHistoryWrite = "history:write"
+)
+
+// Scopes - все области доступа, которые можно выдать токену
+var Scopes = []string{ScopeTasksRead, ScopeTasksWrite, ScopeGenerate, ScopeHistoryRead, ScopeHistoryWrite}
+
+// HasScope проверяет, что среди областей токена (через пробел) есть scope
+func HasScope(scopes, scope string) bool {
+	for _, s := range strings.Fields(scopes) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
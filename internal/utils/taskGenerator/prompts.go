@@ -0,0 +1,117 @@
+package taskGenerator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Ключи промптов
+const (
+	PromptInterests      = "interests"
+	PromptNoInterests    = "nointerests"
+	PromptAnswer         = "answer"
+	PromptClassification = "classification"
+)
+
+// PromptKeys - все промпты, которые можно изменить
+var PromptKeys = []string{PromptInterests, PromptNoInterests, PromptAnswer, PromptClassification}
+
+// DefaultPrompts - промпты версии PromptVersion.
+// Значения подставляются вместо плейсхолдеров в фигурных скобках
+var DefaultPrompts = map[string]string{
+	PromptInterests: `условие: {condition}
+добавь в это условие сюжет по следующим интересам: {interests}.
+Сделай сюжет максимально связным с интересами человека, добавь туда юмор, можешь черный, чтобы ему было интересно решать задачу.
+Не усложняй условие. Не меняй значения в условии.
+Не пиши ответ и не обьясняй задачу. Скинь новую задачу с добавленным в нее сюжетом. Увеличивай ее ДО 1.5x, где x - исходный размер задачи.
+Размер задачи должен быть не больше 1000 символов.
+Выдай только текст нового условия.`,
+	PromptNoInterests: `условие: {condition}
+Добавь сюжет в задачу, сделай ее максимально приближенной к реальной, суровой жизни Русских. Не меняй ответ на задачу.
+Не пиши ответ и не обьясняй задачу.
+Скинь новую задачу с добавленным в нее сюжетом. Увеличивай ее ДО 1.5x, где x - исходный размер задачи.
+Размер задачи должен быть не больше 1000 символов.
+Выдай только текст нового условия.`,
+	PromptAnswer: `условие: {condition}
+Сделай разбор задачи. Раскрой весь сюжет. Покажи формулы в этой задаче и темы, на которые нацелена эта задача.
+Добавь туда юмор.
+Размер разбора должен быть не больше 100 символов.
+Выдай только текст разбора задачи. Формулы пиши обычным текстом.`,
+	PromptClassification: `условие: {condition}
+Классифицируй эту задачу. Ответь только JSON объектом без пояснений и без markdown, в формате:
+{"subject": "...", "topic": "...", "formulas": ["..."], "difficulty": 1}
+subject - один из кодов: {subjects}.
+topic - тема задачи, не больше 100 символов.
+formulas - формулы, которые нужны для решения, обычным текстом.
+difficulty - сложность от {min_difficulty} (очень легко) до {max_difficulty} (олимпиадный уровень).`,
+}
+
+// PromptPlaceholders - плейсхолдеры, без которых промпт не имеет смысла
+var PromptPlaceholders = map[string][]string{
+	PromptInterests:      {"{condition}", "{interests}"},
+	PromptNoInterests:    {"{condition}"},
+	PromptAnswer:         {"{condition}"},
+	PromptClassification: {"{condition}", "{subjects}"},
+}
+
+// Prompt - промпт, измененный администратором
+type Prompt struct {
+	Text string
+	// Revision растет при каждом изменении и входит в версию промпта
+	Revision int
+}
+
+// ValidatePrompt проверяет, что ключ известен и текст содержит все обязательные плейсхолдеры
+func ValidatePrompt(key, text string) error {
+	placeholders, ok := PromptPlaceholders[key]
+	if !ok {
+		return fmt.Errorf("unknown prompt %q", key)
+	}
+	for _, placeholder := range placeholders {
+		if !strings.Contains(text, placeholder) {
+			return fmt.Errorf("prompt must contain %s", placeholder)
+		}
+	}
+	return nil
+}
+
+// SetPrompt заменяет промпт по умолчанию
+func (tg *TaskGenerator) SetPrompt(key string, prompt Prompt) {
+	tg.mu.Lock()
+	defer tg.mu.Unlock()
+	tg.prompts[key] = prompt
+}
+
+// ResetPrompt возвращает промпт по умолчанию
+func (tg *TaskGenerator) ResetPrompt(key string) {
+	tg.mu.Lock()
+	defer tg.mu.Unlock()
+	delete(tg.prompts, key)
+}
+
+// PromptText возвращает текущий текст промпта и признак того, что он изменен
+func (tg *TaskGenerator) PromptText(key string) (string, bool) {
+	tg.mu.RLock()
+	defer tg.mu.RUnlock()
+	if prompt, ok := tg.prompts[key]; ok {
+		return prompt.Text, true
+	}
+	return DefaultPrompts[key], false
+}
+
+// PromptVersion возвращает версию промпта для сохранения вместе с генерацией.
+// Для измененного промпта к PromptVersion добавляется номер правки, например v1-r3
+func (tg *TaskGenerator) PromptVersion(key string) string {
+	tg.mu.RLock()
+	defer tg.mu.RUnlock()
+	if prompt, ok := tg.prompts[key]; ok {
+		return fmt.Sprintf("%s-r%d", PromptVersion, prompt.Revision)
+	}
+	return PromptVersion
+}
+
+// render подставляет значения в промпт. replacements - пары плейсхолдер, значение
+func (tg *TaskGenerator) render(key string, replacements ...string) string {
+	text, _ := tg.PromptText(key)
+	return strings.NewReplacer(replacements...).Replace(text)
+}
@@ -1,19 +1,34 @@
 package taskGenerator
 
 import (
+	"encoding/json"
 	"fmt"
 	"gera-ai/internal/utils/openai"
+	"strconv"
 	"strings"
+	"sync"
 )
 
 const (
-	maxOpenAITokens = 100
-	openAIModel     = "gpt-3.5-turbo"
+	maxOpenAITokens         = 100
+	maxClassificationTokens = 300
+	openAIModel             = "gpt-3.5-turbo"
+
+	MinDifficulty = 1
+	MaxDifficulty = 5
+
+	// PromptVersion нужно менять при каждом изменении текста промптов,
+	// чтобы генерации разных версий можно было сравнивать
+	PromptVersion = "v1"
 )
 
 // TaskGenerator предоставляет функции для генерации и анализа задач
 type TaskGenerator struct {
 	client *openai.Client
+
+	// prompts - промпты, измененные администратором, поверх DefaultPrompts
+	mu      sync.RWMutex
+	prompts map[string]Prompt
 }
 
 // NewTaskGenerator создает новый TaskGenerator
@@ -26,21 +41,27 @@ func NewTaskGenerator(apiKey, proxyURL string) (*TaskGenerator, error) {
 		return nil, fmt.Errorf("failed to create OpenAI client: %w", err)
 	}
 
-	return &TaskGenerator{client: client}, nil
+	return &TaskGenerator{client: client, prompts: map[string]Prompt{}}, nil
+}
+
+// Model возвращает модель OpenAI, которой выполняются генерации
+func (tg *TaskGenerator) Model() string {
+	return openAIModel
+}
+
+// Parameters возвращает параметры запроса к OpenAI для сохранения вместе с генерацией
+func (tg *TaskGenerator) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"max_tokens": maxOpenAITokens,
+	}
 }
 
 // GenerateTaskWithInterests генерирует задачу с учетом интересов
 func (tg *TaskGenerator) GenerateTaskWithInterests(condition string, interests []string) (string, error) {
 	// Формируем запрос с учетом интересов
-	prompt := fmt.Sprintf(
-		`условие: %s
-добавь в это условие сюжет по следующим интересам: %s.
-Сделай сюжет максимально связным с интересами человека, добавь туда юмор, можешь черный, чтобы ему было интересно решать задачу.
-Не усложняй условие. Не меняй значения в условии.
-Не пиши ответ и не обьясняй задачу. Скинь новую задачу с добавленным в нее сюжетом. Увеличивай ее ДО 1.5x, где x - исходный размер задачи.
-Размер задачи должен быть не больше 1000 символов.
-Выдай только текст нового условия.`,
-		condition, strings.Join(interests, "; "),
+	prompt := tg.render(PromptInterests,
+		"{condition}", condition,
+		"{interests}", strings.Join(interests, "; "),
 	)
 
 	// Вызываем OpenAI API с подготовленным запросом
@@ -60,15 +81,7 @@ func (tg *TaskGenerator) GenerateTaskWithInterests(condition string, interests [
 // GenerateTaskWithNoInterests генерирует задачу с "реалистичным" сюжетом
 func (tg *TaskGenerator) GenerateTaskWithNoInterests(condition string) (string, error) {
 	// Формируем запрос с "реалистичным" сюжетом
-	prompt := fmt.Sprintf(
-		`условие: %s
-Добавь сюжет в задачу, сделай ее максимально приближенной к реальной, суровой жизни Русских. Не меняй ответ на задачу.
-Не пиши ответ и не обьясняй задачу.
-Скинь новую задачу с добавленным в нее сюжетом. Увеличивай ее ДО 1.5x, где x - исходный размер задачи.
-Размер задачи должен быть не больше 1000 символов.
-Выдай только текст нового условия.`,
-		condition,
-	)
+	prompt := tg.render(PromptNoInterests, "{condition}", condition)
 
 	// Вызываем OpenAI API с подготовленным запросом
 	response, err := tg.client.CallOpenAI(prompt, openAIModel, maxOpenAITokens)
@@ -87,14 +100,7 @@ func (tg *TaskGenerator) GenerateTaskWithNoInterests(condition string) (string,
 // GenerateAnswer делает разбор задачи
 func (tg *TaskGenerator) GenerateAnswer(condition string) (string, error) {
 	// Формируем запрос для анализа задачи
-	prompt := fmt.Sprintf(
-		`условие: %s
-Сделай разбор задачи. Раскрой весь сюжет. Покажи формулы в этой задаче и темы, на которые нацелена эта задача.
-Добавь туда юмор.
-Размер разбора должен быть не больше 100 символов.
-Выдай только текст разбора задачи. Формулы пиши обычным текстом.`,
-		condition,
-	)
+	prompt := tg.render(PromptAnswer, "{condition}", condition)
 
 	// Вызываем OpenAI API с подготовленным запросом
 	response, err := tg.client.CallOpenAI(prompt, openAIModel, maxOpenAITokens)
@@ -109,3 +115,54 @@ func (tg *TaskGenerator) GenerateAnswer(condition string) (string, error) {
 	// Возвращаем сгенерированный текст
 	return response.Choices[0].Message.Content, nil
 }
+
+// Classification - структурированная классификация задачи
+type Classification struct {
+	Subject    string   `json:"subject"`
+	Topic      string   `json:"topic"`
+	Formulas   []string `json:"formulas"`
+	Difficulty int      `json:"difficulty"`
+}
+
+// ClassifyTask определяет предмет, тему, нужные формулы и сложность задачи.
+// subjects - коды предметов, из которых модель должна выбрать один
+func (tg *TaskGenerator) ClassifyTask(condition string, subjects []string) (Classification, error) {
+	prompt := tg.render(PromptClassification,
+		"{condition}", condition,
+		"{subjects}", strings.Join(subjects, ", "),
+		"{min_difficulty}", strconv.Itoa(MinDifficulty),
+		"{max_difficulty}", strconv.Itoa(MaxDifficulty),
+	)
+
+	response, err := tg.client.CallOpenAI(prompt, openAIModel, maxClassificationTokens)
+	if err != nil {
+		return Classification{}, fmt.Errorf("failed to classify task: %w", err)
+	}
+
+	if len(response.Choices) == 0 {
+		return Classification{}, fmt.Errorf("no response from OpenAI")
+	}
+
+	// Модель иногда оборачивает JSON в markdown, поэтому берем текст между первой и последней скобкой
+	content := response.Choices[0].Message.Content
+	start, end := strings.Index(content, "{"), strings.LastIndex(content, "}")
+	if start == -1 || end < start {
+		return Classification{}, fmt.Errorf("classification is not a JSON object: %q", content)
+	}
+
+	var classification Classification
+	if err := json.Unmarshal([]byte(content[start:end+1]), &classification); err != nil {
+		return Classification{}, fmt.Errorf("failed to decode classification: %w", err)
+	}
+
+	if classification.Difficulty < MinDifficulty {
+		classification.Difficulty = MinDifficulty
+	} else if classification.Difficulty > MaxDifficulty {
+		classification.Difficulty = MaxDifficulty
+	}
+	if classification.Formulas == nil {
+		classification.Formulas = []string{}
+	}
+
+	return classification, nil
+}
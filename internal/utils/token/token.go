@@ -0,0 +1,50 @@
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// Generate создает случайный токен из 32 байт в base64url
+func Generate() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// Hash возвращает SHA-256 от токена. В базе хранится только хеш
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Sign возвращает HMAC-SHA256 от message в hex. Им подписываются ссылки, которые работают без входа
+func Sign(secret, message string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// DeriveKey выводит из secret отдельный ключ для purpose (HKDF-SHA256).
+// Ключи с разным purpose независимы, поэтому подпись одного назначения не подходит для другого
+func DeriveKey(secret, purpose string) string {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, []byte(secret), nil, []byte(purpose)), key); err != nil {
+		// HKDF-SHA256 может выдать до 8160 байт, 32 байта доступны всегда
+		panic(err)
+	}
+	return hex.EncodeToString(key)
+}
+
+// Verify проверяет подпись, сделанную Sign, за постоянное время
+func Verify(secret, message, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, message)), []byte(signature))
+}
@@ -0,0 +1,22 @@
+package token
+
+import "testing"
+
+func TestDeriveKey(t *testing.T) {
+	key := DeriveKey("jwt-secret", "data export")
+	if len(key) != 64 {
+		t.Fatalf("DeriveKey returned %d hex characters, want 64", len(key))
+	}
+	if key != DeriveKey("jwt-secret", "data export") {
+		t.Fatal("DeriveKey is not deterministic")
+	}
+	if key == DeriveKey("jwt-secret", "other purpose") || key == DeriveKey("other-secret", "data export") {
+		t.Fatal("DeriveKey returned the same key for a different secret or purpose")
+	}
+
+	// Подпись производным ключом не проверяется исходным секретом и наоборот
+	signature := Sign(key, "message")
+	if Verify("jwt-secret", "message", signature) || !Verify(key, "message", signature) {
+		t.Fatal("a signature made with the derived key must verify only with that key")
+	}
+}